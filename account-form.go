@@ -95,7 +95,7 @@ func NewAccountSearchTab(box chan fyne.Container, account *fio.Account) {
 					errs.ErrChan <- err.Error()
 					return
 				}
-				ao, err := info.report()
+				ao, err := accountReport(info)
 				if err != nil {
 					errs.ErrChan <- err.Error()
 					return
@@ -128,7 +128,7 @@ func NewAccountSearchTab(box chan fyne.Container, account *fio.Account) {
 	)
 }
 
-func (as *AccountInformation) report() (*widget.Box, error) {
+func accountReport(as *AccountInformation) (*widget.Box, error) {
 	if as.Actor == "" || as.PubKey == "" {
 		return nil, errors.New("nothing to report, account or key name empty")
 	}
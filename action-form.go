@@ -5,8 +5,10 @@ import (
 	"fmt"
 	"fyne.io/fyne"
 	"fyne.io/fyne/layout"
+	"fyne.io/fyne/theme"
 	"fyne.io/fyne/widget"
 	fioassets "github.com/blockpane/cryptonym/assets"
+	"github.com/blockpane/cryptonym/engine"
 	errs "github.com/blockpane/cryptonym/errLog"
 	"github.com/blockpane/cryptonym/fuzzer"
 	"github.com/fioprotocol/fio-go"
@@ -69,6 +71,7 @@ func GetAbiForm(action string, account *fio.Account, api *fio.API, opts *fio.TxO
 	abiState := NewAbi(len(abiStruct.Fields))
 	abiState.Contract = accountAction[0]
 	abiState.Action = accountAction[1]
+	abiState.Def = &abi.ABI
 	for i, deRef := range abiStruct.Fields {
 		fieldRef := &deRef
 		field := *fieldRef
@@ -100,15 +103,15 @@ func GetAbiForm(action string, account *fio.Account, api *fio.API, opts *fio.TxO
 
 		// count field, hidden by default
 		num := &widget.Select{}
-		num = widget.NewSelect(bytesLen, func(s string) {
+		num = widget.NewSelect(engine.BytesLen, func(s string) {
 			FormState.UpdateLen(field.Name, num)
 		})
 		num.Hide()
 
 		// variant field
 		variation := &widget.Select{}
-		variation = widget.NewSelect(formVar, func(s string) {
-			showNum, numVals, sel := getLength(s)
+		variation = widget.NewSelect(engine.FormVar, func(s string) {
+			showNum, numVals, sel := engine.LengthFor(s)
 			if showNum {
 				num.Show()
 			} else {
@@ -125,14 +128,15 @@ func GetAbiForm(action string, account *fio.Account, api *fio.API, opts *fio.TxO
 
 		// options for fuzzer
 		sendAs := &widget.Select{}
-		sendAs = widget.NewSelect(sendAsSelectTypes, func(send string) {
-			if !strings.Contains(send, "form value") {
+		sendAs = widget.NewSelect(engine.SendAsTypes, func(send string) {
+			// load file takes the path from the input box
+			if !strings.Contains(send, "form value") && send != "load file" {
 				inputBox.Hide()
 			} else {
 				inputBox.Show()
 			}
 			var sel string
-			variation.Options, sel = sendAsVariant(send)
+			variation.Options, sel = engine.SendAsVariant(send)
 			variation.SetSelected(sel)
 			FormState.UpdateSendAs(field.Name, sendAs)
 		})
@@ -188,6 +192,15 @@ func GetAbiForm(action string, account *fio.Account, api *fio.API, opts *fio.TxO
 	})
 	zlibPack.Checked = useZlib
 	zlibPack.Refresh()
+	// the seed is shown so a run can be replayed, uncheck "New Seed Each Send" to send the same payloads again
+	seedLabel := widget.NewLabel("Seed: ")
+	seedEntry := widget.NewEntry()
+	seedEntry.SetText(strconv.FormatInt(fuzzSeed, 10))
+	newSeedCheck := widget.NewCheck("New Seed Each Send", func(b bool) {
+		newSeedEachSend = b
+	})
+	newSeedCheck.Checked = newSeedEachSend
+	newSeedCheck.Refresh()
 	threadLabel := widget.NewLabel("Worker Count: ")
 	threadLabel.Hide()
 	threads := widget.NewSelect([]string{"1", "2", "4", "6", "8", "12", "16"}, func(s string) {})
@@ -230,17 +243,39 @@ func GetAbiForm(action string, account *fio.Account, api *fio.API, opts *fio.TxO
 	}
 	deferCheck.Checked = deferTx
 	deferCheck.Refresh()
+	// a load profile paces loop mode, all of these are optional
+	loadTps := widget.NewEntry()
+	loadTps.SetPlaceHolder("max speed")
+	loadRamp := widget.NewEntry()
+	loadRamp.SetPlaceHolder("2m or 5@0s,20@1m")
+	loadDuration := widget.NewEntry()
+	loadDuration.SetPlaceHolder("until stopped")
+	loadBudget := widget.NewEntry()
+	loadBudget.SetPlaceHolder("no limit")
+	loadRow := widget.NewHBox(
+		widget.NewLabel(" Target TPS:"),
+		fyne.NewContainerWithLayout(layout.NewFixedGridLayout(fyne.NewSize(100, 36)), loadTps),
+		widget.NewLabel("Ramp Up:"),
+		fyne.NewContainerWithLayout(layout.NewFixedGridLayout(fyne.NewSize(180, 36)), loadRamp),
+		widget.NewLabel("Duration:"),
+		fyne.NewContainerWithLayout(layout.NewFixedGridLayout(fyne.NewSize(120, 36)), loadDuration),
+		widget.NewLabel("Budget (FIO):"),
+		fyne.NewContainerWithLayout(layout.NewFixedGridLayout(fyne.NewSize(120, 36)), loadBudget),
+	)
+	loadRow.Hide()
 	infinite := widget.NewCheck("Loop", func(b bool) {
 		if b {
 			count.Disable()
 			threadLabel.Show()
 			threads.Show()
+			loadRow.Show()
 			return
 		}
 		count.Enable()
 		threadLabel.Hide()
 		threads.Hide()
 		threads.SetSelected("1")
+		loadRow.Hide()
 	})
 
 	err = EndPoints.Update(Uri, true)
@@ -264,11 +299,11 @@ func GetAbiForm(action string, account *fio.Account, api *fio.API, opts *fio.TxO
 		return s
 	}
 	requested := widget.NewEntry()
-	requested.SetText(getSigners(Settings.MsigAccount, api))
+	requested.SetText(getSigners(Settings.Profile().MsigAccount, api))
 	msig := &widget.Box{}
 	wrap := &widget.Box{}
 	proposer := widget.NewEntry()
-	proposer.SetText(Settings.MsigAccount)
+	proposer.SetText(Settings.Profile().MsigAccount)
 	innerActionActor := widget.NewEntry()
 	innerActionActor.SetText("eosio")
 	innerActionActor.Hide()
@@ -281,7 +316,7 @@ func GetAbiForm(action string, account *fio.Account, api *fio.API, opts *fio.TxO
 			proposer.SetText("eosio.wrap")
 			return
 		}
-		proposer.SetText(Settings.MsigAccount)
+		proposer.SetText(Settings.Profile().MsigAccount)
 		innerActionActor.Hide()
 		innerActionLabel.Hide()
 	})
@@ -350,23 +385,41 @@ func GetAbiForm(action string, account *fio.Account, api *fio.API, opts *fio.TxO
 		}
 	})
 
-	bombsAway = widget.NewButtonWithIcon("Send", fioassets.NewFioLogoResource(), func() {
+	// send opens the results window, compose is nil to send the editor's action
+	send := func(compose *engine.Composition) {
 		fuzzer.ResetIncrement()
+		seed, err := strconv.ParseInt(strings.TrimSpace(seedEntry.Text), 10, 64)
+		if err != nil && !newSeedEachSend {
+			errs.ErrChan <- "invalid seed, using a new one"
+		}
+		if err != nil || newSeedEachSend {
+			seed = fuzzer.NewSeed()
+		}
+		fuzzSeed = seed
+		seedEntry.SetText(strconv.FormatInt(seed, 10))
 		errs.ErrChan <- "generating transaction"
 		repeat, err := strconv.Atoi(count.Text)
 		if err != nil {
 			repeat = 1
 		}
+		load, err := loadProfile(loadTps.Text, loadRamp.Text, loadDuration.Text, loadBudget.Text)
+		if err != nil && infinite.Checked {
+			errs.ErrChan <- "load test: " + err.Error()
+			return
+		}
 		txWindowOpts.msig = proposeCheck.Checked
 		txWindowOpts.msigSigners = requested.Text
 		txWindowOpts.msigAccount = proposer.Text
 		txWindowOpts.repeat = repeat
 		txWindowOpts.loop = infinite.Checked
+		txWindowOpts.load = load
 		txWindowOpts.threads = threads.Selected
 		txWindowOpts.hideFail = hideFailed.Checked
 		txWindowOpts.hideSucc = hideSuccess.Checked
 		txWindowOpts.wrap = wrapCheck.Checked
 		txWindowOpts.wrapActor = innerActionActor.Text
+		txWindowOpts.seed = seed
+		txWindowOpts.compose = compose
 		if proposalRand.Checked {
 			txWindowOpts.msigName = randProposal
 		} else {
@@ -375,6 +428,34 @@ func GetAbiForm(action string, account *fio.Account, api *fio.API, opts *fio.TxO
 			}
 		}
 		TxResultsWindow(txWindowOpts, api, opts, account)
+	}
+	bombsAway = widget.NewButtonWithIcon("Send", fioassets.NewFioLogoResource(), func() {
+		go checkMaxFee(api, account)
+		send(nil)
+	})
+	sendComposition = func() {
+		send(Composer)
+	}
+	dryRunButton := widget.NewButtonWithIcon("Dry Run", theme.SearchIcon(), func() {
+		go dryRun(nil, proposeCheck.Checked)
+	})
+
+	// the composer stacks this action with others into one transaction
+	composeAuth := widget.NewEntry()
+	composeAuth.SetPlaceHolder("actor@active (optional)")
+	composeButton := widget.NewButtonWithIcon("Add to Tx", theme.ContentAddIcon(), func() {
+		auth, err := engine.ParseAuthorization(composeAuth.Text)
+		if err != nil {
+			errs.ErrChan <- err.Error()
+			return
+		}
+		abiState.mux.RLock()
+		a := engine.NewAction(abiState.Contract, abiState.Action, nil, abiState.Def)
+		abiState.mux.RUnlock()
+		a.Fields = abiState.Fields()
+		Composer.Add(engine.Composed{Action: a, Authorization: auth})
+		errs.ErrChan <- p.Sprintf("added %s::%s to the composer, %d actions", a.Contract, a.Action, Composer.Len())
+		ComposerWindow()
 	})
 
 	reqToSend := widget.NewLabel("Requests to send")
@@ -387,12 +468,18 @@ func GetAbiForm(action string, account *fio.Account, api *fio.API, opts *fio.TxO
 		hideFailed.Hide()
 		hideSuccess.Hide()
 		zlibPack.Hide()
+		seedLabel.Hide()
+		seedEntry.Hide()
+		newSeedCheck.Hide()
 		deferCheck.Hide()
 		delaySec.Hide()
+		composeButton.Hide()
+		composeAuth.Hide()
 	}
 	bottom := widget.NewHBox(
 		widget.NewLabel(" "),
 		bombsAway,
+		dryRunButton,
 		reqToSend,
 		count,
 		infinite,
@@ -401,10 +488,17 @@ func GetAbiForm(action string, account *fio.Account, api *fio.API, opts *fio.TxO
 		hideFailed,
 		hideSuccess,
 		zlibPack,
+		seedLabel,
+		seedEntry,
+		newSeedCheck,
 		deferCheck,
 		delaySec,
 		proposeCheck,
+		composeButton,
+		composeAuth,
+		watchOnlyNotice(),
 	)
+	disableWatchOnly(bombsAway)
 	newRowName := widget.NewEntry()
 	newRowName.SetPlaceHolder("New Row Name")
 	label := widget.NewLabel(action)
@@ -426,6 +520,7 @@ func GetAbiForm(action string, account *fio.Account, api *fio.API, opts *fio.TxO
 			form,
 			layout.NewSpacer(),
 			bottom,
+			loadRow,
 			msig,
 			wrap,
 			widget.NewHBox(
@@ -6,8 +6,10 @@ import (
 	"fyne.io/fyne/layout"
 	"fyne.io/fyne/theme"
 	"fyne.io/fyne/widget"
+	"github.com/blockpane/cryptonym/engine"
 	errs "github.com/blockpane/cryptonym/errLog"
 	"github.com/fioprotocol/fio-go"
+	"github.com/fioprotocol/fio-go/eos"
 	"reflect"
 	"strings"
 	"sync"
@@ -38,6 +40,8 @@ type Abi struct {
 	Rows     []AbiFormItem
 	Action   string
 	Contract string
+	// Def is the contract's abi, used by the "abi struct" generator
+	Def *eos.ABI
 }
 
 func NewAbi(length int) *Abi {
@@ -87,21 +91,22 @@ func (abi *Abi) AppendRow(myName string, account *fio.Account, form *widget.Form
 		})
 		typeSelect.SetSelected("string")
 
-		sendAs = widget.NewSelect(sendAsSelectTypes, func(send string) {
-			if !strings.Contains(send, "form value") {
+		sendAs = widget.NewSelect(engine.SendAsTypes, func(send string) {
+			// load file takes the path from the input box
+			if !strings.Contains(send, "form value") && send != "load file" {
 				inputBox.Hide()
 			} else {
 				inputBox.Show()
 			}
 			var sel string
-			variation.Options, sel = sendAsVariant(send)
+			variation.Options, sel = engine.SendAsVariant(send)
 			variation.SetSelected(sel)
 			FormState.UpdateSendAs(myName, sendAs)
 		})
 
 		sendAs.SetSelected("bytes/string")
-		variation = widget.NewSelect(bytesVar, func(s string) {
-			showNum, numVals, sel := getLength(s)
+		variation = widget.NewSelect(engine.BytesVar, func(s string) {
+			showNum, numVals, sel := engine.LengthFor(s)
 			if showNum {
 				num.Show()
 			} else {
@@ -114,7 +119,7 @@ func (abi *Abi) AppendRow(myName string, account *fio.Account, form *widget.Form
 		})
 		variation.SetSelected("many AAAA...")
 
-		num = widget.NewSelect(bytesLen, func(s string) {
+		num = widget.NewSelect(engine.BytesLen, func(s string) {
 			FormState.UpdateLen(myName, num)
 		})
 		num.SetSelected("131,072")
@@ -53,184 +53,6 @@ func abiSelectTypes(mustExist string) []string {
 	return types
 }
 
-var sendAsSelectTypes = []string{
-	"form value",
-	"actor",
-	"pub key",
-	"fio types",
-	"number",
-	"bytes/string",
-	//"load file",
-}
-
-var bytesVar = []string{
-	"bytes",
-	"bytes: base64 encoded",
-	"bytes: hex encoded",
-	"random checksum",
-	"string",
-}
-
-var bytesLen = []string{
-	"random length",
-	"8",
-	"12",
-	"16",
-	"32",
-	"64",
-	"128",
-	"256",
-	"512",
-	"2,048",
-	"4,096",
-	//"8,192",
-	//"16,384",
-	//"32,768",
-	//"65,536",
-	//"131,072",
-	//"262,144",
-	//"524,288",
-	//"1,048,576",
-	//"2,097,152",
-	//"4,194,304",
-	//"8,388,608",
-	//"16,777,216",
-}
-
-var formVar = []string{
-	"as is",
-	"FIO -> suf",
-	"json -> struct",
-	"base64 -> byte[]",
-	"checksum256",
-	"fio address@ (invalid)",
-	"fio address@ (valid)",
-	"fio address@ (valid, max size)",
-	"hex -> byte[]",
-	"signature",
-}
-
-var actorVar = []string{
-	"mine",
-	"random",
-}
-
-var numericVar = []string{
-	"incrementing float",
-	"incrementing int",
-	"random float",
-	"random int",
-	"overflow int",
-	"random number (mixed)",
-	"max int",
-}
-
-var maxIntVar = []string{
-	"int8",
-	"uint8",
-	"int16",
-	"uint16",
-	"int32",
-	"uint32",
-	"int64",
-	"uint64",
-}
-
-var fioVar = []string{
-	"invalid fio domain",
-	"valid fio domain",
-	"valid fio domain (max size)",
-	"max length: newfundsreq.content",
-	"max length: recordobt.content",
-	"max length: regproducer.url",
-	"max length: voteproducer.producers",
-	"max length: addaddress.public_addresses",
-	"variable length: addaddress.public_addresses",
-	//TODO:
-	//"string[] of existing fio address",
-}
-
-//TODO: "string[] of existing fio address"....
-var addressLen = []string{
-	"2",
-	"4",
-	"8",
-	"16",
-	"32",
-}
-
-var floatLen = []string{
-	"32",
-	"64",
-}
-
-var intLen = []string{
-	"8",
-	"16",
-	"32",
-	"64",
-	"128",
-}
-
-var overflowLen = []string{
-	"8",
-	"16",
-	"32",
-}
-
-var numAddresses = []string{
-	"1",
-	"2",
-	"3",
-	"4",
-	"5",
-	"10",
-	"50",
-	"100",
-	"1000",
-}
-
-func sendAsVariant(kind string) (options []string, selected string) {
-	switch kind {
-	case "form value":
-		return formVar, "as is"
-	case "actor":
-		return actorVar, "mine"
-	case "pub key":
-		return actorVar, "mine"
-	case "number":
-		return numericVar, "random int"
-	case "bytes/string":
-		return bytesVar, "string"
-	case "fio types":
-		return fioVar, "invalid fio domain"
-	}
-	return []string{}, "--"
-}
-
-func getLength(what string) (show bool, values []string, selected string) {
-	switch {
-	case what == "random float":
-		return true, floatLen, "32"
-	case what == "variable length addaddress.public_addresses":
-		return true, numAddresses, "1"
-	case what == "random int":
-		return true, intLen, "32"
-	case what == "overflow int":
-		return true, overflowLen, "16"
-	case what == "max int":
-		return true, maxIntVar, "int32"
-	case what == "random number (mixed)":
-		return false, []string{""}, ""
-	case strings.HasPrefix(what, "string") ||
-		strings.HasPrefix(what, "bytes") ||
-		strings.HasPrefix(what, "nop") ||
-		strings.HasPrefix(what, "many"):
-		return true, bytesLen, "64"
-	}
-	return
-}
-
 func defaultValues(contract string, action string, fieldName string, fieldType string, account *fio.Account, api *fio.API) string {
 	var returnValue string
 	switch {
@@ -239,18 +61,13 @@ func defaultValues(contract string, action string, fieldName string, fieldType s
 	case fieldName == "bundled_transactions":
 		return "100"
 	case fieldName == "max_fee":
-		api2, _, err := fio.NewConnection(nil, api.BaseURL)
+		// the table fee, not what the account's address would pay, a max_fee has to cover it once bundles run out
+		fee, err := Fees.ForAction(api, action, "")
 		if err != nil {
-			return "0"
-		}
-		api2.Header.Set("User-Agent", "fio-cryptonym-wallet")
-		fio.UpdateMaxFees(api2)
-		fee := fio.GetMaxFeeByAction(action)
-		if fee == 0 {
 			// as expensive as it gets ... pretty safe to return
-			fee = fio.GetMaxFee("register_fio_domain")
+			fee = fio.Tokens(liveFee(api, fio.FeeRegisterFioDomain))
 		}
-		returnValue = p.Sprintf("%.9f", fee)
+		returnValue = p.Sprintf("%.9f", float64(fee)/1_000_000_000.0)
 	case fieldName == "can_vote":
 		returnValue = "1"
 	case fieldName == "is_public":
@@ -309,7 +126,7 @@ func defaultValues(contract string, action string, fieldName string, fieldType s
 	case strings.Contains(fieldName, "public") || strings.HasSuffix(fieldName, "_key"):
 		returnValue = account.PubKey
 	case fieldName == "tpid":
-		returnValue = Settings.Tpid
+		returnValue = Settings.Profile().Tpid
 	case strings.HasSuffix(fieldName, "_address") || strings.HasPrefix(fieldName, "pay"):
 		returnValue = DefaultFioAddress
 	case fieldName == "authority" || (fieldName == "permission" && fieldType == "name"):
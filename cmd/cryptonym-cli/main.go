@@ -0,0 +1,501 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/blockpane/cryptonym/engine"
+	"github.com/blockpane/cryptonym/fuzzer"
+	"github.com/fioprotocol/fio-go"
+	"gopkg.in/yaml.v3"
+	"io/ioutil"
+	"math"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const usage = `usage:
+  cryptonym-cli [options] contracts             list contracts and their actions
+  cryptonym-cli [options] fees [fio address]    list the live fees, and what the address would pay after bundles
+  cryptonym-cli [options] send <action file>    build an action from a .json or .yaml file, sign and push it
+  cryptonym-cli [options] run <scenario> ...    run scenario files and report which steps passed
+  cryptonym-cli [options] fuzz <contract> <action> [valid|boundary|invalid]
+                                                fill every field from the abi, nested structs included
+  cryptonym-cli [options] mutate <contract> <action> <data file> ...
+                                                mutate action data that worked, or exported reproducers
+  cryptonym-cli [options] export <action file> ...
+                                                build one unsigned transaction from the actions for offline signing
+  cryptonym-cli [options] sign <transaction file>
+                                                review and sign an exported transaction, this never connects to a node
+  cryptonym-cli [options] push <transaction file>
+                                                push a transaction that was signed offline
+
+The private key is read from the CRYPTONYM_WIF environment variable, or the file given with -key-file. It is
+optional for scenarios that don't send actions. Export doesn't need it if -pub is set, the transaction file
+can be json or the text of its qr codes, one per line.
+
+Send, fuzz and mutate become a load test with -tps, -ramp, -duration or -budget, they keep sending until the
+duration or budget is reached or ^C.
+
+options:`
+
+func main() {
+	os.Exit(cli())
+}
+
+func cli() int {
+	var (
+		url       string
+		keyFile   string
+		endpoint  string
+		repeat    int
+		zlib      bool
+		allowFail bool
+		report    string
+		seed      int64
+		iteration int
+		out       string
+		qrDir     string
+		pub       string
+		expire    time.Duration
+		yes       bool
+		dryRun    bool
+		tps       float64
+		ramp      string
+		duration  time.Duration
+		budget    float64
+	)
+	flags := flag.NewFlagSet("cryptonym-cli", flag.ContinueOnError)
+	flags.StringVar(&url, "u", "http://127.0.0.1:8888", "nodeos url")
+	flags.StringVar(&keyFile, "key-file", "", "file holding the WIF private key to sign with")
+	flags.StringVar(&endpoint, "endpoint", "/v1/chain/push_transaction", "endpoint transactions are sent to")
+	flags.IntVar(&repeat, "n", 1, "number of times to send the action, generators run again for each")
+	flags.BoolVar(&zlib, "zlib", false, "compress transactions")
+	flags.BoolVar(&allowFail, "allow-fail", false, "exit 0 even if a transaction fails, useful when fuzzing")
+	flags.StringVar(&report, "report", "", "write the scenario results to a .json or .yaml file")
+	flags.Int64Var(&seed, "seed", 0, "seed for the generators, a run can be replayed with the same seed (default is a new seed)")
+	flags.IntVar(&iteration, "iteration", 0, "first iteration to send, with -seed and -n 1 this replays a single transaction")
+	flags.StringVar(&out, "o", "", "file the exported or signed transaction is written to")
+	flags.StringVar(&qrDir, "qr", "", "also write the exported or signed transaction as a sequence of qr code images to this directory")
+	flags.StringVar(&pub, "pub", "", "public key of the offline signer, the default actor when exporting")
+	flags.DurationVar(&expire, "expire", engine.MaxOfflineExpiration, "how long an exported transaction has to be signed and pushed")
+	flags.BoolVar(&yes, "y", false, "sign without asking")
+	flags.BoolVar(&dryRun, "dry-run", false, "report the size, fee and required keys of each transaction instead of sending it")
+	flags.Float64Var(&tps, "tps", 0, "target transactions per second, 0 is as fast as possible")
+	flags.StringVar(&ramp, "ramp", "", "ramp up to -tps over a duration (2m), or in steps of rate@time (5@0s,20@1m,50@3m)")
+	flags.DurationVar(&duration, "duration", 0, "stop sending after this long, without -n it keeps sending until then")
+	flags.Float64Var(&budget, "budget", 0, "stop once this much FIO has been spent, checked against the balance and fee estimates")
+	flags.Usage = func() {
+		fmt.Fprintln(os.Stderr, usage)
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(os.Args[1:]); err != nil {
+		return 2
+	}
+	args := flags.Args()
+	if len(args) == 0 {
+		flags.Usage()
+		return 2
+	}
+
+	// ^C cancels any requests in flight
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt)
+	go func() {
+		<-interrupt
+		cancel()
+	}()
+
+	if seed == 0 {
+		seed = fuzzer.NewSeed()
+	}
+	var err error
+	gen := generator{seed: seed, first: iteration, repeat: repeat, dryRun: dryRun}
+	gen.load.Tps, gen.load.Duration, gen.load.Budget = tps, duration, fio.Tokens(budget)
+	if gen.load.Ramp, err = engine.ParseRamp(ramp, tps); err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		return 2
+	}
+	// a load test runs until it's stopped unless -n is given
+	if gen.load.Active() {
+		gen.repeat = math.MaxInt32
+		flags.Visit(func(f *flag.Flag) {
+			if f.Name == "n" {
+				gen.repeat = repeat
+			}
+		})
+	}
+
+	switch {
+	case args[0] == "contracts" && len(args) == 1:
+		err = contracts(ctx, url)
+	case args[0] == "fees" && len(args) <= 2:
+		payer := ""
+		if len(args) == 2 {
+			payer = args[1]
+		}
+		err = fees(ctx, url, payer)
+	case args[0] == "send" && len(args) == 2:
+		var action *engine.Action
+		var failed int
+		if action, err = engine.LoadAction(args[1]); err == nil {
+			failed, err = send(ctx, url, keyFile, action, "", endpoint, gen, zlib)
+		}
+		if err == nil && failed > 0 && !allowFail {
+			return 1
+		}
+	case args[0] == "fuzz" && (len(args) == 3 || len(args) == 4):
+		mode := "valid"
+		if len(args) == 4 {
+			mode = args[3]
+		}
+		var failed int
+		action := &engine.Action{Contract: args[1], Action: args[2]}
+		failed, err = send(ctx, url, keyFile, action, mode, endpoint, gen, zlib)
+		if err == nil && failed > 0 && !allowFail {
+			return 1
+		}
+	case args[0] == "mutate" && len(args) > 3:
+		var failed int
+		if gen.corpus, err = loadCorpus(args[3:]); err == nil {
+			action := &engine.Action{Contract: args[1], Action: args[2]}
+			failed, err = send(ctx, url, keyFile, action, "", endpoint, gen, zlib)
+		}
+		if err == nil && failed > 0 && !allowFail {
+			return 1
+		}
+	case args[0] == "export" && len(args) > 1:
+		err = export(ctx, url, keyFile, pub, args[1:], engine.PackOptions{Compress: zlib}, expire, out, qrDir)
+	case args[0] == "sign" && len(args) == 2:
+		err = signOffline(keyFile, args[1], out, qrDir, yes)
+	case args[0] == "push" && len(args) == 2:
+		err = pushSigned(ctx, url, endpoint, args[1])
+	case args[0] == "run" && len(args) > 1:
+		var ok bool
+		ok, err = run(ctx, url, keyFile, args[1:], report)
+		if err == nil && !ok {
+			return 1
+		}
+	default:
+		flags.Usage()
+		return 2
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, args[0]+": "+err.Error())
+		return 1
+	}
+	return 0
+}
+
+func contracts(ctx context.Context, url string) error {
+	session, err := engine.NewSession(ctx, url, nil)
+	if err != nil {
+		return err
+	}
+	c, err := session.GetContracts(ctx)
+	if err != nil {
+		return err
+	}
+	for contract, err := range c.Errors {
+		fmt.Fprintf(os.Stderr, "could not load abi for %s: %s\n", contract, err.Error())
+	}
+	for _, contract := range c.Index {
+		fmt.Printf("%s: %s\n", contract, strings.Join(c.Actions[contract], ", "))
+	}
+	return nil
+}
+
+func fees(ctx context.Context, url string, payer string) error {
+	session, err := engine.NewSession(ctx, url, nil)
+	if err != nil {
+		return err
+	}
+	api, err := session.Api(ctx)
+	if err != nil {
+		return err
+	}
+	service := engine.NewFeeService(engine.DefaultFeeWindow)
+	table, err := service.Table(api)
+	if err != nil {
+		return err
+	}
+	multiplier, _ := service.Multiplier(api)
+	fmt.Printf("fee multiplier %g\n", multiplier)
+	for _, f := range table {
+		line := fmt.Sprintf("%-28s %18s", f.EndPoint, engine.FioString(f.Suf))
+		if payer != "" {
+			fee, err := service.Fee(api, f.EndPoint, payer)
+			if err != nil {
+				return err
+			}
+			line += fmt.Sprintf("  %18s", engine.FioString(fee))
+		}
+		if f.Bundled {
+			line += "  bundled"
+		}
+		if f.VotesPending {
+			line += "  votes pending"
+		}
+		fmt.Println(line)
+	}
+	return nil
+}
+
+func readWif(keyFile string) (string, error) {
+	if keyFile == "" {
+		if wif := os.Getenv("CRYPTONYM_WIF"); wif != "" {
+			return wif, nil
+		}
+		return "", errors.New("a private key is needed, set CRYPTONYM_WIF or use -key-file")
+	}
+	b, err := ioutil.ReadFile(keyFile)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(b)), nil
+}
+
+// generator is which iterations of a seeded run to send, if there is a corpus it's mutated instead of
+// generating new values
+type generator struct {
+	seed   int64
+	first  int
+	repeat int
+	corpus []json.RawMessage
+	dryRun bool
+	load   engine.LoadProfile
+}
+
+// loadCorpus reads action data, or the data from a reproducer exported by the gui
+func loadCorpus(files []string) ([]json.RawMessage, error) {
+	corpus := make([]json.RawMessage, 0, len(files))
+	for _, f := range files {
+		b, err := ioutil.ReadFile(f)
+		if err != nil {
+			return nil, err
+		}
+		repro := engine.Reproducer{}
+		if json.Unmarshal(b, &repro) == nil && repro.Contract != "" && len(repro.Data) > 0 {
+			b = repro.Data
+		}
+		if !json.Valid(b) {
+			return nil, errors.New(f + " is not valid json")
+		}
+		corpus = append(corpus, b)
+	}
+	return corpus, nil
+}
+
+// send pushes the action repeat times, printing the TxSummary for each. Returns how many failed. If fuzzMode
+// is set every field is sent as an "abi struct" using that mode.
+func send(ctx context.Context, url string, keyFile string, action *engine.Action, fuzzMode string, endpoint string, gen generator, zlib bool) (failed int, err error) {
+	wif, err := readWif(keyFile)
+	if err != nil {
+		return 0, err
+	}
+	account, err := fio.NewAccountFromWif(wif)
+	if err != nil {
+		return 0, err
+	}
+	session, err := engine.NewSession(ctx, url, account)
+	if err != nil {
+		return 0, err
+	}
+	api, err := session.Api(ctx)
+	if err != nil {
+		return 0, err
+	}
+	if err = action.FillFromAbi(api); err != nil {
+		return 0, err
+	}
+	if fuzzMode != "" {
+		if _, err = fuzzer.ParseMode(fuzzMode); err != nil {
+			return 0, err
+		}
+		for i := range action.Fields {
+			action.Fields[i].SendAs, action.Fields[i].Variation = "abi struct", fuzzMode
+		}
+	}
+
+	var mutation *engine.Mutation
+	if len(gen.corpus) > 0 {
+		mutation = &engine.Mutation{
+			Contract: action.Contract,
+			Action:   action.Action,
+			Fields:   action.Fields,
+			Corpus:   gen.corpus,
+			Names:    fuzzer.ExistingFioAddresses(url),
+		}
+	}
+
+	var load *engine.LoadRun
+	if gen.load.Active() {
+		fees := engine.NewFeeService(engine.DefaultFeeWindow)
+		load, err = gen.load.Start(
+			func() (uint64, error) {
+				b, err := api.GetFioBalance(account.PubKey)
+				if err != nil {
+					return 0, err
+				}
+				return b.Available, nil
+			},
+			func() (uint64, error) {
+				fee, err := fees.ForAction(api, action.Action, fees.PayerAddress(api, account.Actor))
+				if errors.Is(err, engine.ErrNoFee) {
+					return 0, nil
+				}
+				return fee, err
+			},
+		)
+		if err != nil {
+			return 0, err
+		}
+		fmt.Println("--- load test: " + gen.load.String())
+	}
+
+	repeat := gen.repeat
+	of := strconv.Itoa(repeat)
+	if repeat == math.MaxInt32 {
+		of = "-"
+	}
+	triage := engine.NewTriage()
+	fmt.Printf("--- seed %d\n", gen.seed)
+	for i := 0; i < repeat && ctx.Err() == nil; i++ {
+		if load != nil {
+			if err = load.Wait(ctx); err != nil {
+				if ctx.Err() == nil {
+					fmt.Println("--- " + err.Error() + ", " + load.Status())
+				}
+				repeat = i
+				break
+			}
+		}
+		iteration := gen.first + i
+		fmt.Printf("--- %s::%s %d/%s iteration %d\n", action.Contract, action.Action, i+1, of, iteration)
+		var payload *engine.Payload
+		if mutation != nil {
+			var applied []string
+			payload, applied, err = mutation.Payload(gen.seed, iteration)
+			if err == nil {
+				fmt.Println("mutated: " + strings.Join(applied, ", "))
+			}
+		} else {
+			payload, err = action.GenerateSeeded(account, url, gen.seed, iteration)
+		}
+		if errors.Is(err, fuzzer.ErrExhausted) {
+			fmt.Println("--- " + err.Error())
+			repeat = i
+			break
+		}
+		if err != nil {
+			return failed, err
+		}
+		if gen.dryRun {
+			d, err := session.DryRun(ctx, []*engine.Payload{payload}, engine.PackOptions{Compress: zlib})
+			if err != nil {
+				fmt.Println("could not encode: " + err.Error())
+				failed += 1
+				continue
+			}
+			if !d.Ok() {
+				failed += 1
+			}
+			y, _ := yaml.Marshal(d)
+			fmt.Print(string(y))
+			continue
+		}
+		repro := engine.Reproducer{Contract: action.Contract, Action: action.Action, Endpoint: endpoint, Seed: gen.seed, Iteration: iteration}
+		raw, tx, err := session.PackAndSign(ctx, payload, engine.PackOptions{Compress: zlib})
+		if err != nil {
+			fmt.Println("could not sign: " + err.Error())
+			triage.Add(repro, []byte(err.Error()))
+			failed += 1
+			continue
+		}
+		if json.Valid(raw) {
+			repro.Data = raw
+		}
+		if len(raw) < 4096 {
+			fmt.Println(string(raw))
+		}
+		summary, result, err := session.Push(ctx, endpoint, tx)
+		if err != nil {
+			fmt.Println(err.Error())
+			if len(result) > 0 {
+				fmt.Println(string(result))
+				triage.Add(repro, result)
+			} else {
+				triage.Add(repro, []byte(err.Error()))
+			}
+			failed += 1
+			continue
+		}
+		y, _ := yaml.Marshal(summary)
+		fmt.Print(string(y))
+	}
+	if repeat > 1 {
+		fmt.Printf("--- sent %d, %d failed, replay with -seed %d\n", repeat, failed, gen.seed)
+	}
+	// group the failures so a long run shows the distinct errors, not every one of them
+	if buckets := triage.Buckets(); len(buckets) > 0 && repeat > 1 {
+		fmt.Printf("--- %d distinct failures\n", len(buckets))
+		for _, b := range buckets {
+			fmt.Printf("%6d  %s  (first: -iteration %d)\n", b.Count, b.Signature, b.First.Iteration)
+		}
+	}
+	return failed, nil
+}
+
+// run executes each scenario, returns false if any step failed
+func run(ctx context.Context, url string, keyFile string, files []string, reportFile string) (ok bool, err error) {
+	scenarios := make([]*engine.Scenario, 0, len(files))
+	for _, f := range files {
+		sc, err := engine.LoadScenario(f)
+		if err != nil {
+			return false, err
+		}
+		scenarios = append(scenarios, sc)
+	}
+	var account *fio.Account
+	if wif, e := readWif(keyFile); e == nil {
+		if account, err = fio.NewAccountFromWif(wif); err != nil {
+			return false, err
+		}
+	}
+	session, err := engine.NewSession(ctx, url, account)
+	if err != nil {
+		return false, err
+	}
+	runner := &engine.Runner{Session: session}
+	reports := make([]*engine.Report, 0, len(scenarios))
+	ok = true
+	for _, sc := range scenarios {
+		fmt.Println("--- " + sc.Name)
+		rep := runner.Run(ctx, sc)
+		fmt.Print(rep.String())
+		reports = append(reports, rep)
+		ok = ok && rep.Ok()
+	}
+	if reportFile == "" {
+		return ok, nil
+	}
+	var out []byte
+	switch strings.ToLower(filepath.Ext(reportFile)) {
+	case ".yaml", ".yml":
+		out, err = yaml.Marshal(reports)
+	default:
+		out, err = json.MarshalIndent(reports, "", "  ")
+	}
+	if err != nil {
+		return ok, err
+	}
+	return ok, ioutil.WriteFile(reportFile, out, 0644)
+}
@@ -0,0 +1,179 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/blockpane/cryptonym/engine"
+	"github.com/blockpane/cryptonym/fuzzer"
+	"github.com/fioprotocol/fio-go"
+	"github.com/fioprotocol/fio-go/eos/ecc"
+	"github.com/skip2/go-qrcode"
+	"gopkg.in/yaml.v3"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// qrFrameSize is how much of the transaction goes in each qr code, small enough to scan from a screen
+const qrFrameSize = 1000
+
+// export builds an unsigned transaction from action files. Only the public key is needed, but a private key
+// works too.
+func export(ctx context.Context, url string, keyFile string, pub string, files []string, po engine.PackOptions, expire time.Duration, out string, qrDir string) error {
+	var account *fio.Account
+	if pub != "" {
+		actor, err := fio.ActorFromPub(pub)
+		if err != nil {
+			return err
+		}
+		account = &fio.Account{PubKey: pub, Actor: actor}
+	} else {
+		wif, err := readWif(keyFile)
+		if err != nil {
+			return errors.New("set -pub to the offline signer's public key")
+		}
+		if account, err = fio.NewAccountFromWif(wif); err != nil {
+			return err
+		}
+	}
+	session, err := engine.NewSession(ctx, url, nil)
+	if err != nil {
+		return err
+	}
+	api, err := session.Api(ctx)
+	if err != nil {
+		return err
+	}
+	composition := engine.NewComposition()
+	for _, f := range files {
+		action, err := engine.LoadAction(f)
+		if err != nil {
+			return err
+		}
+		if err = action.FillFromAbi(api); err != nil {
+			return err
+		}
+		composition.Add(engine.Composed{Action: action})
+	}
+	var payloads []*engine.Payload
+	err = fuzzer.WithSeed(fuzzer.NewSeed(), 0, func() (e error) {
+		payloads, e = composition.Generate(account, url)
+		return
+	})
+	if err != nil {
+		return err
+	}
+	unsigned, err := engine.ExportUnsigned(api, session.Opts(), account, payloads, po, expire)
+	if err != nil {
+		return err
+	}
+	if out == "" {
+		out = "unsigned-tx.json"
+	}
+	return writeOffline(unsigned, out, qrDir)
+}
+
+// signOffline never creates a session, it only reads the file and the key
+func signOffline(keyFile string, in string, out string, qrDir string, yes bool) error {
+	b, err := ioutil.ReadFile(in)
+	if err != nil {
+		return err
+	}
+	tx, err := engine.ParseOfflineTx(b)
+	if err != nil {
+		return err
+	}
+	review, err := tx.Review()
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(review))
+	wif, err := readWif(keyFile)
+	if err != nil {
+		return err
+	}
+	account, err := fio.NewAccountFromWif(wif)
+	if err != nil {
+		return err
+	}
+	if !yes {
+		fmt.Printf("sign as %s (%s)? [y/N] ", account.PubKey, account.Actor)
+		answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+		if strings.ToLower(strings.TrimSpace(answer)) != "y" {
+			return errors.New("not signed")
+		}
+	}
+	pub, err := ecc.NewPublicKey(account.PubKey)
+	if err != nil {
+		return err
+	}
+	if err = tx.Sign(account.KeyBag, pub); err != nil {
+		return err
+	}
+	if out == "" {
+		out = strings.TrimSuffix(in, filepath.Ext(in)) + "-signed.json"
+	}
+	return writeOffline(tx, out, qrDir)
+}
+
+func pushSigned(ctx context.Context, url string, endpoint string, in string) error {
+	b, err := ioutil.ReadFile(in)
+	if err != nil {
+		return err
+	}
+	tx, err := engine.ParseOfflineTx(b)
+	if err != nil {
+		return err
+	}
+	if !tx.Signed() {
+		return errors.New(in + " has not been signed")
+	}
+	session, err := engine.NewSession(ctx, url, nil)
+	if err != nil {
+		return err
+	}
+	summary, result, err := session.Push(ctx, endpoint, tx.Packed)
+	if err != nil {
+		if len(result) > 0 {
+			fmt.Println(string(result))
+		}
+		return err
+	}
+	y, _ := yaml.Marshal(summary)
+	fmt.Print(string(y))
+	return nil
+}
+
+// writeOffline saves the json, and if qrDir is set a numbered png for each frame
+func writeOffline(tx *engine.OfflineTx, out string, qrDir string) error {
+	j, err := tx.Json()
+	if err != nil {
+		return err
+	}
+	if err = ioutil.WriteFile(out, append(j, '\n'), 0644); err != nil {
+		return err
+	}
+	fmt.Println("wrote " + out)
+	if qrDir == "" {
+		return nil
+	}
+	frames, err := tx.Frames(qrFrameSize)
+	if err != nil {
+		return err
+	}
+	if err = os.MkdirAll(qrDir, 0755); err != nil {
+		return err
+	}
+	for i, frame := range frames {
+		name := filepath.Join(qrDir, fmt.Sprintf("%s-%02d.png", strings.TrimSuffix(filepath.Base(out), filepath.Ext(out)), i+1))
+		if err = qrcode.WriteFile(frame, qrcode.Low, 768, name); err != nil {
+			return err
+		}
+	}
+	fmt.Printf("wrote %d qr codes to %s\n", len(frames), qrDir)
+	return nil
+}
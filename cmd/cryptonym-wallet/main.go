@@ -3,6 +3,7 @@ package main
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"fyne.io/fyne"
 	"fyne.io/fyne/layout"
@@ -10,7 +11,9 @@ import (
 	"fyne.io/fyne/widget"
 	explorer "github.com/blockpane/cryptonym"
 	fioassets "github.com/blockpane/cryptonym/assets"
+	"github.com/blockpane/cryptonym/engine"
 	errs "github.com/blockpane/cryptonym/errLog"
+	"github.com/blockpane/cryptonym/fuzzer"
 	"github.com/fioprotocol/fio-go"
 	"golang.org/x/text/language"
 	"golang.org/x/text/message"
@@ -54,7 +57,8 @@ var (
 	tabEntries    = tabs{}
 	hostEntry     = explorer.NewClickEntry(connectButton)
 	myFioAddress  = widget.NewEntry()
-	moneyBags     = widget.NewSelect(moneySlice(), func(s string) {})
+	moneyBags     = widget.NewSelect(moneySlice(""), func(s string) {})
+	keyFilter     = widget.NewEntry()
 	wifEntry      = widget.NewPasswordEntry()
 	balanceLabel  = widget.NewLabel("Balance: unknown")
 	loadButton    = &widget.Button{}
@@ -82,14 +86,10 @@ var (
 	prodsCheck    = &widget.Check{}
 )
 
-var savedKeys = map[string]string{
-	"devnet vote1":   "5JBbUG5SDpLWxvBKihMeXLENinUzdNKNeozLas23Mj6ZNhz3hLS",
-	"devnet vote2":   "5KC6Edd4BcKTLnRuGj2c8TRT9oLuuXLd3ZuCGxM9iNngc3D8S93",
-	"devnet bp1":     "5KQ6f9ZgUtagD3LZ4wcMKhhvK9qy4BuwL3L1pkm6E2v62HCne2R",
-	"devnet locked1": "5HwvMtAEd7kwDPtKhZrwA41eRMdFH5AaBKPRim6KxkTXcg5M9L5",
-}
-
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "settings" {
+		os.Exit(settingsCommand(os.Args[2:]))
+	}
 	// the MacOS resolver causes serious performance issues, if GODEBUG is empty, then set it to force pure go resolver.
 	if runtime.GOOS == "darwin" {
 		gdb := os.Getenv("GODEBUG")
@@ -176,7 +176,15 @@ func main() {
 		&tabEntries.Vote,
 		&tabEntries.Msig,
 		&tabEntries.Requests,
+		widget.NewTabItem("Scenarios", explorer.ScenarioTab()),
 	)
+	// anything that changes tabs or is typed outside an entry counts as activity for the idle lock
+	tabContent.OnChanged = func(*widget.TabItem) {
+		explorer.MarkActivity()
+	}
+	explorer.Win.Canvas().SetOnTypedKey(func(*fyne.KeyEvent) {
+		explorer.MarkActivity()
+	})
 
 	uriContainer = fyne.NewContainerWithLayout(layout.NewFixedGridLayout(fyne.NewSize(5, 35)),
 		uriContent,
@@ -290,7 +298,10 @@ func main() {
 			fyne.CurrentApp().Settings().SetTheme(explorer.ExLightTheme().ToFyneTheme())
 		}
 		go explorer.PromptForPassword()
+		controlHooks()
 		go settingsReload(explorer.SettingsLoaded)
+		go lockedReload(explorer.LockChan)
+		explorer.StartIdleLock()
 	}()
 	explorer.Win.ShowAndRun()
 }
@@ -305,19 +316,26 @@ func settingsReload(newSettings chan *explorer.FioSettings) {
 	for {
 		select {
 		case s := <-newSettings:
-			if !strings.HasPrefix(s.Server, "http") {
-				s.Server = "http://" + s.Server
+			explorer.ApplyControlApi(s)
+			if server, ok := s.ConnectTo(); ok {
+				if !strings.HasPrefix(server, "http") {
+					server = "http://" + server
+				}
+				*uri = server
+			} else {
+				*uri = ""
 			}
-			*uri = s.Server
 			hostEntry.SetText(*uri)
-			savedKeys = map[string]string{
-				s.DefaultKeyDesc: s.DefaultKey,
-				s.FavKey2Desc:    s.FavKey2,
-				s.FavKey3Desc:    s.FavKey3,
-				s.FavKey4Desc:    s.FavKey4,
+			keyFilter.SetText("")
+			moneyBags.Options = moneySlice("")
+			defaultKey := s.ProfileKey()
+			if defaultKey == nil {
+				errs.ErrChan <- "no keys in the saved settings keyring"
+				keyContent.Children = keyBoxContent().Children
+				refreshNotNil(keyContent)
+				continue
 			}
-			moneyBags.Options = moneySlice()
-			newAccount, err := fio.NewAccountFromWif(s.DefaultKey)
+			newAccount, err := fio.NewAccountFromWif(defaultKey.Wif)
 			keyContent.Children = keyBoxContent().Children
 			if err != nil {
 				errs.ErrChan <- "error loading key from saved settings. " + err.Error()
@@ -325,7 +343,7 @@ func settingsReload(newSettings chan *explorer.FioSettings) {
 				dr := *newAccount
 				account = &dr
 				explorer.Account = &dr
-				wifEntry.SetText(s.DefaultKey)
+				wifEntry.SetText(defaultKey.Wif)
 				importButton.OnTapped()
 				tabContent.SelectTabIndex(0)
 			}
@@ -333,6 +351,69 @@ func settingsReload(newSettings chan *explorer.FioSettings) {
 	}
 }
 
+// controlHooks lets the control api use the connect and quick load buttons
+func controlHooks() {
+	explorer.ControlConnect = func(url string) error {
+		if !strings.HasPrefix(url, "http") {
+			url = "http://" + url
+		}
+		*uri = url
+		hostEntry.SetText(url)
+		if !reconnect(account) {
+			return errors.New("could not connect to " + url)
+		}
+		updateActions(true, opts)
+		return nil
+	}
+	explorer.ControlSwitchKey = func(name string) error {
+		if explorer.Locked() || explorer.Settings == nil {
+			return errors.New("cryptonym is locked")
+		}
+		k := explorer.Settings.FindKey(name)
+		if k == nil {
+			return errors.New("no saved key named " + name)
+		}
+		newAcc, err := fio.NewAccountFromWif(k.Wif)
+		if err != nil {
+			return err
+		}
+		wifEntry.SetText(k.Wif)
+		importButton.OnTapped()
+		if explorer.Account == nil || explorer.Account.PubKey != newAcc.PubKey {
+			return errors.New("could not load key " + name)
+		}
+		return nil
+	}
+}
+
+// lockedReload clears the key box when the idle timeout locks the app, it is rebuilt when settings are
+// unlocked by settingsReload.
+func lockedReload(locked chan bool) {
+	for range locked {
+		wifEntry.SetText("")
+		keyFilter.SetText("")
+		moneyBags.Options = make([]string, 0)
+		moneyBags.Selected = ""
+		balanceLabel.SetText("")
+		unlockButton := widget.NewButtonWithIcon("Unlock", theme.WarningIcon(), func() {
+			if explorer.RequestUnlock() {
+				// no saved settings, nothing to unlock: a key will have to be imported
+				keyContent.Children = keyBoxContent().Children
+				refreshNotNil(keyContent)
+			}
+		})
+		keyContent.Children = []fyne.CanvasObject{
+			widget.NewHBox(
+				layout.NewSpacer(),
+				widget.NewLabelWithStyle("Locked after being idle, keys have been removed from memory.", fyne.TextAlignCenter, fyne.TextStyle{Bold: true}),
+				unlockButton,
+				layout.NewSpacer(),
+			),
+		}
+		refreshNotNil(keyContent)
+	}
+}
+
 func refreshMyName() {
 	if account.Addresses != nil && len(account.Addresses) > 0 {
 		txt := account.Addresses[0].FioAddress
@@ -392,14 +473,9 @@ func refreshInfo(deadline time.Duration) (string, bool) {
 			errs.ErrChan <- e.Error()
 			if apiDeadCounter >= 10 {
 				errs.ErrChan <- "connection seems to be having issues, trying to reconnect"
-				var err error
-				explorer.Api, explorer.Opts, err = fio.NewConnection(explorer.Account.KeyBag, explorer.Uri)
-				if err != nil {
+				if err := connectSession(explorer.Account); err != nil {
 					errs.ErrChan <- err.Error()
-					return
 				}
-				api = explorer.Api
-				opts = explorer.Opts
 			}
 			return
 		}
@@ -427,17 +503,25 @@ func reconnect(account *fio.Account) (result bool) {
 	}()
 	clientMux.Lock()
 	defer clientMux.Unlock()
-	var err error
-	api, opts, err = fio.NewConnection(account.KeyBag, *uri)
-	if err != nil {
+	session := &engine.Session{}
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	if err := session.Connect(ctx, *uri); err != nil {
 		if *uri != "" {
 			errs.ErrChan <- err.Error()
 		}
 		return
 	}
-	api.Header.Set("User-Agent", "fio-cryptonym-wallet")
-	explorer.Api, explorer.Opts, _ = fio.NewConnection(account.KeyBag, *uri)
-	explorer.Api.Header.Set("User-Agent", "fio-cryptonym-wallet")
+	// don't connect to mainnet by accident when the profile is for a devnet, etc.
+	if err := explorer.Settings.Profile().CheckChainId(session.Opts().ChainID.String()); err != nil {
+		errs.ErrChan <- err.Error()
+		return
+	}
+	explorer.Session = session
+	if err := connectSession(account); err != nil {
+		errs.ErrChan <- err.Error()
+		return
+	}
 	errs.ErrChan <- "connected to nodeos at " + *uri
 	explorer.Win.SetTitle(fmt.Sprintf("Cryptonym - nodeos @ %s", *uri))
 	errs.RefreshChan <- true
@@ -449,6 +533,29 @@ func reconnect(account *fio.Account) (result bool) {
 	return
 }
 
+// connectSession points the shared session at the current uri and account. The main window and the
+// explorer package each get their own client from the session, so they don't share one connection.
+func connectSession(account *fio.Account) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	// the chain state the generators pick from belongs to the session, read it again for the new node or account
+	fuzzer.ForgetChainState()
+	explorer.Session.SetAccount(account)
+	if explorer.Session.Url() != strings.TrimRight(*uri, "/") {
+		if err := explorer.Session.Connect(ctx, *uri); err != nil {
+			return err
+		}
+	}
+	newApi, err := explorer.Session.Api(context.Background())
+	if err != nil {
+		return err
+	}
+	api, opts = newApi, explorer.Session.Opts()
+	explorer.Api, _ = explorer.Session.Api(context.Background())
+	explorer.Opts = explorer.Session.Opts()
+	return nil
+}
+
 func updateActions(ready bool, opts *fio.TxOptions) {
 	newGroup := true
 	if actionsGroup == nil || actionsGroup.Text == "" {
@@ -462,15 +569,11 @@ func updateActions(ready bool, opts *fio.TxOptions) {
 	}
 	var err error
 	clientMux.Lock()
-	api, opts, err = fio.NewConnection(account.KeyBag, *uri)
-	if err != nil {
+	if err = connectSession(account); err != nil {
 		errs.ErrChan <- "not connected to nodeos server"
 		clientMux.Unlock()
 		return
 	}
-	api.Header.Set("User-Agent", "fio-cryptonym-wallet")
-	explorer.Api, explorer.Opts, _ = fio.NewConnection(account.KeyBag, *uri)
-	explorer.Api.Header.Set("User-Agent", "fio-cryptonym-wallet")
 	_, err = api.GetInfo()
 	if err != nil {
 		errs.ErrChan <- "not connected to nodeos server"
@@ -664,13 +767,12 @@ func showHideActions(s string) {
 	}
 }
 
-func moneySlice() []string {
-	o := make([]string, 0)
-	for k := range savedKeys {
-		o = append(o, k)
+// moneySlice lists the keyring names matching the filter, in the order they are saved
+func moneySlice(filter string) []string {
+	if explorer.Settings == nil {
+		return []string{}
 	}
-	sort.Strings(o)
-	return o
+	return explorer.Settings.KeyNames(filter)
 }
 
 func makeTabs() tabs {
@@ -738,15 +840,28 @@ func uriSimplerInput() {
 			connectButton.Enable()
 		}
 	})
-	mainSelect := widget.NewSelect(explorer.MainnetApi, func(s string) {
+	serverSelect := widget.NewSelect(explorer.Settings.Profile().Servers, func(s string) {
 		hostEntry.SetText(s)
 	})
-	mainSelect.PlaceHolder = "Mainnet Nodes"
+	serverSelect.PlaceHolder = "Profile Servers"
+	profileSelect := widget.NewSelect(explorer.Settings.ProfileNames(), func(s string) {
+		if explorer.Settings.FindProfile(s) == nil {
+			return
+		}
+		explorer.Settings.ActiveProfile = s
+		serverSelect.Options = explorer.Settings.Profile().Servers
+		serverSelect.Selected = ""
+		serverSelect.Refresh()
+		if server := explorer.Settings.Profile().Server(); server != "" {
+			hostEntry.SetText(server)
+		}
+	})
+	profileSelect.Selected = explorer.Settings.Profile().Name
 	hEntry.Text = *uri
 	nw.SetContent(widget.NewVBox(
 		layout.NewSpacer(),
 		widget.NewHBox(layout.NewSpacer(), hostEntry, cancelButton, connectButton, layout.NewSpacer()),
-		widget.NewHBox(layout.NewSpacer(), mainSelect, layout.NewSpacer()),
+		widget.NewHBox(layout.NewSpacer(), profileSelect, serverSelect, layout.NewSpacer()),
 		layout.NewSpacer()),
 	)
 	nw.Resize(fyne.NewSize(400, 200))
@@ -828,6 +943,7 @@ var (
 
 func keyBoxContent() *widget.Box {
 	doImport := func() {}
+	useAccount := func(*fio.Account) {}
 	entryChan := make(chan string)
 	moneyBags.OnChanged = func(s string) {
 		if s != "" && s != moneyBags.PlaceHolder && wifEntry != nil {
@@ -836,6 +952,15 @@ func keyBoxContent() *widget.Box {
 	}
 	moneyBags.PlaceHolder = "Quick Load Saved Key"
 	moneyBags.Refresh()
+	keyFilter.SetPlaceHolder("search name or tag")
+	keyFilter.OnChanged = func(s string) {
+		moneyBags.Options = moneySlice(s)
+		moneyBags.Selected = ""
+		if len(moneyBags.Options) == 1 {
+			moneyBags.SetSelected(moneyBags.Options[0])
+		}
+		moneyBags.Refresh()
+	}
 	pubkey := widget.NewEntry()
 	func(s string) {
 		pubkey.OnChanged = func(string) {
@@ -863,6 +988,11 @@ func keyBoxContent() *widget.Box {
 	}(txt)
 	myFioAddress.SetText(txt)
 
+	keyLabel := widget.NewLabel("Current Key: ")
+	if explorer.WatchOnly {
+		keyLabel.SetText("Watching: ")
+	}
+
 	wifWindow := explorer.App.NewWindow("Import WIF")
 	doImport = func() {
 		explorer.Win.RequestFocus()
@@ -873,6 +1003,16 @@ func keyBoxContent() *widget.Box {
 			//wifWindow.Hide()
 			return
 		}
+		explorer.WatchOnly = false
+		useAccount(newAcc)
+	}
+	useAccount = func(newAcc *fio.Account) {
+		explorer.MarkActivity()
+		if explorer.WatchOnly {
+			keyLabel.SetText("Watching: ")
+		} else {
+			keyLabel.SetText("Current Key: ")
+		}
 		tabContent.SelectTabIndex(1)
 		myFioAddress.OnChanged = func(string) {
 			myFioAddress.SetText("")
@@ -930,9 +1070,49 @@ func keyBoxContent() *widget.Box {
 						return
 					},
 				),
-				layout.NewSpacer(), moneyBags,
+				layout.NewSpacer(), keyFilter, moneyBags,
 			),
 		)
+		if explorer.Settings.SignerType != "" && explorer.Settings.SignerType != explorer.SignerLocal {
+			signerType := explorer.Settings.SignerType
+			signerKeys, err := explorer.SignerPublicKeys()
+			if err != nil {
+				errs.ErrChan <- signerType + ": " + err.Error()
+			}
+			signerSelect := widget.NewSelect(signerKeys, func(pub string) {
+				newAcc, err := explorer.NewPubKeyAccount(pub)
+				if err != nil {
+					errs.ErrChan <- signerType + ": " + err.Error()
+					return
+				}
+				errs.ErrChan <- "using " + signerType + " signer for key " + pub
+				wifEntry.SetText("")
+				explorer.WatchOnly = false
+				useAccount(newAcc)
+				wifWindow.Close()
+			})
+			signerSelect.PlaceHolder = "Use Key From " + signerType + " Signer"
+			keyBox.Append(signerSelect)
+		}
+		// watch-only: look at another account's requests, votes, and msigs without a private key
+		watchEntry := widget.NewEntry()
+		watchEntry.SetPlaceHolder("public key, actor, or FIO address")
+		watchButton := widget.NewButtonWithIcon("Watch", theme.VisibilityIcon(), func() {
+			newAcc, err := explorer.NewWatchAccount(api, watchEntry.Text)
+			if err != nil {
+				errs.ErrChan <- "watch: " + err.Error()
+				return
+			}
+			errs.ErrChan <- fmt.Sprintf("watching %s %s, signing is disabled", newAcc.Actor, newAcc.PubKey)
+			wifEntry.SetText("")
+			explorer.WatchOnly = true
+			useAccount(newAcc)
+			wifWindow.Close()
+		})
+		keyBox.Append(widget.NewHBox(
+			fyne.NewContainerWithLayout(layout.NewFixedGridLayout(fyne.NewSize(340, watchEntry.MinSize().Height)), watchEntry),
+			watchButton,
+		))
 		wifWindow.SetContent(keyBox)
 		wifWindow.Show()
 	})
@@ -1006,8 +1186,8 @@ func keyBoxContent() *widget.Box {
 			case newWif := <-entryChan:
 				infoTickDuration = time.Second
 				infoTick = time.NewTicker(infoTickDuration)
-				if savedKeys[newWif] != "" {
-					wifEntry.SetText(savedKeys[newWif])
+				if k := explorer.Settings.FindKey(newWif); k != nil && k.Wif != "" {
+					wifEntry.SetText(k.Wif)
 				}
 			}
 		}
@@ -1017,7 +1197,7 @@ func keyBoxContent() *widget.Box {
 		fyne.NewContainerWithLayout(layout.NewFixedGridLayout(fyne.NewSize(explorer.RWidth(), 90)),
 			fyne.NewContainerWithLayout(layout.NewGridLayoutWithRows(2),
 				widget.NewHBox(
-					widget.NewLabel("Current Key: "),
+					keyLabel,
 					pubkey,
 					actor,
 					myFioAddress,
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	explorer "github.com/blockpane/cryptonym"
+	"golang.org/x/crypto/ssh/terminal"
+	"os"
+	"strings"
+)
+
+const settingsUsage = `usage:
+  cryptonym-wallet settings export <file>    write an encrypted backup of the saved settings
+  cryptonym-wallet settings import <file>    replace the saved settings with a backup`
+
+// settingsCommand handles moving the encrypted settings between workstations without starting the gui
+func settingsCommand(args []string) int {
+	if len(args) != 2 || (args[0] != "export" && args[0] != "import") {
+		fmt.Fprintln(os.Stderr, settingsUsage)
+		return 2
+	}
+	var err error
+	switch args[0] {
+	case "export":
+		err = settingsExport(args[1])
+	case "import":
+		err = settingsImport(args[1])
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "settings "+args[0]+": "+err.Error())
+		return 1
+	}
+	return 0
+}
+
+func settingsExport(fileName string) error {
+	if _, err := os.Stat(fileName); err == nil && !confirm(fileName+" exists, overwrite?") {
+		return errors.New("cancelled")
+	}
+	password, err := readPassword("settings password: ")
+	if err != nil {
+		return err
+	}
+	exportPassword, err := newPassword("backup password: ")
+	if err != nil {
+		return err
+	}
+	if err = explorer.ExportSettings(fileName, password, exportPassword); err != nil {
+		return err
+	}
+	fmt.Println("wrote settings backup to " + fileName)
+	return nil
+}
+
+func settingsImport(fileName string) error {
+	if _, fileLen, _, _ := explorer.LoadEncryptedSettings(""); fileLen > 0 && !confirm("replace the existing saved settings?") {
+		return errors.New("cancelled")
+	}
+	backupPassword, err := readPassword("backup password: ")
+	if err != nil {
+		return err
+	}
+	password, err := newPassword("new settings password: ")
+	if err != nil {
+		return err
+	}
+	if err = explorer.ImportSettings(fileName, backupPassword, password); err != nil {
+		return err
+	}
+	fmt.Println("imported settings from " + fileName)
+	return nil
+}
+
+func readPassword(prompt string) (string, error) {
+	fmt.Fprint(os.Stderr, prompt)
+	defer fmt.Fprintln(os.Stderr)
+	b, err := terminal.ReadPassword(int(os.Stdin.Fd()))
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+// newPassword has the same rules as the settings window, 8 characters and confirmed
+func newPassword(prompt string) (string, error) {
+	password, err := readPassword(prompt)
+	if err != nil {
+		return "", err
+	}
+	if len(password) < 8 {
+		return "", errors.New("password must be at least 8 characters")
+	}
+	confirmed, err := readPassword("confirm: ")
+	if err != nil {
+		return "", err
+	}
+	if confirmed != password {
+		return "", errors.New("passwords do not match")
+	}
+	return password, nil
+}
+
+func confirm(question string) bool {
+	fmt.Fprint(os.Stderr, question+" [y/N] ")
+	answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	return strings.HasPrefix(strings.ToLower(strings.TrimSpace(answer)), "y")
+}
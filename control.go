@@ -0,0 +1,327 @@
+package cryptonym
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/blockpane/cryptonym/engine"
+	errs "github.com/blockpane/cryptonym/errLog"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultControlAddress is used when the control api is enabled without an address
+const DefaultControlAddress = "127.0.0.1:8099"
+
+const controlTokenFile = "control-token"
+
+// ControlConnect and ControlSwitchKey are set by the gui, they do the same thing as the connect and quick load
+// buttons so the window stays in sync with what the control api changes.
+var (
+	ControlConnect   func(url string) error
+	ControlSwitchKey func(name string) error
+)
+
+var control = struct {
+	sync.Mutex
+	server *http.Server
+	addr   string
+}{}
+
+// ApplyControlApi starts, stops, or moves the control api to match the settings
+func ApplyControlApi(s *FioSettings) {
+	control.Lock()
+	defer control.Unlock()
+	addr := ""
+	if s != nil && s.ControlApi {
+		addr = s.ControlAddress
+		if addr == "" {
+			addr = DefaultControlAddress
+		}
+	}
+	if addr == control.addr {
+		return
+	}
+	if control.server != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		_ = control.server.Shutdown(ctx)
+		cancel()
+		control.server, control.addr = nil, ""
+		errs.ErrChan <- "control api stopped"
+	}
+	if addr == "" {
+		return
+	}
+	server, tokenFile, err := startControlApi(addr)
+	if err != nil {
+		errs.ErrChan <- "control api: " + err.Error()
+		return
+	}
+	control.server, control.addr = server, addr
+	errs.ErrChan <- fmt.Sprintf("control api listening on http://%s, the token is in %s", addr, tokenFile)
+}
+
+// startControlApi only listens on loopback addresses, a new token is written to the settings directory each
+// time it starts.
+func startControlApi(addr string) (*http.Server, string, error) {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, "", err
+	}
+	if ip := net.ParseIP(host); host != "localhost" && (ip == nil || !ip.IsLoopback()) {
+		return nil, "", errors.New("refusing to listen on " + addr + ", only localhost is allowed")
+	}
+	token, tokenFile, err := newControlToken()
+	if err != nil {
+		return nil, "", err
+	}
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, "", err
+	}
+	server := &http.Server{Handler: controlHandler(token), ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		if e := server.Serve(listener); e != nil && e != http.ErrServerClosed {
+			errs.ErrChan <- "control api: " + e.Error()
+		}
+	}()
+	return server, tokenFile, nil
+}
+
+func newControlToken() (token string, fileName string, err error) {
+	configDir, err := os.UserConfigDir()
+	if err != nil {
+		return "", "", err
+	}
+	if _, err = MkDir(); err != nil {
+		return "", "", err
+	}
+	b := make([]byte, 32)
+	if _, err = rand.Read(b); err != nil {
+		return "", "", err
+	}
+	token = hex.EncodeToString(b)
+	fileName = filepath.Join(configDir, settingsDir, controlTokenFile)
+	return token, fileName, ioutil.WriteFile(fileName, []byte(token+"\n"), 0600)
+}
+
+type controlError struct {
+	Error string `json:"error"`
+}
+
+type controlStatus struct {
+	Connected bool   `json:"connected"`
+	Url       string `json:"url,omitempty"`
+	ChainId   string `json:"chain_id,omitempty"`
+	Actor     string `json:"actor,omitempty"`
+	PubKey    string `json:"pub_key,omitempty"`
+	WatchOnly bool   `json:"watch_only"`
+	Locked    bool   `json:"locked"`
+}
+
+type controlSendResult struct {
+	TransactionId string          `json:"transaction_id,omitempty"`
+	Summary       *TxSummary      `json:"summary,omitempty"`
+	Action        json.RawMessage `json:"action,omitempty"`
+	Response      json.RawMessage `json:"response,omitempty"`
+	Error         string          `json:"error,omitempty"`
+}
+
+type controlTableResult struct {
+	Rows json.RawMessage `json:"rows"`
+	More bool            `json:"more"`
+}
+
+// controlHandler routes the control api, every request needs "Authorization: Bearer <token>"
+func controlHandler(token string) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/status", func(w http.ResponseWriter, r *http.Request) {
+		status := controlStatus{Connected: Session.Connected(), Url: Session.Url(), WatchOnly: WatchOnly, Locked: Locked()}
+		if status.Connected {
+			status.ChainId = Session.Opts().ChainID.String()
+		}
+		if account := Session.Account(); account != nil {
+			status.Actor, status.PubKey = string(account.Actor), account.PubKey
+		}
+		controlReply(w, http.StatusOK, status)
+	})
+	mux.HandleFunc("/v1/log", func(w http.ResponseWriter, r *http.Request) {
+		controlReply(w, http.StatusOK, map[string][]string{"events": errs.Events()})
+	})
+	mux.HandleFunc("/v1/connect", func(w http.ResponseWriter, r *http.Request) {
+		req := struct {
+			Url string `json:"url"`
+		}{}
+		if !controlRequest(w, r, &req) {
+			return
+		}
+		if req.Url == "" || ControlConnect == nil {
+			controlReply(w, http.StatusBadRequest, controlError{Error: "a url is required"})
+			return
+		}
+		if err := ControlConnect(req.Url); err != nil {
+			controlReply(w, http.StatusBadGateway, controlError{Error: err.Error()})
+			return
+		}
+		controlReply(w, http.StatusOK, map[string]string{"url": Session.Url()})
+	})
+	mux.HandleFunc("/v1/key", func(w http.ResponseWriter, r *http.Request) {
+		req := struct {
+			Name string `json:"name"`
+		}{}
+		if !controlRequest(w, r, &req) {
+			return
+		}
+		if ControlSwitchKey == nil {
+			controlReply(w, http.StatusServiceUnavailable, controlError{Error: "key switching is not available"})
+			return
+		}
+		if err := ControlSwitchKey(req.Name); err != nil {
+			controlReply(w, http.StatusBadRequest, controlError{Error: err.Error()})
+			return
+		}
+		controlReply(w, http.StatusOK, map[string]string{"actor": string(Session.Account().Actor), "pub_key": Session.Account().PubKey})
+	})
+	mux.HandleFunc("/v1/send", func(w http.ResponseWriter, r *http.Request) {
+		req := &engine.ActionStep{}
+		if !controlRequest(w, r, req) {
+			return
+		}
+		if req.Contract == "" || req.Action.Action == "" {
+			controlReply(w, http.StatusBadRequest, controlError{Error: "contract and action are required"})
+			return
+		}
+		status, result := controlSend(r.Context(), req)
+		controlReply(w, status, result)
+	})
+	mux.HandleFunc("/v1/table", func(w http.ResponseWriter, r *http.Request) {
+		q := &engine.TableQuery{}
+		if !controlRequest(w, r, q) {
+			return
+		}
+		rows, more, err := Session.QueryTable(r.Context(), q)
+		if err != nil {
+			controlReply(w, http.StatusBadGateway, controlError{Error: err.Error()})
+			return
+		}
+		controlReply(w, http.StatusOK, controlTableResult{Rows: rows, More: more})
+	})
+	mux.HandleFunc("/v1/account", func(w http.ResponseWriter, r *http.Request) {
+		req := struct {
+			SearchFor string `json:"search_for"`
+			Value     string `json:"value"`
+		}{}
+		if !controlRequest(w, r, &req) {
+			return
+		}
+		info, err := Session.AccountSearch(r.Context(), req.SearchFor, req.Value)
+		if err != nil {
+			controlReply(w, http.StatusBadGateway, controlError{Error: err.Error()})
+			return
+		}
+		controlReply(w, http.StatusOK, info)
+	})
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// browsers send an Origin header, nothing legitimate should be calling this from a web page
+		if r.Header.Get("Origin") != "" || !controlLocalHost(r.Host) {
+			controlReply(w, http.StatusForbidden, controlError{Error: "forbidden"})
+			return
+		}
+		auth := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if subtle.ConstantTimeCompare([]byte(auth), []byte(token)) != 1 {
+			controlReply(w, http.StatusUnauthorized, controlError{Error: "invalid token"})
+			return
+		}
+		mux.ServeHTTP(w, r)
+	})
+}
+
+// controlLocalHost checks the Host header, prevents dns rebinding from reaching the api
+func controlLocalHost(hostPort string) bool {
+	host, _, err := net.SplitHostPort(hostPort)
+	if err != nil {
+		host = hostPort
+	}
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// controlSend builds the action the same way the action editor does and signs it with the loaded key
+func controlSend(ctx context.Context, req *engine.ActionStep) (int, *controlSendResult) {
+	result := &controlSendResult{}
+	account := Session.Account()
+	if account == nil {
+		result.Error = "no key has been loaded"
+		return http.StatusBadRequest, result
+	}
+	api, err := Session.Api(ctx)
+	if err != nil {
+		result.Error = err.Error()
+		return http.StatusServiceUnavailable, result
+	}
+	a := req.Build(nil)
+	if err = a.FillFromAbi(api); err != nil {
+		result.Error = err.Error()
+		return http.StatusBadRequest, result
+	}
+	p, err := a.Generate(account, Session.Url())
+	if err != nil {
+		result.Error = err.Error()
+		return http.StatusBadRequest, result
+	}
+	if err = applySigner(api, account); err != nil {
+		result.Error = err.Error()
+		return http.StatusForbidden, result
+	}
+	raw, tx, err := engine.PackAndSign(api, Session.Opts(), account, p, engine.PackOptions{Compress: req.Compress})
+	if err != nil {
+		result.Error = err.Error()
+		return http.StatusBadRequest, result
+	}
+	result.Action = raw
+	summary, resp, err := engine.Push(api, req.PushEndpoint(), tx)
+	if json.Valid(resp) {
+		result.Response = resp
+	}
+	if err != nil {
+		result.Error = err.Error()
+		// the transaction was rejected by the node, that's still a successful call to the control api
+		return http.StatusOK, result
+	}
+	result.Summary, result.TransactionId = summary, summary.TransactionId
+	errs.ErrChan <- fmt.Sprintf("control api: sent %s::%s %s", req.Contract, req.Action.Action, summary.TransactionId)
+	return http.StatusOK, result
+}
+
+// controlRequest decodes a POST body, writing an error response if it can't
+func controlRequest(w http.ResponseWriter, r *http.Request, v interface{}) bool {
+	if r.Method != http.MethodPost {
+		controlReply(w, http.StatusMethodNotAllowed, controlError{Error: "use POST"})
+		return false
+	}
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 4<<20)).Decode(v); err != nil {
+		controlReply(w, http.StatusBadRequest, controlError{Error: err.Error()})
+		return false
+	}
+	return true
+}
+
+func controlReply(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,76 @@
+package cryptonym
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/blockpane/cryptonym/engine"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestControlHandler(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/chain/get_info", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"chain_id":"b20901380af44ef59c5918439a1f9a41d83669020319a80574b804a5f95cbd7e","head_block_id":"0000000a5c5e4d2a2da1b4e5e9c9b2ad7b0c0a4b3e9e3f9a7b1a9d4c6f2a1b3c"}`))
+	})
+	mux.HandleFunc("/v1/chain/get_table_rows", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"rows":[{"name":"dapix"}],"more":true}`))
+	})
+	node := httptest.NewServer(mux)
+	defer node.Close()
+	session, err := engine.NewSession(context.Background(), node.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	saved := Session
+	Session = session
+	defer func() { Session = saved }()
+
+	handler := controlHandler("secret")
+	call := func(method string, path string, body string, mod func(r *http.Request)) (int, map[string]interface{}) {
+		r := httptest.NewRequest(method, "http://127.0.0.1:8099"+path, strings.NewReader(body))
+		r.Header.Set("Authorization", "Bearer secret")
+		if mod != nil {
+			mod(r)
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		out := make(map[string]interface{})
+		_ = json.Unmarshal(w.Body.Bytes(), &out)
+		return w.Code, out
+	}
+
+	if code, _ := call("GET", "/v1/status", "", func(r *http.Request) { r.Header.Set("Authorization", "Bearer nope") }); code != http.StatusUnauthorized {
+		t.Error("expected a bad token to be rejected, got", code)
+	}
+	if code, _ := call("GET", "/v1/status", "", func(r *http.Request) { r.Header.Set("Origin", "http://example.com") }); code != http.StatusForbidden {
+		t.Error("expected a browser request to be rejected, got", code)
+	}
+	if code, _ := call("GET", "/v1/status", "", func(r *http.Request) { r.Host = "rebind.example.com:8099" }); code != http.StatusForbidden {
+		t.Error("expected a non-local host header to be rejected, got", code)
+	}
+
+	code, out := call("GET", "/v1/status", "", nil)
+	if code != http.StatusOK || out["connected"] != true || out["url"] != node.URL {
+		t.Errorf("unexpected status %d %v", code, out)
+	}
+	if code, _ = call("GET", "/v1/table", "", nil); code != http.StatusMethodNotAllowed {
+		t.Error("table should require a POST, got", code)
+	}
+	code, out = call("POST", "/v1/table", `{"contract":"fio.address","table":"domains"}`, nil)
+	if rows, _ := out["rows"].([]interface{}); code != http.StatusOK || len(rows) != 1 || out["more"] != true {
+		t.Errorf("unexpected table result %d %v", code, out)
+	}
+	if code, out = call("POST", "/v1/send", `{"contract":"fio.token","action":"trnsfiopubky"}`, nil); code != http.StatusBadRequest || out["error"] == nil {
+		t.Errorf("send without a key should fail, got %d %v", code, out)
+	}
+	if code, _ = call("GET", "/v1/log", "", nil); code != http.StatusOK {
+		t.Error("could not read the log", code)
+	}
+
+	if _, _, err = startControlApi("0.0.0.0:0"); err == nil {
+		t.Error("control api should only listen on localhost")
+	}
+}
@@ -0,0 +1,290 @@
+package engine
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/blockpane/cryptonym/fuzzer"
+	"github.com/fioprotocol/fio-go"
+	"github.com/fioprotocol/fio-go/eos"
+	"gopkg.in/yaml.v3"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Action is the action editor's form without any widgets, it can be loaded from a JSON or YAML file.
+type Action struct {
+	Contract string  `json:"contract" yaml:"contract"`
+	Action   string  `json:"action" yaml:"action"`
+	Fields   []Field `json:"fields" yaml:"fields"`
+
+	abi *eos.ABI
+}
+
+// LoadAction reads an action from a .json, .yaml or .yml file
+func LoadAction(fileName string) (*Action, error) {
+	b, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		return nil, err
+	}
+	a := &Action{}
+	switch strings.ToLower(filepath.Ext(fileName)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(b, a)
+	default:
+		err = json.Unmarshal(b, a)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", fileName, err.Error())
+	}
+	if a.Contract == "" || a.Action == "" {
+		return nil, fmt.Errorf("%s: contract and action are required", fileName)
+	}
+	return a, nil
+}
+
+// FillFromAbi sets the type for each field from the contract's abi, and adds any missing fields as an empty
+// form value. Fields that don't exist in the abi are left alone, they are sent anyway.
+func (a *Action) FillFromAbi(api *fio.API) error {
+	bi, err := api.GetABI(eos.AccountName(a.Contract))
+	if err != nil {
+		return err
+	}
+	a.abi = &bi.ABI
+	def := bi.ABI.StructForName(a.Action)
+	if def == nil {
+		return fmt.Errorf("%s does not have an action named %s", a.Contract, a.Action)
+	}
+	byName := make(map[string]int)
+	for i := range a.Fields {
+		byName[a.Fields[i].Name] = i
+	}
+	fields := make([]Field, 0, len(a.Fields))
+	for _, abiField := range def.Fields {
+		f := Field{Name: abiField.Name}
+		if i, ok := byName[abiField.Name]; ok {
+			f = a.Fields[i]
+		}
+		if f.Type == "" {
+			f.Type = abiField.Type
+		}
+		if f.SendAs == "" {
+			f.SendAs = "form value"
+		}
+		if f.Variation == "" {
+			_, f.Variation = SendAsVariant(f.SendAs)
+		}
+		fields = append(fields, f)
+		delete(byName, abiField.Name)
+	}
+	for _, f := range a.Fields {
+		if _, extra := byName[f.Name]; extra {
+			fields = append(fields, f)
+		}
+	}
+	a.Fields = fields
+	return nil
+}
+
+// Payload is one set of generated values for an action's fields
+type Payload struct {
+	Contract string
+	Action   string
+	Fields   []Field
+	Values   []Value
+	// Authorization is who signs the action, if empty it's the actor field or the account, with active
+	Authorization []eos.PermissionLevel
+}
+
+// Generate runs every field's generator
+func (a *Action) Generate(key *fio.Account, uri string) (*Payload, error) {
+	p := &Payload{
+		Contract: a.Contract,
+		Action:   a.Action,
+		Fields:   make([]Field, len(a.Fields)),
+		Values:   make([]Value, len(a.Fields)),
+	}
+	for i, f := range a.Fields {
+		v, err := f.GenerateWith(key, uri, a.abi)
+		if err != nil {
+			if err == ErrUnknownVariation {
+				return nil, fmt.Errorf("%s: unknown variation %q for %q", f.Name, f.Variation, f.SendAs)
+			}
+			return nil, err
+		}
+		if v.Convert && v.AbiType != "" {
+			f.Type = v.AbiType
+		}
+		p.Fields[i], p.Values[i] = f, v
+	}
+	return p, nil
+}
+
+// GenerateSeeded is Generate with the fuzzers seeded for one iteration of a run, the same seed and iteration
+// generate the same payload.
+func (a *Action) GenerateSeeded(key *fio.Account, uri string, seed int64, iteration int) (p *Payload, err error) {
+	err = fuzzer.WithSeed(seed, iteration, func() error {
+		p, err = a.Generate(key, uri)
+		return err
+	})
+	return p, err
+}
+
+// Json builds the action data, field order is kept since it can matter to a contract
+func (p *Payload) Json() ([]byte, error) {
+	jsonString := "{"
+	for i := range p.Fields {
+		v := p.Values[i]
+		var b []byte
+		var err error
+		switch {
+		case !v.IsSlice && !v.NoJsonEscape:
+			b, err = json.Marshal(v.V)
+		case !v.IsSlice && v.NoJsonEscape:
+			b = []byte(fmt.Sprintf("%v", v.V))
+		//FIXME, this is converting everything to string arrays
+		case v.IsSlice:
+			s := strings.Split(p.Fields[i].Input, ",")
+			for j := range s {
+				s[j] = strings.TrimSpace(s[j])
+			}
+			b, err = json.Marshal(s)
+		}
+		if err != nil {
+			return nil, errors.New(p.Fields[i].Name + ": " + err.Error())
+		}
+		jsonString = jsonString + fmt.Sprintf(`"%s":%s`, p.Fields[i].Name, string(b))
+		if i < len(p.Fields)-1 {
+			jsonString = jsonString + ","
+		}
+	}
+	return []byte(jsonString + "}"), nil
+}
+
+// StructDef is the abi struct for the payload, generators can change a field's type so it may not match
+// what is on chain.
+func (p *Payload) StructDef() eos.StructDef {
+	def := eos.StructDef{Name: p.Action, Fields: make([]eos.FieldDef, 0)}
+	for _, f := range p.Fields {
+		def.Fields = append(def.Fields, eos.FieldDef{Name: f.Name, Type: f.Type})
+	}
+	return def
+}
+
+// Actor is the value of a field named actor, or empty
+func (p *Payload) Actor() string {
+	for i, f := range p.Fields {
+		if f.Name == "actor" && p.Values[i].V != nil {
+			return fmt.Sprintf("%v", p.Values[i].V)
+		}
+	}
+	return ""
+}
+
+// PackOptions are the action editor's transaction settings
+type PackOptions struct {
+	Compress  bool
+	DelaySecs uint32
+	// Msig extends the expiration to an hour, the transaction gets embedded in a proposal
+	Msig bool
+}
+
+// PackAndSign encodes the payload using the on-chain abi (updated with any type changes,) and signs it with
+// the api's signer. The returned json is the action data as it was sent.
+func PackAndSign(api *fio.API, opts *fio.TxOptions, account *fio.Account, p *Payload, po PackOptions) (json.RawMessage, *eos.PackedTransaction, error) {
+	if p == nil || len(p.Fields) == 0 {
+		return nil, nil, nil
+	}
+	rawJ, action, _, err := encodeAction(api, account, p)
+	if err != nil {
+		return nil, nil, err
+	}
+	packedTx, err := signActions(api, opts, []*fio.Action{action}, po)
+	if err != nil {
+		return nil, nil, err
+	}
+	return rawJ, packedTx, nil
+}
+
+// encodeAction builds the action for a payload, the json is the data as it was encoded and the abi is the one
+// that encoded it
+func encodeAction(api *fio.API, account *fio.Account, p *Payload) (json.RawMessage, *fio.Action, *eos.ABI, error) {
+	jsonBytes, err := p.Json()
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	rawJ := json.RawMessage(jsonBytes)
+	// make sure we can marshall the json we just created ...
+	if len(jsonBytes) >= 524288 {
+		rawJ = []byte(fmt.Sprintf(`{"message":"Not showing request: %d bytes is too large"}"`, len(jsonBytes)))
+	} else {
+		err = json.Unmarshal(jsonBytes, &rawJ)
+		if err != nil {
+			return nil, nil, nil, errors.New("could not marshal new data into json: " + err.Error())
+		}
+	}
+
+	// get the "real" abi, and we will update it with any changes:
+	newAbi, err := api.GetABI(eos.AccountName(p.Contract))
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	for i, def := range newAbi.ABI.Structs {
+		if def.Name == p.Action {
+			newAbi.ABI.Structs[i] = p.StructDef()
+			break
+		}
+	}
+	encoded, err := newAbi.ABI.EncodeAction(eos.ActionName(p.Action), jsonBytes)
+	if err != nil && len(newAbi.ABI.Variants) > 0 {
+		// the local encoder can't handle variants, let the node do it
+		m := make(eos.M)
+		if e := json.Unmarshal(jsonBytes, &m); e != nil {
+			return nil, nil, nil, err
+		}
+		if hexData, e := api.ABIJSONToBin(eos.AccountName(p.Contract), eos.Name(p.Action), m); e == nil {
+			encoded, err = hexData, nil
+		}
+	}
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	actionData := eos.NewActionData(nil)
+	actionData.HexData = encoded
+	auth := p.Authorization
+	if len(auth) == 0 {
+		finalActor := p.Actor()
+		if finalActor == "" {
+			finalActor = string(account.Actor)
+		}
+		auth = []eos.PermissionLevel{
+			{
+				Actor:      eos.AccountName(finalActor),
+				Permission: "active",
+			},
+		}
+	}
+	return rawJ, &fio.Action{
+		Account:       eos.AccountName(p.Contract),
+		Name:          eos.ActionName(p.Action),
+		Authorization: auth,
+		ActionData:    actionData,
+	}, &newAbi.ABI, nil
+}
+
+func signActions(api *fio.API, opts *fio.TxOptions, actions []*fio.Action, po PackOptions) (*eos.PackedTransaction, error) {
+	compression := fio.CompressionNone
+	if po.Compress {
+		compression = fio.CompressionZlib
+	}
+	opts.TxOptions.DelaySecs = po.DelaySecs
+	signMe := fio.NewTransaction(actions, opts)
+	if po.Msig {
+		signMe.Expiration.Time = time.Now().Add(time.Hour)
+	}
+	_, packedTx, err := api.SignTransaction(signMe, opts.ChainID, compression)
+	return packedTx, err
+}
@@ -0,0 +1,102 @@
+package engine
+
+import (
+	"encoding/json"
+	"github.com/fioprotocol/fio-go"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadAction(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cryptonym-engine")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	y := filepath.Join(dir, "transfer.yaml")
+	err = ioutil.WriteFile(y, []byte(`contract: fio.token
+action: trnsfiopubky
+fields:
+  - name: payee_public_key
+    send_as: pub key
+    variation: random
+  - name: amount
+    type: int64
+    input: "1000000000"
+`), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	a, err := LoadAction(y)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a.Contract != "fio.token" || a.Action != "trnsfiopubky" || len(a.Fields) != 2 {
+		t.Fatalf("did not load action: %+v", a)
+	}
+	if a.Fields[0].SendAs != "pub key" || a.Fields[1].Input != "1000000000" {
+		t.Errorf("fields were not read: %+v", a.Fields)
+	}
+
+	j := filepath.Join(dir, "bad.json")
+	_ = ioutil.WriteFile(j, []byte(`{"fields":[]}`), 0600)
+	if _, err = LoadAction(j); err == nil {
+		t.Error("loaded an action without a contract")
+	}
+}
+
+func TestPayloadJson(t *testing.T) {
+	key, err := fio.NewRandomAccount()
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := &Action{
+		Contract: "fio.token",
+		Action:   "trnsfiopubky",
+		Fields: []Field{
+			{Name: "payee_public_key", Type: "string", SendAs: "pub key", Variation: "mine"},
+			{Name: "amount", Type: "int64", SendAs: "form value", Variation: "FIO -> suf", Input: "1,000.5"},
+			{Name: "max_fee", Type: "string", SendAs: "number", Variation: "random int", Len: "32"},
+			{Name: "actor", Type: "name", SendAs: "actor", Variation: "mine"},
+			{Name: "tpid", Type: "string", SendAs: "bytes/string", Variation: "string", Len: "8"},
+		},
+	}
+	p, err := a.Generate(key, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := p.Json()
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := make(map[string]interface{})
+	if err = json.Unmarshal(b, &out); err != nil {
+		t.Fatal(string(b), err)
+	}
+	if out["payee_public_key"] != key.PubKey {
+		t.Error("pub key was not mine")
+	}
+	if out["amount"] != float64(1_000_500_000_000) {
+		t.Errorf("amount should be in suf, got %v", out["amount"])
+	}
+	if _, ok := out["max_fee"].(string); !ok {
+		t.Errorf("number sent to a string field should be quoted, got %v", out["max_fee"])
+	}
+	if s, _ := out["tpid"].(string); len(s) != 8 {
+		t.Errorf("expected an 8 character string, got %q", s)
+	}
+	if p.Actor() != string(key.Actor) {
+		t.Error("actor field was not used for authorization")
+	}
+	if def := p.StructDef(); def.Name != "trnsfiopubky" || len(def.Fields) != 5 || def.Fields[2].Type != "string" {
+		t.Errorf("wrong struct def %+v", def)
+	}
+
+	a.Fields[0].Variation = "nope"
+	if _, err = a.Generate(key, ""); err == nil {
+		t.Error("generated a payload with an unknown variation")
+	}
+}
@@ -0,0 +1,191 @@
+package engine
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/fioprotocol/fio-go"
+	"github.com/fioprotocol/fio-go/eos"
+	"strings"
+	"sync"
+)
+
+// Composed is one action in a multi-action transaction
+type Composed struct {
+	Action *Action
+	// Authorization is who signs this action, if empty it's the actor field or the signing account
+	Authorization []eos.PermissionLevel
+}
+
+func (c Composed) String() string {
+	auth := make([]string, len(c.Authorization))
+	for i := range c.Authorization {
+		auth[i] = string(c.Authorization[i].Actor) + "@" + string(c.Authorization[i].Permission)
+	}
+	if len(auth) == 0 {
+		auth = append(auth, "actor")
+	}
+	return fmt.Sprintf("%s::%s (%s)", c.Action.Contract, c.Action.Action, strings.Join(auth, ", "))
+}
+
+// Composition stacks actions from any contract into one transaction, they run in the order they are kept in
+type Composition struct {
+	mux     sync.Mutex
+	actions []Composed
+}
+
+func NewComposition() *Composition {
+	return &Composition{actions: make([]Composed, 0)}
+}
+
+// NewAction is an action for a composition, abi is only needed by the "abi struct" generator
+func NewAction(contract string, action string, fields []Field, abi *eos.ABI) *Action {
+	return &Action{Contract: contract, Action: action, Fields: append([]Field{}, fields...), abi: abi}
+}
+
+func (c *Composition) Add(a Composed) {
+	c.mux.Lock()
+	c.actions = append(c.actions, a)
+	c.mux.Unlock()
+}
+
+func (c *Composition) Remove(i int) {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	if i < 0 || i >= len(c.actions) {
+		return
+	}
+	c.actions = append(c.actions[:i], c.actions[i+1:]...)
+}
+
+// Move puts the action at from in position to, the others keep their order
+func (c *Composition) Move(from int, to int) {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	if from < 0 || from >= len(c.actions) || to < 0 || to >= len(c.actions) || from == to {
+		return
+	}
+	a := c.actions[from]
+	c.actions = append(c.actions[:from], c.actions[from+1:]...)
+	c.actions = append(c.actions[:to], append([]Composed{a}, c.actions[to:]...)...)
+}
+
+func (c *Composition) Clear() {
+	c.mux.Lock()
+	c.actions = make([]Composed, 0)
+	c.mux.Unlock()
+}
+
+// Actions is a copy of the actions in order
+func (c *Composition) Actions() []Composed {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	return append([]Composed{}, c.actions...)
+}
+
+// String lists the actions in order, it labels failures from the composition
+func (c *Composition) String() string {
+	actions := c.Actions()
+	names := make([]string, len(actions))
+	for i, a := range actions {
+		names[i] = a.Action.Contract + "::" + a.Action.Action
+	}
+	return strings.Join(names, ", ")
+}
+
+func (c *Composition) Len() int {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	return len(c.actions)
+}
+
+// Generate builds a payload for each action, in order. Call it inside fuzzer.WithSeed for a repeatable run.
+func (c *Composition) Generate(key *fio.Account, uri string) ([]*Payload, error) {
+	actions := c.Actions()
+	if len(actions) == 0 {
+		return nil, errors.New("there are no actions in the composition")
+	}
+	payloads := make([]*Payload, len(actions))
+	for i, a := range actions {
+		p, err := a.Action.Generate(key, uri)
+		if err != nil {
+			return nil, fmt.Errorf("action %d (%s::%s): %s", i+1, a.Action.Contract, a.Action.Action, err)
+		}
+		p.Authorization = a.Authorization
+		payloads[i] = p
+	}
+	return payloads, nil
+}
+
+// PackActions is PackAndSign for several payloads in one transaction, the json is every action with its
+// authorization and data.
+func PackActions(api *fio.API, opts *fio.TxOptions, account *fio.Account, payloads []*Payload, po PackOptions) (json.RawMessage, *eos.PackedTransaction, error) {
+	actions := make([]*fio.Action, len(payloads))
+	for i, p := range payloads {
+		_, a, _, err := encodeAction(api, account, p)
+		if err != nil {
+			return nil, nil, fmt.Errorf("action %d (%s::%s): %s", i+1, p.Contract, p.Action, err)
+		}
+		actions[i] = a
+	}
+	raw, err := Preview(account, payloads)
+	if err != nil {
+		return nil, nil, err
+	}
+	tx, err := signActions(api, opts, actions, po)
+	if err != nil {
+		return nil, nil, err
+	}
+	return raw, tx, nil
+}
+
+type previewAction struct {
+	Account       string                `json:"account"`
+	Name          string                `json:"name"`
+	Authorization []eos.PermissionLevel `json:"authorization"`
+	Data          json.RawMessage       `json:"data"`
+}
+
+// Preview is the actions as they would be sent, without encoding or signing them
+func Preview(account *fio.Account, payloads []*Payload) (json.RawMessage, error) {
+	out := make([]previewAction, len(payloads))
+	for i, p := range payloads {
+		data, err := p.Json()
+		if err != nil {
+			return nil, err
+		}
+		if !json.Valid(data) {
+			return nil, fmt.Errorf("action %d (%s::%s) is not valid json", i+1, p.Contract, p.Action)
+		}
+		auth := p.Authorization
+		if len(auth) == 0 {
+			actor := p.Actor()
+			if actor == "" && account != nil {
+				actor = string(account.Actor)
+			}
+			auth = []eos.PermissionLevel{{Actor: eos.AccountName(actor), Permission: "active"}}
+		}
+		out[i] = previewAction{Account: p.Contract, Name: p.Action, Authorization: auth, Data: data}
+	}
+	return json.MarshalIndent(out, "", "  ")
+}
+
+// ParseAuthorization reads a comma separated list of actor@permission, the permission defaults to active
+func ParseAuthorization(s string) ([]eos.PermissionLevel, error) {
+	auth := make([]eos.PermissionLevel, 0)
+	for _, a := range strings.Split(s, ",") {
+		a = strings.TrimSpace(a)
+		if a == "" {
+			continue
+		}
+		perm := "active"
+		if i := strings.Index(a, "@"); i >= 0 {
+			a, perm = a[:i], a[i+1:]
+		}
+		if _, err := eos.StringToName(a); err != nil || a == "" || perm == "" {
+			return nil, fmt.Errorf("invalid authorization %q, expecting actor@permission", a+"@"+perm)
+		}
+		auth = append(auth, eos.PermissionLevel{Actor: eos.AccountName(a), Permission: eos.PermissionName(perm)})
+	}
+	return auth, nil
+}
@@ -0,0 +1,131 @@
+package engine
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"github.com/fioprotocol/fio-go"
+	"github.com/fioprotocol/fio-go/eos"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestComposition(t *testing.T) {
+	c := NewComposition()
+	for _, name := range []string{"a", "b", "c"} {
+		c.Add(Composed{Action: NewAction("fio.token", name, nil, nil)})
+	}
+	order := func() (s string) {
+		for _, a := range c.Actions() {
+			s += a.Action.Action
+		}
+		return
+	}
+	c.Move(0, 2)
+	if order() != "bca" {
+		t.Error("expected bca, got", order())
+	}
+	c.Move(2, 0)
+	c.Move(1, 5)
+	if order() != "abc" {
+		t.Error("expected abc, got", order())
+	}
+	c.Remove(1)
+	if order() != "ac" {
+		t.Error("expected ac, got", order())
+	}
+
+	auth, err := ParseAuthorization("eosio, fio.address@owner")
+	if err != nil || len(auth) != 2 || auth[0].Permission != "active" || auth[1].Permission != "owner" {
+		t.Error("unexpected authorization", auth, err)
+	}
+	if _, err = ParseAuthorization("eosio@"); err == nil {
+		t.Error("expected an error for an empty permission")
+	}
+}
+
+func TestPackActions(t *testing.T) {
+	server := testChain()
+	defer server.Close()
+
+	account, _ := fio.NewAccountFromWif("5KC6Edd4BcKTLnRuGj2c8TRT9oLuuXLd3ZuCGxM9iNngc3D8S93")
+	s, err := NewSession(context.Background(), server.URL, account)
+	if err != nil {
+		t.Fatal(err)
+	}
+	api, err := s.Api(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c := NewComposition()
+	c.Add(Composed{Action: NewAction("fio.address", "first", []Field{{Name: "memo", Type: "string", SendAs: "form value", Variation: "as is", Input: "hello"}}, nil)})
+	c.Add(Composed{
+		Action:        NewAction("fio.token", "second", []Field{{Name: "amount", Type: "uint64", SendAs: "form value", Variation: "as is", Input: "5"}}, nil),
+		Authorization: []eos.PermissionLevel{{Actor: "eosio", Permission: "owner"}},
+	})
+	payloads, err := c.Generate(account, server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw, tx, err := PackActions(api, s.Opts(), account, payloads, PackOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	signed, err := tx.Unpack()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(signed.Actions) != 2 || signed.Actions[0].Account != "fio.address" || signed.Actions[1].Account != "fio.token" {
+		t.Fatal("actions are missing or out of order")
+	}
+	if signed.Actions[0].Authorization[0].Actor != account.Actor {
+		t.Error("first action should default to the signing account")
+	}
+	if signed.Actions[1].Authorization[0].Actor != "eosio" || signed.Actions[1].Authorization[0].Permission != "owner" {
+		t.Error("second action should keep its own authorization")
+	}
+	preview := make([]previewAction, 0)
+	if err = json.Unmarshal(raw, &preview); err != nil || len(preview) != 2 {
+		t.Fatalf("unexpected preview %s %v", string(raw), err)
+	}
+	data := bytes.NewBuffer(nil)
+	_ = json.Compact(data, preview[1].Data)
+	if data.String() != `{"amount":5}` {
+		t.Error("unexpected preview data", data.String())
+	}
+}
+
+// testChain answers get_info, get_fee, get_required_keys (every key is required,) and get_abi with the same
+// actions for any contract
+func testChain() *httptest.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/chain/get_info", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"chain_id":"b20901380af44ef59c5918439a1f9a41d83669020319a80574b804a5f95cbd7e","head_block_num":10,"last_irreversible_block_num":9,"head_block_id":"0000000a5c5e4d2a2da1b4e5e9c9b2ad7b0c0a4b3e9e3f9a7b1a9d4c6f2a1b3c","head_block_time":"2020-01-01T00:00:00.000","server_version":"v2.0.7"}`))
+	})
+	mux.HandleFunc("/v1/chain/get_abi", func(w http.ResponseWriter, r *http.Request) {
+		req := struct {
+			AccountName string `json:"account_name"`
+		}{}
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		_, _ = w.Write([]byte(`{"account_name":"` + req.AccountName + `","abi":{"version":"eosio::abi/1.1","structs":[
+			{"name":"first","base":"","fields":[{"name":"memo","type":"string"}]},
+			{"name":"second","base":"","fields":[{"name":"amount","type":"uint64"}]},
+			{"name":"regaddress","base":"","fields":[{"name":"fio_address","type":"string"},{"name":"max_fee","type":"uint64"}]}],
+			"actions":[{"name":"first","type":"first","ricardian_contract":""},{"name":"second","type":"second","ricardian_contract":""},
+			{"name":"regaddress","type":"regaddress","ricardian_contract":""}]}}`))
+	})
+	mux.HandleFunc("/v1/chain/get_fee", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"fee":40000000000}`))
+	})
+	mux.HandleFunc("/v1/chain/get_required_keys", func(w http.ResponseWriter, r *http.Request) {
+		req := struct {
+			AvailableKeys []string `json:"available_keys"`
+		}{}
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		j, _ := json.Marshal(map[string][]string{"required_keys": req.AvailableKeys})
+		_, _ = w.Write(j)
+	})
+	return httptest.NewServer(mux)
+}
@@ -0,0 +1,101 @@
+package engine
+
+import (
+	"encoding/json"
+	"github.com/fioprotocol/fio-go"
+	"github.com/fioprotocol/fio-go/eos"
+	"math"
+	"sort"
+)
+
+// Contracts lists the actions and tables for every contract with an abi, Index is sorted by account name.
+type Contracts struct {
+	Index   []string
+	Actions map[string][]string
+	Tables  map[string][]string
+	// Errors has any contract whose abi couldn't be loaded, it is skipped.
+	Errors map[string]error
+}
+
+type contracts struct {
+	Owner string `json:"owner"`
+}
+
+// GetContracts reads the abihash table to find contracts, and loads their abis
+func GetContracts(api *fio.API) (*Contracts, error) {
+	table, err := api.GetTableRows(eos.GetTableRowsRequest{
+		Code:  "eosio",
+		Scope: "eosio",
+		Table: "abihash",
+		Limit: uint32(math.MaxUint32),
+		JSON:  true,
+	})
+	if err != nil {
+		return nil, err
+	}
+	result := make([]contracts, 0)
+	err = json.Unmarshal(table.Rows, &result)
+	if err != nil {
+		return nil, err
+	}
+	// FIXME: reading the abihash table isn't returning everything because of how the chain is boostrapped.
+	// for now, appending a list of known contracts if not found :(
+	defaults := []contracts{
+		{Owner: "eosio"},
+		//{Owner: "eosio.bios"},
+		{Owner: "eosio.msig"},
+		{Owner: "eosio.wrap"},
+		{Owner: "fio.address"},
+		//{Owner: "fio.common"},
+		{Owner: "fio.fee"},
+		{Owner: "fio.foundatn"},
+		{Owner: "fio.reqobt"},
+		//{Owner: "fio.system"},
+		{Owner: "fio.token"},
+		{Owner: "fio.tpid"},
+		{Owner: "fio.treasury"},
+		//{Owner: "fio.whitelst"},
+	}
+	for _, def := range defaults {
+		func() {
+			for _, found := range result {
+				if def.Owner == found.Owner {
+					return
+				}
+			}
+			result = append(result, def)
+		}()
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Owner < result[j].Owner
+	})
+
+	c := &Contracts{
+		Index:   make([]string, 0),
+		Actions: make(map[string][]string),
+		Tables:  make(map[string][]string),
+		Errors:  make(map[string]error),
+	}
+	for _, name := range result {
+		bi, err := api.GetABI(eos.AccountName(name.Owner))
+		if err != nil {
+			c.Errors[name.Owner] = err
+			continue
+		}
+		if c.Actions[name.Owner] == nil {
+			c.Actions[name.Owner] = make([]string, 0)
+			c.Index = append(c.Index, name.Owner)
+		}
+		actionList := make(map[string]bool)
+		for _, a := range bi.ABI.Actions {
+			if !actionList[string(a.Name)] {
+				c.Actions[name.Owner] = append(c.Actions[name.Owner], string(a.Name))
+			}
+			actionList[string(a.Name)] = true
+		}
+		for _, table := range bi.ABI.Tables {
+			c.Tables[name.Owner] = append(c.Tables[name.Owner], string(table.Name))
+		}
+	}
+	return c, nil
+}
@@ -0,0 +1,172 @@
+package engine
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/fioprotocol/fio-go"
+	"github.com/fioprotocol/fio-go/eos"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// DryRun is what a transaction would cost and who has to sign it, worked out without broadcasting anything
+type DryRun struct {
+	Actions int `json:"actions" yaml:"Actions"`
+	// ActionBytes is the same as TxSummary.TotalBytes after a push
+	ActionBytes  int         `json:"action_bytes" yaml:"Action Data Bytes"`
+	PackedBytes  int         `json:"packed_bytes" yaml:"Packed Transaction Bytes"`
+	ZlibBytes    int         `json:"zlib_bytes" yaml:"Zlib Compressed Bytes"`
+	Fees         []ActionFee `json:"fees,omitempty" yaml:"Fees,omitempty"`
+	TotalFee     string      `json:"total_fee" yaml:"Total Fee"`
+	RequiredKeys []string    `json:"required_keys" yaml:"Required Keys"`
+	// MissingAuthority is set if the node says the signing keys can't satisfy the transaction
+	MissingAuthority string   `json:"missing_authority,omitempty" yaml:"Missing Authority,omitempty"`
+	Warnings         []string `json:"warnings,omitempty" yaml:"Warnings,omitempty"`
+
+	totalFee  uint64
+	feeTooLow bool
+}
+
+// ActionFee is the fee get_fee reports for one action, paid by the first actor in its authorization
+type ActionFee struct {
+	Action     string `json:"action" yaml:"Action"`
+	EndPoint   string `json:"end_point" yaml:"Endpoint"`
+	FioAddress string `json:"fio_address,omitempty" yaml:"FIO Address,omitempty"`
+	Fee        string `json:"fee" yaml:"Fee"`
+	MaxFee     string `json:"max_fee,omitempty" yaml:"Max Fee,omitempty"`
+}
+
+// Ok is false if the transaction would be rejected for its max_fee or missing authority
+func (d *DryRun) Ok() bool {
+	return d.MissingAuthority == "" && !d.feeTooLow
+}
+
+// TotalSuf is the sum of the fees in SUFs
+func (d *DryRun) TotalSuf() uint64 {
+	return d.totalFee
+}
+
+// DryRunActions encodes the payloads the same way PackActions does and reports the sizes, fees and required
+// keys. The api needs a signer for get_required_keys, nothing is signed or sent.
+func DryRunActions(api *fio.API, opts *fio.TxOptions, account *fio.Account, payloads []*Payload, po PackOptions) (*DryRun, error) {
+	if len(payloads) == 0 {
+		return nil, errors.New("there is nothing to check")
+	}
+	d := &DryRun{Actions: len(payloads), RequiredKeys: make([]string, 0)}
+	if po.Msig {
+		d.Warnings = append(d.Warnings, "propose is set, this is the transaction inside the proposal")
+	}
+	actions := make([]*fio.Action, len(payloads))
+	addresses := make(map[eos.AccountName]string)
+	for i, p := range payloads {
+		_, a, _, err := encodeAction(api, account, p)
+		if err != nil {
+			return nil, fmt.Errorf("action %d (%s::%s): %s", i+1, p.Contract, p.Action, err)
+		}
+		actions[i] = a
+		d.ActionBytes += len(a.HexData)
+
+		endpoint, ok := FeeEndpoints[p.Action]
+		if !ok {
+			continue
+		}
+		payer := a.Authorization[0].Actor
+		if _, ok = addresses[payer]; !ok {
+			addresses[payer] = ""
+			if names, found, _ := api.GetFioNamesForActor(string(payer)); found && len(names.FioAddresses) > 0 {
+				addresses[payer] = names.FioAddresses[0].FioAddress
+			}
+		}
+		af := ActionFee{Action: p.Contract + "::" + p.Action, EndPoint: endpoint, FioAddress: addresses[payer]}
+		fee, err := GetFee(api, af.FioAddress, endpoint)
+		if err != nil {
+			d.Warnings = append(d.Warnings, fmt.Sprintf("action %d: get_fee failed, using the fee table: %s", i+1, err))
+			fee = fio.Tokens(fio.GetMaxFee(endpoint))
+		}
+		d.totalFee += fee
+		af.Fee = FioString(fee)
+		if maxFee, ok := payloadMaxFee(p); ok {
+			af.MaxFee = FioString(maxFee)
+			if maxFee < fee {
+				d.feeTooLow = true
+				d.Warnings = append(d.Warnings, fmt.Sprintf("action %d: max_fee %s is below the fee %s", i+1, af.MaxFee, af.Fee))
+			}
+		}
+		d.Fees = append(d.Fees, af)
+	}
+	d.TotalFee = FioString(d.totalFee)
+
+	opts.TxOptions.DelaySecs = po.DelaySecs
+	tx := fio.NewTransaction(actions, opts)
+	packed, err := eos.NewSignedTransaction(tx).Pack(fio.CompressionNone)
+	if err != nil {
+		return nil, err
+	}
+	d.PackedBytes = len(packed.PackedTransaction)
+	if packed, err = eos.NewSignedTransaction(tx).Pack(fio.CompressionZlib); err != nil {
+		return nil, err
+	}
+	d.ZlibBytes = len(packed.PackedTransaction)
+
+	if api.Signer == nil {
+		d.MissingAuthority = "no signing key is loaded"
+		return d, nil
+	}
+	required, err := api.GetRequiredKeys(tx)
+	if err != nil {
+		// the node's message is the useful part, "transaction declares authority ... but does not have signatures"
+		d.MissingAuthority = err.Error()
+		return d, nil
+	}
+	for _, k := range required.RequiredKeys {
+		d.RequiredKeys = append(d.RequiredKeys, "FIO"+k.String()[3:])
+	}
+	return d, nil
+}
+
+// GetFee asks the node what an endpoint costs the owner of fioAddress, with bundled transactions taken into
+// account. fio-go's GetFee returns 0 when the node responds with an error, this doesn't.
+func GetFee(api *fio.API, fioAddress string, endpoint string) (uint64, error) {
+	j, _ := json.Marshal(&fio.GetFeeRequest{FioAddress: fioAddress, EndPoint: endpoint})
+	resp, err := api.HttpClient.Post(api.BaseURL+"/v1/chain/get_fee", "application/json", bytes.NewReader(j))
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return 0, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("%d %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	fee := &fio.GetFeeResponse{}
+	if err = json.Unmarshal(body, fee); err != nil {
+		return 0, err
+	}
+	return fee.Fee, nil
+}
+
+// FioString formats SUFs as FIO, without trailing zeros
+func FioString(suf uint64) string {
+	return strconv.FormatFloat(float64(suf)/1_000_000_000.0, 'f', -1, 64) + " FIO"
+}
+
+// payloadMaxFee reads the max_fee field, if the action has one
+func payloadMaxFee(p *Payload) (uint64, bool) {
+	j, err := p.Json()
+	if err != nil {
+		return 0, false
+	}
+	fields := make(map[string]json.RawMessage)
+	if json.Unmarshal(j, &fields) != nil || fields["max_fee"] == nil {
+		return 0, false
+	}
+	s := strings.Trim(string(fields["max_fee"]), `"`)
+	fee, err := strconv.ParseUint(s, 10, 64)
+	return fee, err == nil
+}
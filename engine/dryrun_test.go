@@ -0,0 +1,50 @@
+package engine
+
+import (
+	"context"
+	"github.com/fioprotocol/fio-go"
+	"testing"
+)
+
+func TestDryRun(t *testing.T) {
+	server := testChain()
+	defer server.Close()
+
+	account, _ := fio.NewAccountFromWif("5KC6Edd4BcKTLnRuGj2c8TRT9oLuuXLd3ZuCGxM9iNngc3D8S93")
+	s, err := NewSession(context.Background(), server.URL, account)
+	if err != nil {
+		t.Fatal(err)
+	}
+	action := NewAction("fio.address", "regaddress", []Field{
+		{Name: "fio_address", Type: "string", SendAs: "form value", Variation: "as is", Input: "test@dapixdev"},
+		{Name: "max_fee", Type: "uint64", SendAs: "form value", Variation: "FIO -> suf", Input: "40"},
+	}, nil)
+	p, err := action.Generate(account, server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d, err := s.DryRun(context.Background(), []*Payload{p}, PackOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !d.Ok() || d.TotalSuf() != 40_000_000_000 || d.TotalFee != "40 FIO" || len(d.Fees) != 1 || d.Fees[0].EndPoint != fio.FeeRegisterFioAddress {
+		t.Errorf("unexpected fees %+v", d)
+	}
+	if d.ActionBytes == 0 || d.PackedBytes <= d.ActionBytes || d.ZlibBytes == 0 {
+		t.Errorf("unexpected sizes %+v", d)
+	}
+	if len(d.RequiredKeys) != 1 || d.RequiredKeys[0] != account.PubKey {
+		t.Error("expected the account's key to be required, got", d.RequiredKeys)
+	}
+
+	action.Fields[1].Input = "1"
+	if p, err = action.Generate(account, server.URL); err != nil {
+		t.Fatal(err)
+	}
+	if d, err = s.DryRun(context.Background(), []*Payload{p}, PackOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if d.Ok() || len(d.Warnings) != 1 {
+		t.Error("a max_fee below the fee should fail the dry run", d.Warnings)
+	}
+}
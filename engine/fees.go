@@ -0,0 +1,304 @@
+package engine
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/fioprotocol/fio-go"
+	"github.com/fioprotocol/fio-go/eos"
+	"reflect"
+	"sort"
+	"sync"
+	"time"
+)
+
+// FeeEndpoints maps actions to the endpoint name used by get_fee and the fiofees table. fio-go has the same
+// list but doesn't export it.
+var FeeEndpoints = map[string]string{
+	"addaddress":   fio.FeeAddPubAddress,
+	"approve":      fio.FeeMsigApprove,
+	"bundlevote":   fio.FeeBundleVote,
+	"burnaddress":  fio.FeeBurnAddress,
+	"cancel":       fio.FeeMsigCancel,
+	"cancelfndreq": fio.FeeCancelFundsRequest,
+	"deleteauth":   fio.FeeAuthDelete,
+	"exec":         fio.FeeMsigExec,
+	"invalidate":   fio.FeeMsigInvalidate,
+	"linkauth":     fio.FeeAuthLink,
+	"newfundsreq":  fio.FeeNewFundsRequest,
+	"propose":      fio.FeeMsigPropose,
+	"recordobt":    fio.FeeRecordObtData,
+	"regaddress":   fio.FeeRegisterFioAddress,
+	"regdomain":    fio.FeeRegisterFioDomain,
+	"regproducer":  fio.FeeRegisterProducer,
+	"regproxy":     fio.FeeRegisterProxy,
+	"rejectfndreq": fio.FeeRejectFundsRequest,
+	"remaddress":   fio.FeeRemovePubAddress,
+	"remalladdr":   fio.FeeRemoveAllAddresses,
+	"renewaddress": fio.FeeRenewFioAddress,
+	"renewdomain":  fio.FeeRenewFioDomain,
+	"setdomainpub": fio.FeeSetDomainPub,
+	"setfeemult":   fio.FeeSubmitFeeMult,
+	"setfeevote":   fio.FeeSubmitFeeVote,
+	"trnsfiopubky": fio.FeeTransferTokensPubKey,
+	"trnsloctoks":  fio.FeeTransferLockedTokens,
+	"unapprove":    fio.FeeMsigUnapprove,
+	"unregprod":    fio.FeeUnregisterProducer,
+	"unregproxy":   fio.FeeUnregisterProxy,
+	"updateauth":   fio.FeeAuthUpdate,
+	"voteproducer": fio.FeeVoteProducer,
+	"voteproxy":    fio.FeeProxyVote,
+	"xferaddress":  fio.FeeTransferAddress,
+	"xferdomain":   fio.FeeTransferDom,
+}
+
+// DefaultFeeWindow is how many blocks the fee table is kept for, about a minute
+const DefaultFeeWindow = 120
+
+// ErrNoFee is returned for actions that don't have an entry in the fee table
+var ErrNoFee = errors.New("no fee for this action")
+
+// FeeInfo is one row from the fiofees table
+type FeeInfo struct {
+	EndPoint string `json:"end_point" yaml:"Endpoint"`
+	Suf      uint64 `json:"suf_amount" yaml:"Fee"`
+	// Bundled fees are free while the payer's address has bundled transactions left
+	Bundled      bool `json:"bundled" yaml:"Bundle Eligible"`
+	VotesPending bool `json:"votes_pending" yaml:"Votes Pending"`
+}
+
+// FeeService reads fees from the fio.fee contract instead of fio-go's table, which is only loaded when connecting
+// and goes stale after fee votes. Everything is cached for Window blocks.
+type FeeService struct {
+	Window uint32
+
+	mux        sync.Mutex
+	url        string
+	head       uint32
+	headAt     time.Time
+	loadedAt   uint32
+	fees       map[string]FeeInfo
+	multiplier float64
+	bundles    map[string]cachedInt
+	addresses  map[eos.AccountName]cachedString
+}
+
+type cachedInt struct {
+	v  int
+	at uint32
+}
+
+type cachedString struct {
+	v  string
+	at uint32
+}
+
+func NewFeeService(window uint32) *FeeService {
+	if window == 0 {
+		window = DefaultFeeWindow
+	}
+	return &FeeService{Window: window}
+}
+
+// Fee is what endpoint costs the owner of fioAddress: nothing if the fee is bundle eligible and the address has
+// bundled transactions left, otherwise the table's fee. An empty fioAddress gets the table's fee, which is what a
+// max_fee has to cover.
+func (f *FeeService) Fee(api *fio.API, endpoint string, fioAddress string) (uint64, error) {
+	f.mux.Lock()
+	defer f.mux.Unlock()
+	head, err := f.refresh(api)
+	if err != nil {
+		return 0, err
+	}
+	info, ok := f.fees[endpoint]
+	if !ok {
+		return 0, fmt.Errorf("%w: %s", ErrNoFee, endpoint)
+	}
+	if !info.Bundled || fioAddress == "" {
+		return info.Suf, nil
+	}
+	b, ok := f.bundles[fioAddress]
+	if !ok || f.stale(b.at, head) {
+		remaining, err := api.GetBundleRemaining(fio.Address(fioAddress))
+		if err != nil {
+			return info.Suf, nil
+		}
+		b = cachedInt{v: remaining, at: head}
+		f.bundles[fioAddress] = b
+	}
+	if b.v > 0 {
+		return 0, nil
+	}
+	return info.Suf, nil
+}
+
+// ForAction is Fee for a contract action
+func (f *FeeService) ForAction(api *fio.API, action string, fioAddress string) (uint64, error) {
+	endpoint, ok := FeeEndpoints[action]
+	if !ok {
+		return 0, fmt.Errorf("%w: %s", ErrNoFee, action)
+	}
+	return f.Fee(api, endpoint, fioAddress)
+}
+
+// PayerAddress is the first FIO address owned by actor, empty if it doesn't have one
+func (f *FeeService) PayerAddress(api *fio.API, actor eos.AccountName) string {
+	f.mux.Lock()
+	defer f.mux.Unlock()
+	head, err := f.refresh(api)
+	if err != nil {
+		return ""
+	}
+	if a, ok := f.addresses[actor]; ok && !f.stale(a.at, head) {
+		return a.v
+	}
+	a := cachedString{at: head}
+	if names, found, _ := api.GetFioNamesForActor(string(actor)); found && len(names.FioAddresses) > 0 {
+		a.v = names.FioAddresses[0].FioAddress
+	}
+	f.addresses[actor] = a
+	return a.v
+}
+
+// Table is every fee as of the last refresh
+func (f *FeeService) Table(api *fio.API) ([]FeeInfo, error) {
+	f.mux.Lock()
+	defer f.mux.Unlock()
+	if _, err := f.refresh(api); err != nil {
+		return nil, err
+	}
+	table := make([]FeeInfo, 0, len(f.fees))
+	for _, info := range f.fees {
+		table = append(table, info)
+	}
+	sort.Slice(table, func(i, j int) bool {
+		return table[i].EndPoint < table[j].EndPoint
+	})
+	return table, nil
+}
+
+// Multiplier is the median of the producers' fee multiplier votes. The table's fees already include it once
+// computefees has run, a VotesPending fee may still change.
+func (f *FeeService) Multiplier(api *fio.API) (float64, error) {
+	f.mux.Lock()
+	defer f.mux.Unlock()
+	_, err := f.refresh(api)
+	return f.multiplier, err
+}
+
+// Forget drops everything, the next call reloads
+func (f *FeeService) Forget() {
+	f.mux.Lock()
+	f.loadedAt, f.headAt = 0, time.Time{}
+	f.mux.Unlock()
+}
+
+func (f *FeeService) stale(at uint32, head uint32) bool {
+	return at == 0 || head < at || head-at >= f.Window
+}
+
+// refresh reloads the tables once the window has passed, or if the node changed. The head block is only asked
+// for every half second, about a block. Called with mux held.
+func (f *FeeService) refresh(api *fio.API) (head uint32, err error) {
+	if api == nil || api.API == nil {
+		return 0, ErrNotConnected
+	}
+	if f.url != api.BaseURL {
+		f.url, f.loadedAt, f.headAt = api.BaseURL, 0, time.Time{}
+	}
+	if time.Since(f.headAt) > 500*time.Millisecond {
+		info, err := api.GetInfo()
+		if err != nil {
+			return 0, err
+		}
+		f.head, f.headAt = info.HeadBlockNum, time.Now()
+	}
+	if !f.stale(f.loadedAt, f.head) {
+		return f.head, nil
+	}
+
+	rows, err := api.GetTableRows(eos.GetTableRowsRequest{
+		Code:  "fio.fee",
+		Scope: "fio.fee",
+		Table: "fiofees",
+		Limit: 1000,
+		JSON:  true,
+	})
+	if err != nil {
+		return 0, err
+	}
+	results := make([]fio.FioFee, 0)
+	if err = json.Unmarshal(rows.Rows, &results); err != nil {
+		return 0, err
+	}
+	if len(results) == 0 {
+		return 0, errors.New("the fiofees table is empty")
+	}
+	fees := make(map[string]FeeInfo)
+	for _, r := range results {
+		fees[r.EndPoint] = FeeInfo{EndPoint: r.EndPoint, Suf: r.SufAmount, Bundled: r.Type == 1, VotesPending: bool(r.VotesPending)}
+	}
+
+	multiplier := 1.0
+	if rows, err = api.GetTableRows(eos.GetTableRowsRequest{
+		Code:  "fio.fee",
+		Scope: "fio.fee",
+		Table: "feevoters",
+		Limit: 1000,
+		JSON:  true,
+	}); err == nil {
+		voters := make([]fio.FeeVoter, 0)
+		if json.Unmarshal(rows.Rows, &voters) == nil && len(voters) > 0 {
+			votes := make([]float64, len(voters))
+			for i := range voters {
+				votes[i] = voters[i].FeeMultiplier
+			}
+			sort.Float64s(votes)
+			multiplier = votes[len(votes)/2]
+			if len(votes)%2 == 0 {
+				multiplier = (votes[len(votes)/2-1] + votes[len(votes)/2]) / 2
+			}
+		}
+	}
+
+	f.fees, f.multiplier, f.loadedAt = fees, multiplier, f.head
+	f.bundles = make(map[string]cachedInt)
+	f.addresses = make(map[eos.AccountName]cachedString)
+	return f.head, nil
+}
+
+// SetMaxFee changes the max_fee of an action built by fio-go, its constructors use fio-go's copy of the fee table.
+// Returns false if the action doesn't have a max_fee.
+func SetMaxFee(a *fio.Action, fee uint64) bool {
+	if a == nil || a.ActionData.Data == nil {
+		return false
+	}
+	v := reflect.ValueOf(a.ActionData.Data)
+	isPtr := v.Kind() == reflect.Ptr
+	if isPtr {
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return false
+	}
+	c := reflect.New(v.Type()).Elem()
+	c.Set(v)
+	maxFee := c.FieldByName("MaxFee")
+	if !maxFee.IsValid() || !maxFee.CanSet() {
+		return false
+	}
+	switch maxFee.Kind() {
+	case reflect.Uint64:
+		maxFee.SetUint(fee)
+	case reflect.Int64:
+		maxFee.SetInt(int64(fee))
+	default:
+		return false
+	}
+	if isPtr {
+		a.ActionData.Data = c.Addr().Interface()
+	} else {
+		a.ActionData.Data = c.Interface()
+	}
+	return true
+}
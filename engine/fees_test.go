@@ -0,0 +1,83 @@
+package engine
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/fioprotocol/fio-go"
+	"github.com/fioprotocol/fio-go/eos"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestFeeService(t *testing.T) {
+	var head, loads, bundles int32 = 100, 0, 3
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/chain/get_info", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = fmt.Fprintf(w, `{"chain_id":"b20901380af44ef59c5918439a1f9a41d83669020319a80574b804a5f95cbd7e","head_block_num":%d}`, atomic.LoadInt32(&head))
+	})
+	mux.HandleFunc("/v1/chain/get_table_rows", func(w http.ResponseWriter, r *http.Request) {
+		req := struct {
+			Table string `json:"table"`
+		}{}
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		switch req.Table {
+		case "fiofees":
+			atomic.AddInt32(&loads, 1)
+			_, _ = w.Write([]byte(`{"rows":[
+				{"fee_id":1,"end_point":"register_fio_address","type":0,"suf_amount":40000000000,"votes_pending":0},
+				{"fee_id":2,"end_point":"add_pub_address","type":1,"suf_amount":400000000,"votes_pending":1}]}`))
+		case "feevoters":
+			_, _ = w.Write([]byte(`{"rows":[{"fee_multiplier":1.0},{"fee_multiplier":3.0},{"fee_multiplier":2.0}]}`))
+		case "fionames":
+			_, _ = fmt.Fprintf(w, `{"rows":[{"bundleeligiblecountdown":%d}]}`, atomic.LoadInt32(&bundles))
+		default:
+			_, _ = w.Write([]byte(`{"rows":[]}`))
+		}
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+	api := &fio.API{API: eos.New(server.URL)}
+
+	fees := NewFeeService(10)
+	if fee, err := fees.ForAction(api, "regaddress", "test@dapixdev"); err != nil || fee != 40_000_000_000 {
+		t.Error("expected the table fee for a mandatory fee", fee, err)
+	}
+	if fee, err := fees.Fee(api, fio.FeeAddPubAddress, "test@dapixdev"); err != nil || fee != 0 {
+		t.Error("expected no fee while there are bundled transactions", fee, err)
+	}
+	if fee, _ := fees.Fee(api, fio.FeeAddPubAddress, ""); fee != 400_000_000 {
+		t.Error("expected the table fee without a payer", fee)
+	}
+	if _, err := fees.ForAction(api, "nope", ""); err == nil {
+		t.Error("expected an error for an action without a fee")
+	}
+	if m, _ := fees.Multiplier(api); m != 2.0 {
+		t.Error("expected the median multiplier, got", m)
+	}
+
+	// inside the window nothing is reloaded, even if the bundle count changes
+	atomic.StoreInt32(&bundles, 0)
+	atomic.StoreInt32(&head, 105)
+	fees.headAt = fees.headAt.Add(-time.Second)
+	if fee, _ := fees.Fee(api, fio.FeeAddPubAddress, "test@dapixdev"); fee != 0 || atomic.LoadInt32(&loads) != 1 {
+		t.Error("expected the cached bundle count and one load", fee, loads)
+	}
+	atomic.StoreInt32(&head, 111)
+	fees.headAt = fees.headAt.Add(-time.Second)
+	if fee, _ := fees.Fee(api, fio.FeeAddPubAddress, "test@dapixdev"); fee != 400_000_000 || atomic.LoadInt32(&loads) != 2 {
+		t.Error("expected a reload after the window", fee, loads)
+	}
+}
+
+func TestSetMaxFee(t *testing.T) {
+	a := fio.NewRejectFndReq("aaaaaaaaaaaa", "1")
+	if !SetMaxFee(a, 123) || a.ActionData.Data.(fio.RejectFndReq).MaxFee != 123 {
+		t.Error("max_fee was not set")
+	}
+	if SetMaxFee(fio.NewAction("eosio", "noop", "aaaaaaaaaaaa", struct{ Foo string }{}), 1) {
+		t.Error("an action without a max_fee should not be changed")
+	}
+}
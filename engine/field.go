@@ -0,0 +1,360 @@
+package engine
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/blockpane/cryptonym/fuzzer"
+	"github.com/fioprotocol/fio-go"
+	"github.com/fioprotocol/fio-go/eos"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// ErrUnknownVariation is returned when the variation doesn't belong to the generator, the editor leaves the
+// previous value in place when this happens.
+var ErrUnknownVariation = errors.New("unknown variation for generator")
+
+// Field is one row of the action editor: the abi type, and how the value is generated
+type Field struct {
+	Name      string `json:"name" yaml:"name"`
+	Type      string `json:"type,omitempty" yaml:"type,omitempty"`
+	SendAs    string `json:"send_as,omitempty" yaml:"send_as,omitempty"`
+	Variation string `json:"variation,omitempty" yaml:"variation,omitempty"`
+	Len       string `json:"len,omitempty" yaml:"len,omitempty"`
+	Input     string `json:"input,omitempty" yaml:"input,omitempty"`
+}
+
+// Value is what a Field generated. If Convert is set the value was converted for AbiType, which overrides
+// the field's type when the action is encoded.
+type Value struct {
+	V            interface{}
+	IsSlice      bool
+	NoJsonEscape bool // if true uses fmt, otherwise json-encodes the value ... fmt is useful for some numeric values
+	Convert      bool
+	AbiType      string
+}
+
+func value(v interface{}, isSlice bool, noJsonEscape bool) Value {
+	return Value{V: v, IsSlice: isSlice, NoJsonEscape: noJsonEscape}
+}
+
+// converted is the same as the editor's UpdateValueWithConvert, numbers sent as a string are quoted
+func converted(v interface{}, isSlice bool, abiType string, noJsonEscape bool) Value {
+	if abiType == "string" {
+		t := fmt.Sprintf("%T", v)
+		if strings.Contains(t, "int") || strings.Contains(t, "float") {
+			v = fmt.Sprintf("%v", v)
+		}
+	}
+	return Value{V: v, IsSlice: isSlice, NoJsonEscape: noJsonEscape, Convert: true, AbiType: abiType}
+}
+
+// Generate builds a new value for the field, key is used for the "mine" variations and signatures, and uri
+// is needed by the fio types that query the chain.
+func (f Field) Generate(key *fio.Account, uri string) (Value, error) {
+	return f.GenerateWith(key, uri, nil)
+}
+
+// GenerateWith is Generate with the contract's abi, which the "abi struct" generator needs to build nested
+// types.
+func (f Field) GenerateWith(key *fio.Account, uri string, abi *eos.ABI) (Value, error) {
+	isSlice := strings.HasSuffix(f.Type, `[]`)
+	fieldErr := func(e error) error {
+		return errors.New(f.Name + ": " + e.Error())
+	}
+	var v interface{}
+
+	switch f.SendAs {
+
+	case "form value":
+		switch f.Variation {
+		case "as is":
+			if strings.Contains(f.Type, "int") {
+				t, e := strconv.ParseInt(f.Input, 10, 64)
+				if e != nil {
+					return Value{}, fieldErr(e)
+				}
+				return value(t, isSlice, true), nil
+			} else if strings.Contains(f.Type, "float") {
+				t, e := strconv.ParseFloat(f.Input, 64)
+				if e != nil {
+					return Value{}, fieldErr(e)
+				}
+				return value(t, isSlice, true), nil
+			}
+			return value(f.Input, isSlice, false), nil
+		case "FIO -> suf":
+			unFriendly := strings.ReplaceAll(strings.ReplaceAll(f.Input, ",", ""), "_", "")
+			fl, e := strconv.ParseFloat(unFriendly, 64)
+			if e != nil {
+				return Value{}, fieldErr(e)
+			}
+			return value(uint64(fl*1_000_000_000.0), isSlice, true), nil
+		case "json -> struct":
+			return value(f.Input, false, true), nil
+		case "hex -> byte[]":
+			h, e := hex.DecodeString(f.Input)
+			if e != nil {
+				return Value{}, fieldErr(e)
+			}
+			return value(h, isSlice, false), nil
+		case "base64 -> byte[]":
+			buf := bytes.NewReader([]byte(f.Input))
+			b64 := base64.NewDecoder(base64.StdEncoding, buf)
+			b := make([]byte, 0)
+			_, e := b64.Read(b)
+			if e != nil {
+				return Value{}, fieldErr(e)
+			}
+			return value(b, isSlice, false), nil
+		case "checksum256":
+			return value(fuzzer.ChecksumOf(f.Input), isSlice, false), nil
+		case "signature":
+			return value(fuzzer.SignatureFor(f.Input, key), isSlice, false), nil
+		case "fio address@ (valid)":
+			return value(fuzzer.FioAddressAt(f.Input), isSlice, false), nil
+		case "fio address@ (valid, max size)":
+			return value(fuzzer.MaxRandomFioAddressAt(f.Input), isSlice, false), nil
+		case "fio address@ (invalid)":
+			return value(fuzzer.InvalidFioAddressAt(f.Input), isSlice, false), nil
+		}
+
+	case "actor":
+		switch f.Variation {
+		case "mine":
+			return value(key.Actor, isSlice, false), nil
+		case "random":
+			return value(fuzzer.RandomActor(), isSlice, false), nil
+		}
+
+	case "pub key":
+		switch f.Variation {
+		case "mine":
+			return value(key.PubKey, isSlice, false), nil
+		case "random":
+			return value(fuzzer.RandomFioPubKey(), isSlice, false), nil
+		}
+
+	case "fio types":
+		// the on chain variations are read once per session, see fuzzer.ChainState
+		var actor, pub string
+		if key != nil {
+			actor, pub = string(key.Actor), key.PubKey
+		}
+		switch f.Variation {
+		// TODO:
+		//case "random array of existing fio address":
+		case "max length: addaddress.public_addresses":
+			return value(fuzzer.MaxAddPubAddress(), false, true), nil
+		case "max length: voteproducer.producers":
+			return converted(fuzzer.MaxVoteProducers(uri), false, "string[]", false), nil
+		case "variable length: addaddress.public_addresses":
+			payloadLen, err := strconv.Atoi(f.Len)
+			if err != nil {
+				payloadLen = 1
+			}
+			return value(fuzzer.RandomAddAddress(payloadLen), false, true), nil
+		case "invalid fio domain":
+			v = fuzzer.InvalidFioDomain()
+		case "valid fio domain (max size)":
+			v = fuzzer.MaxRandomFioDomain()
+		case "valid fio domain":
+			v = fuzzer.FioDomain()
+		case "max length: newfundsreq.content":
+			v = fuzzer.MaxNewFundsContent()
+		case "max length: recordobt.content":
+			v = fuzzer.MaxRecObtContent()
+		case "max length: regproducer.url":
+			v = fuzzer.MaxProducerUrl()
+		case "random existing fio address":
+			v = fuzzer.RandomExistingFioAddress(uri)
+		case "chain code":
+			v = fuzzer.ChainCode()
+		case "token code":
+			v = fuzzer.TokenCode()
+		case "on chain: expired domain":
+			v = fuzzer.ExpiredDomain(uri)
+		case "on chain: active domain":
+			v = fuzzer.ActiveDomain(uri)
+		case "on chain: public domain":
+			v = fuzzer.PublicDomain(uri)
+		case "on chain: private domain":
+			v = fuzzer.PrivateDomain(uri)
+		case "on chain: someone else's domain":
+			v = fuzzer.OthersDomain(uri, actor)
+		case "on chain: someone else's address":
+			v = fuzzer.OthersAddress(uri, actor)
+		case "on chain: address with zero bundles":
+			v = fuzzer.ZeroBundleAddress(uri)
+		case "on chain: actor with balance":
+			v = fuzzer.FundedActor(uri)
+		case "on chain: actor without balance":
+			v = fuzzer.UnfundedActor(uri)
+		case "on chain: someone else's pending request id":
+			id, found := fuzzer.OthersPendingRequest(uri, pub)
+			if !found {
+				return Value{}, fieldErr(errors.New("there are no pending requests for someone else"))
+			}
+			// fio_request_id is a string in the fio.reqobt abi
+			return value(strconv.FormatUint(id, 10), isSlice, f.Type != "string"), nil
+		}
+		return value(v, isSlice, false), nil
+
+	case "number":
+		var l int
+		var e error
+		if f.Variation != "random number (mixed)" && f.Variation != "max int" {
+			if f.Len == "" {
+				return Value{}, errors.New(f.Name + ": no number specified")
+			}
+			l, e = strconv.Atoi(f.Len)
+			if e != nil {
+				return Value{}, fieldErr(e)
+			}
+		}
+		// numbers sent to a string field are quoted
+		abiTypeFor := func(t string) (string, bool) {
+			if f.Type == "string" {
+				return "string", false
+			}
+			return t, true
+		}
+		switch f.Variation {
+		case "max int":
+			abiType, noJsonEscape := abiTypeFor(f.Len)
+			return converted(fuzzer.MaxInt(f.Len), isSlice, abiType, noJsonEscape), nil
+		case "incrementing float":
+			abiType, noJsonEscape := abiTypeFor("float64")
+			return converted(fuzzer.IncrementingFloat(), isSlice, abiType, noJsonEscape), nil
+		case "incrementing int":
+			abiType, noJsonEscape := abiTypeFor("int64")
+			return converted(fuzzer.IncrementingInt(), isSlice, abiType, noJsonEscape), nil
+		case "random float":
+			abiType, noJsonEscape := abiTypeFor(fmt.Sprintf("float%d", l))
+			return converted(fuzzer.RandomFloat(l), isSlice, abiType, noJsonEscape), nil
+		case "random int":
+			if l == 128 {
+				return converted(fuzzer.RandomInt128(), isSlice, "string", false), nil
+			}
+			abiType, noJsonEscape := abiTypeFor(fmt.Sprintf("int%d", l))
+			return converted(fuzzer.RandomInteger(l).Interface(), isSlice, abiType, noJsonEscape), nil
+		case "overflow int":
+			abiType, noJsonEscape := abiTypeFor("uint64")
+			signed := strings.HasPrefix(f.Type, "int")
+			return converted(fuzzer.OverFlowInt(l, signed), isSlice, abiType, noJsonEscape), nil
+		case "random number (mixed)":
+			rn := fuzzer.RandomNumber()
+			abiType, noJsonEscape := abiTypeFor(rn.AbiType())
+			return converted(rn.String(), false, abiType, noJsonEscape), nil
+		}
+
+	case "bytes/string":
+		var hasLen bool
+		var payloadLen int
+		if f.Len != "" {
+			hasLen = true
+			if f.Len == "random length" {
+				payloadLen = fuzzer.Intn(math.MaxInt16 + 8)
+			} else {
+				var e error
+				payloadLen, e = strconv.Atoi(strings.ReplaceAll(f.Len, ",", ""))
+				if e != nil {
+					return Value{}, errors.New(f.Name + ": invalid number for payload length")
+				}
+			}
+		}
+		lenError := errors.New(f.Name + ": no length specified for random payload")
+		switch f.Variation {
+		case "string":
+			if !hasLen {
+				return Value{}, lenError
+			}
+			return value(fuzzer.RandomString(payloadLen), isSlice, false), nil
+		case "bytes":
+			if !hasLen {
+				return Value{}, lenError
+			}
+			return value(fuzzer.RandomBytes(payloadLen, fuzzer.EncodeRaw), isSlice, false), nil
+		case "bytes: hex encoded":
+			if !hasLen {
+				return Value{}, lenError
+			}
+			return value(fuzzer.RandomBytes(payloadLen, fuzzer.EncodeHexString), isSlice, false), nil
+		case "bytes: base64 encoded":
+			if !hasLen {
+				return Value{}, lenError
+			}
+			return value(fuzzer.RandomBytes(payloadLen, fuzzer.EncodeBase64), isSlice, false), nil
+		case "random checksum":
+			return value(fuzzer.RandomChecksum(), isSlice, false), nil
+		}
+
+	case "abi struct":
+		if abi == nil {
+			return Value{}, fieldErr(errors.New("abi struct needs the contract's abi"))
+		}
+		mode, err := fuzzer.ParseMode(f.Variation)
+		if err != nil {
+			return Value{}, ErrUnknownVariation
+		}
+		raw, err := fuzzer.NewAbiGen(abi, mode).Type(f.Type)
+		if err != nil {
+			return Value{}, fieldErr(err)
+		}
+		// the whole array is generated, so it's never split like a form value
+		return value(string(raw), false, true), nil
+
+	case "obt content":
+		// encrypted to the sender's own key, so the result can be decrypted with the same account
+		kind := f.Len
+		if kind == "" {
+			kind = "newfundsreq"
+		}
+		c, err := fuzzer.Content(key, "", kind, strings.TrimPrefix(f.Variation, ContentPrefix))
+		if err != nil {
+			return Value{}, fieldErr(err)
+		}
+		return value(c, false, false), nil
+
+	case "boundary":
+		typeName := strings.TrimPrefix(f.Variation, BoundaryPrefix)
+		edge := f.Len
+		if cases := fuzzer.BoundaryCases(typeName); len(cases) == 0 {
+			return Value{}, ErrUnknownVariation
+		} else if edge == "" {
+			edge = cases[0]
+		}
+		raw, abiType, err := fuzzer.Boundary(typeName, edge)
+		if err != nil {
+			return Value{}, fieldErr(err)
+		}
+		// the edge case decides the type, an overflow has to be sent as a larger int
+		return converted(string(raw), false, abiType, true), nil
+
+	case "load file":
+		if f.Input == "" {
+			return Value{}, fieldErr(errors.New("no file given, put the path in the input box"))
+		}
+		s, err := fuzzer.FromFile(f.Input, f.Variation, f.Len)
+		if err != nil {
+			// wrapped, so a run can tell the file ran out
+			return Value{}, fmt.Errorf("%s: %w", f.Name, err)
+		}
+		switch {
+		case f.Variation == "jsonl":
+			return value(s, false, true), nil
+		case isSlice:
+			// one value from a wordlist is an array of one
+			return value([]string{s}, false, false), nil
+		}
+		return value(s, false, false), nil
+
+	default:
+		return Value{}, errors.New("unknown generator provided")
+	}
+	return Value{}, ErrUnknownVariation
+}
@@ -0,0 +1,264 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrLoadDuration and ErrLoadBudget are how a LoadRun says it's finished, every worker gets the same error
+	ErrLoadDuration = errors.New("load test finished: duration reached")
+	ErrLoadBudget   = errors.New("load test finished: budget reached")
+)
+
+// BalanceInterval is how often a LoadRun reads the balance, fee estimates cover the time in between
+var BalanceInterval = 5 * time.Second
+
+// minRampTps is where a ramp starts if the first step isn't at 0
+const minRampTps = 1.0
+
+// LoadStep is a point on the ramp, the rate is interpolated between steps
+type LoadStep struct {
+	After time.Duration `json:"after" yaml:"after"`
+	Tps   float64       `json:"tps" yaml:"tps"`
+}
+
+// LoadProfile controls a long run. Everything is optional, a zero value sends as fast as possible until stopped.
+type LoadProfile struct {
+	// Tps is the rate once the ramp is done, 0 is unpaced
+	Tps      float64       `json:"tps,omitempty" yaml:"tps,omitempty"`
+	Ramp     []LoadStep    `json:"ramp,omitempty" yaml:"ramp,omitempty"`
+	Duration time.Duration `json:"duration,omitempty" yaml:"duration,omitempty"`
+	// Budget is the most the run can spend in SUFs
+	Budget uint64 `json:"budget,omitempty" yaml:"budget,omitempty"`
+}
+
+// Active is true if any of the limits are set
+func (p LoadProfile) Active() bool {
+	return p.Tps > 0 || len(p.Ramp) > 0 || p.Duration > 0 || p.Budget > 0
+}
+
+// Rate is the target transactions per second after elapsed, 0 means no limit
+func (p LoadProfile) Rate(elapsed time.Duration) float64 {
+	if len(p.Ramp) == 0 {
+		return p.Tps
+	}
+	prev := LoadStep{Tps: minRampTps}
+	for _, s := range p.Ramp {
+		if elapsed < s.After {
+			frac := float64(elapsed-prev.After) / float64(s.After-prev.After)
+			return prev.Tps + frac*(s.Tps-prev.Tps)
+		}
+		prev = s
+	}
+	if p.Tps > 0 {
+		return p.Tps
+	}
+	return prev.Tps
+}
+
+// String is the same format ParseRamp reads, with the totals
+func (p LoadProfile) String() string {
+	s := make([]string, 0)
+	if p.Tps > 0 {
+		s = append(s, strconv.FormatFloat(p.Tps, 'f', -1, 64)+" tps")
+	}
+	if len(p.Ramp) > 0 {
+		steps := make([]string, len(p.Ramp))
+		for i := range p.Ramp {
+			steps[i] = strconv.FormatFloat(p.Ramp[i].Tps, 'f', -1, 64) + "@" + p.Ramp[i].After.String()
+		}
+		s = append(s, "ramp "+strings.Join(steps, ","))
+	}
+	if p.Duration > 0 {
+		s = append(s, "for "+p.Duration.String())
+	}
+	if p.Budget > 0 {
+		s = append(s, "budget "+FioString(p.Budget))
+	}
+	if len(s) == 0 {
+		return "unlimited"
+	}
+	return strings.Join(s, ", ")
+}
+
+// ParseRamp reads a ramp schedule. Either a duration, "2m" climbs from 1 tps to tps over two minutes, or a list of
+// rate@time steps such as "5@0s,20@1m,50@3m". An empty string is no ramp.
+func ParseRamp(s string, tps float64) ([]LoadStep, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return nil, nil
+	}
+	if !strings.Contains(s, "@") {
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid ramp %q: %s", s, err)
+		}
+		if tps <= 0 {
+			return nil, errors.New("a ramp duration needs a target tps")
+		}
+		return []LoadStep{{After: d, Tps: tps}}, nil
+	}
+	steps := make([]LoadStep, 0)
+	for _, part := range strings.Split(s, ",") {
+		kv := strings.SplitN(strings.TrimSpace(part), "@", 2)
+		if len(kv) != 2 {
+			return nil, fmt.Errorf("invalid ramp step %q, expected rate@time", part)
+		}
+		rate, err := strconv.ParseFloat(kv[0], 64)
+		if err != nil || rate <= 0 {
+			return nil, fmt.Errorf("invalid rate in ramp step %q", part)
+		}
+		after, err := time.ParseDuration(kv[1])
+		if err != nil {
+			return nil, fmt.Errorf("invalid time in ramp step %q: %s", part, err)
+		}
+		if len(steps) > 0 && after <= steps[len(steps)-1].After {
+			return nil, errors.New("ramp steps have to be in order")
+		}
+		steps = append(steps, LoadStep{After: after, Tps: rate})
+	}
+	return steps, nil
+}
+
+// LoadRun paces the workers of one run and keeps track of spending, it's shared by every worker
+type LoadRun struct {
+	profile LoadProfile
+	// balance is the account's balance in SUFs, fee what the next transaction is expected to cost
+	balance func() (uint64, error)
+	fee     func() (uint64, error)
+
+	mux          sync.Mutex
+	start        time.Time
+	next         time.Time
+	checked      time.Time
+	startBalance uint64
+	lastBalance  uint64
+	// fees reserved since the last two balance reads. A transaction reserved just before a read may not be in
+	// that balance yet, so the older estimates are kept for one more interval, spending is over estimated
+	// instead of under.
+	prior   uint64
+	pending uint64
+	sent    int
+	err     error
+}
+
+// Start reads the starting balance and the clock starts. balance and fee are only used if there is a budget.
+func (p LoadProfile) Start(balance func() (uint64, error), fee func() (uint64, error)) (*LoadRun, error) {
+	r := &LoadRun{profile: p, balance: balance, fee: fee}
+	if p.Budget > 0 {
+		if balance == nil || fee == nil {
+			return nil, errors.New("a budget needs the balance and fee estimates")
+		}
+		b, err := balance()
+		if err != nil {
+			return nil, fmt.Errorf("could not read the balance: %s", err)
+		}
+		r.startBalance, r.lastBalance, r.checked = b, b, time.Now()
+	}
+	r.start = time.Now()
+	return r, nil
+}
+
+// Wait blocks until the next transaction can be sent. Once the duration or budget is reached it returns
+// ErrLoadDuration or ErrLoadBudget, and keeps returning it. The fee for the transaction is counted as spent.
+func (r *LoadRun) Wait(ctx context.Context) error {
+	r.mux.Lock()
+	if r.err != nil {
+		r.mux.Unlock()
+		return r.err
+	}
+	now := time.Now()
+	slot := now
+	if rate := r.profile.Rate(now.Sub(r.start)); rate > 0 {
+		// a worker that stalls doesn't earn a burst later
+		if r.next.After(now) {
+			slot = r.next
+		}
+		r.next = slot.Add(time.Duration(float64(time.Second) / rate))
+	}
+	if r.profile.Duration > 0 && slot.Sub(r.start) >= r.profile.Duration {
+		r.err = ErrLoadDuration
+	} else if r.profile.Budget > 0 {
+		r.err = r.reserve(now)
+	}
+	if r.err != nil {
+		r.mux.Unlock()
+		return r.err
+	}
+	r.sent += 1
+	r.mux.Unlock()
+
+	if wait := slot.Sub(now); wait > 0 {
+		t := time.NewTimer(wait)
+		defer t.Stop()
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-t.C:
+		}
+	}
+	return ctx.Err()
+}
+
+// reserve counts the next transaction's fee, called with mux held
+func (r *LoadRun) reserve(now time.Time) error {
+	if now.Sub(r.checked) >= BalanceInterval {
+		b, err := r.balance()
+		if err != nil {
+			// the budget can't be enforced without it
+			return fmt.Errorf("load test stopped, could not read the balance: %s", err)
+		}
+		r.lastBalance, r.checked = b, now
+		r.prior, r.pending = r.pending, 0
+	}
+	fee, err := r.fee()
+	if err != nil {
+		return fmt.Errorf("load test stopped, could not estimate the fee: %s", err)
+	}
+	if r.spent()+fee > r.profile.Budget {
+		return ErrLoadBudget
+	}
+	if r.prior+r.pending+fee > r.lastBalance {
+		return fmt.Errorf("%w, the balance is %s", ErrLoadBudget, FioString(r.lastBalance))
+	}
+	r.pending += fee
+	return nil
+}
+
+func (r *LoadRun) spent() uint64 {
+	var s uint64
+	if r.startBalance > r.lastBalance {
+		s = r.startBalance - r.lastBalance
+	}
+	return s + r.prior + r.pending
+}
+
+// Stop ends the run early, waiting workers finish their current transaction
+func (r *LoadRun) Stop(err error) {
+	r.mux.Lock()
+	if r.err == nil {
+		r.err = err
+	}
+	r.mux.Unlock()
+}
+
+// Status is a one line summary for the ui or log
+func (r *LoadRun) Status() string {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	elapsed := time.Since(r.start)
+	s := fmt.Sprintf("%d sent in %s", r.sent, elapsed.Truncate(time.Second))
+	if rate := r.profile.Rate(elapsed); rate > 0 {
+		s += fmt.Sprintf(", target %.1f tps", rate)
+	}
+	if r.profile.Budget > 0 {
+		s += fmt.Sprintf(", spent about %s of %s", FioString(r.spent()), FioString(r.profile.Budget))
+	}
+	return s
+}
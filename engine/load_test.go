@@ -0,0 +1,93 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestLoadProfile(t *testing.T) {
+	steps, err := ParseRamp("5@0s,20@10s,50@30s", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := LoadProfile{Ramp: steps}
+	for _, want := range []struct {
+		at  time.Duration
+		tps float64
+	}{{0, 5}, {5 * time.Second, 12.5}, {20 * time.Second, 35}, {time.Minute, 50}} {
+		if got := p.Rate(want.at); got != want.tps {
+			t.Errorf("rate at %s: expected %v got %v", want.at, want.tps, got)
+		}
+	}
+	steps, _ = ParseRamp("10s", 11)
+	p = LoadProfile{Tps: 11, Ramp: steps}
+	if p.Rate(0) != minRampTps || p.Rate(5*time.Second) != 6 || p.Rate(time.Hour) != 11 {
+		t.Error("a ramp duration should climb from 1 tps to the target", p.Rate(0), p.Rate(5*time.Second))
+	}
+	for _, bad := range []string{"2m", "5@1m,6@30s", "x@1s", "5@soon"} {
+		if _, err = ParseRamp(bad, 0); err == nil {
+			t.Error("expected an error for", bad)
+		}
+	}
+
+	// 50 tps for 200ms is 10 transactions, give or take the first one
+	run, err := LoadProfile{Tps: 50, Duration: 200 * time.Millisecond}.Start(nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sent := 0
+	for run.Wait(context.Background()) == nil {
+		sent += 1
+	}
+	if sent < 9 || sent > 11 {
+		t.Error("expected about 10 transactions, sent", sent)
+	}
+	if run.Wait(context.Background()) != ErrLoadDuration {
+		t.Error("the run should stay finished")
+	}
+}
+
+func TestLoadBudget(t *testing.T) {
+	balance := uint64(100_000_000_000)
+	var fee uint64 = 2_000_000_000
+	run, err := LoadProfile{Budget: 10_000_000_000}.Start(
+		func() (uint64, error) { return balance, nil },
+		func() (uint64, error) { return fee, nil },
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sent := 0
+	for run.Wait(context.Background()) == nil {
+		sent += 1
+		balance -= fee
+	}
+	if sent != 5 {
+		t.Error("expected the budget to cover 5 transactions, sent", sent)
+	}
+
+	// the balance is the limit when it's lower than the budget
+	run, _ = LoadProfile{Budget: 10_000_000_000}.Start(
+		func() (uint64, error) { return 3_000_000_000, nil },
+		func() (uint64, error) { return fee, nil },
+	)
+	if err = run.Wait(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if err = run.Wait(context.Background()); !errors.Is(err, ErrLoadBudget) {
+		t.Error("expected the run to stop at the balance", err)
+	}
+
+	if _, err = (LoadProfile{Budget: 1}).Start(nil, nil); err == nil {
+		t.Error("a budget without a balance should fail")
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	run, _ = LoadProfile{Tps: 0.1}.Start(nil, nil)
+	_ = run.Wait(ctx)
+	if err = run.Wait(ctx); err != context.Canceled {
+		t.Error("expected the context error while waiting", err)
+	}
+}
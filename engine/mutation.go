@@ -0,0 +1,120 @@
+package engine
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"github.com/blockpane/cryptonym/fuzzer"
+	"sync"
+)
+
+// Mutation builds payloads by changing action data that was accepted by the chain
+type Mutation struct {
+	Contract string
+	Action   string
+	// Fields are the names and types for the action, the values come from the mutated data
+	Fields []Field
+	// Base is mutated, if it's empty a random entry from the Corpus is used each time
+	Base   json.RawMessage
+	Corpus []json.RawMessage
+	// Names are existing on-chain names that strings can be replaced with
+	Names []string
+}
+
+// Payload mutates the data for one iteration of a seeded run, it returns what was changed
+func (m *Mutation) Payload(seed int64, iteration int) (p *Payload, applied []string, err error) {
+	if len(m.Base) == 0 && len(m.Corpus) == 0 {
+		return nil, nil, errors.New("nothing to mutate, there are no successful transactions for " + m.Contract + "::" + m.Action)
+	}
+	err = fuzzer.WithSeed(seed, iteration, func() error {
+		base := m.Base
+		if len(base) == 0 {
+			base = m.Corpus[fuzzer.Intn(len(m.Corpus))]
+		}
+		data, changes, e := fuzzer.NewMutator(m.Corpus, m.Names).Mutate(base)
+		if e != nil {
+			return e
+		}
+		applied = changes
+		p, e = PayloadFromJson(m.Contract, m.Action, m.Fields, data)
+		return e
+	})
+	return p, applied, err
+}
+
+// PayloadFromJson uses the values in action data instead of generating them, fields missing from the data
+// are left out.
+func PayloadFromJson(contract string, action string, fields []Field, data []byte) (*Payload, error) {
+	values := make(map[string]json.RawMessage)
+	d := json.NewDecoder(bytes.NewReader(data))
+	d.UseNumber()
+	if err := d.Decode(&values); err != nil {
+		return nil, err
+	}
+	p := &Payload{Contract: contract, Action: action, Fields: make([]Field, 0, len(fields)), Values: make([]Value, 0, len(fields))}
+	for _, f := range fields {
+		v, ok := values[f.Name]
+		if !ok {
+			continue
+		}
+		p.Fields = append(p.Fields, f)
+		p.Values = append(p.Values, value(string(v), false, true))
+	}
+	if len(p.Fields) == 0 {
+		return nil, errors.New("none of the fields for " + contract + "::" + action + " are in the data")
+	}
+	return p, nil
+}
+
+// Corpus keeps the action data from successful transactions so it can be mutated later, the oldest is
+// dropped when there are more than max for an action.
+type Corpus struct {
+	mux  sync.Mutex
+	max  int
+	data map[string][]json.RawMessage
+}
+
+func NewCorpus(max int) *Corpus {
+	return &Corpus{max: max, data: make(map[string][]json.RawMessage)}
+}
+
+func (c *Corpus) Add(contract string, action string, data json.RawMessage) {
+	if !json.Valid(data) {
+		return
+	}
+	compact := bytes.Buffer{}
+	if json.Compact(&compact, data) != nil {
+		return
+	}
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	key := contract + "::" + action
+	for _, d := range c.data[key] {
+		if bytes.Equal(d, compact.Bytes()) {
+			return
+		}
+	}
+	c.data[key] = append(c.data[key], compact.Bytes())
+	if len(c.data[key]) > c.max {
+		c.data[key] = c.data[key][1:]
+	}
+}
+
+// AddComposed adds each action from a composed transaction's json (see PackActions) under its own contract
+// and action
+func (c *Corpus) AddComposed(raw json.RawMessage) {
+	actions := make([]previewAction, 0)
+	if json.Unmarshal(raw, &actions) != nil {
+		return
+	}
+	for _, a := range actions {
+		c.Add(a.Account, a.Name, a.Data)
+	}
+}
+
+// Get returns a copy of the data for an action
+func (c *Corpus) Get(contract string, action string) []json.RawMessage {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	return append([]json.RawMessage{}, c.data[contract+"::"+action]...)
+}
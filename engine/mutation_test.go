@@ -0,0 +1,46 @@
+package engine
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestMutation(t *testing.T) {
+	corpus := NewCorpus(2)
+	for _, d := range []string{`{"a":1, "b":"x"}`, `{"a":1,"b":"x"}`, `{"a":2,"b":"y"}`, `not json`, `{"a":3,"b":"z"}`} {
+		corpus.Add("c", "act", json.RawMessage(d))
+	}
+	data := corpus.Get("c", "act")
+	if len(data) != 2 || string(data[0]) != `{"a":2,"b":"y"}` {
+		t.Fatalf("unexpected corpus %q", data)
+	}
+	corpus.AddComposed(json.RawMessage(`[{"account":"c","name":"other","authorization":[],"data":{"z":1}},{"account":"d","name":"act","data":{"y":2}}]`))
+	if len(corpus.Get("c", "other")) != 1 || len(corpus.Get("d", "act")) != 1 || len(corpus.Get("c", "act")) != 2 {
+		t.Error("composed actions should be added under their own contract and action")
+	}
+
+	m := &Mutation{
+		Contract: "c",
+		Action:   "act",
+		Fields:   []Field{{Name: "b", Type: "string"}, {Name: "a", Type: "uint64"}, {Name: "missing", Type: "string"}},
+		Corpus:   data,
+	}
+	first, applied, err := m.Payload(7, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(applied) == 0 || len(first.Fields) != 2 || first.Fields[0].Name != "b" {
+		t.Errorf("unexpected payload %+v %v", first, applied)
+	}
+	j, err := first.Json()
+	if err != nil || !json.Valid(j) {
+		t.Fatal("invalid json", string(j), err)
+	}
+	again, _, _ := m.Payload(7, 3)
+	if j2, _ := again.Json(); string(j2) != string(j) {
+		t.Errorf("replay did not match %s %s", j, j2)
+	}
+	if _, _, err = (&Mutation{Contract: "c", Action: "act"}).Payload(1, 1); err == nil {
+		t.Error("expected an error with nothing to mutate")
+	}
+}
@@ -0,0 +1,263 @@
+package engine
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/fioprotocol/fio-go"
+	"github.com/fioprotocol/fio-go/eos"
+	"github.com/fioprotocol/fio-go/eos/ecc"
+	"io/ioutil"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// MaxOfflineExpiration is the longest a transaction can wait to be signed, nodeos won't accept a later expiration
+const MaxOfflineExpiration = time.Hour
+
+// OfflineTx moves a transaction between a connected machine and one that holds the keys but has no network. It's
+// exported unsigned, with the chain id, ref block, expiration and the abis needed to read it back, the offline
+// machine adds signatures, and the connected machine pushes it.
+type OfflineTx struct {
+	ChainId eos.Checksum256 `json:"chain_id"`
+	// Expiration is only for people reading the file, the one in the transaction is what counts
+	Expiration time.Time           `json:"expiration"`
+	Abis       map[string]*eos.ABI `json:"abis"`
+	// Packed is the transaction, it's never re-encoded after export so the signatures cover exactly what was exported
+	Packed *eos.PackedTransaction `json:"transaction"`
+}
+
+// ExportUnsigned encodes the payloads into one unsigned transaction. The ref block is refreshed first, the
+// expiration is capped at MaxOfflineExpiration and defaults to it if zero.
+func ExportUnsigned(api *fio.API, opts *fio.TxOptions, account *fio.Account, payloads []*Payload, po PackOptions, expires time.Duration) (*OfflineTx, error) {
+	if len(payloads) == 0 {
+		return nil, errors.New("there is nothing to export")
+	}
+	if expires <= 0 || expires > MaxOfflineExpiration {
+		expires = MaxOfflineExpiration
+	}
+	abis := make(map[string]*eos.ABI)
+	actions := make([]*fio.Action, len(payloads))
+	for i, p := range payloads {
+		_, a, abi, err := encodeAction(api, account, p)
+		if err != nil {
+			return nil, fmt.Errorf("action %d (%s::%s): %s", i+1, p.Contract, p.Action, err)
+		}
+		actions[i] = a
+		abis[p.Contract] = abi
+	}
+	if err := opts.FillFromChain(api.API); err != nil {
+		return nil, err
+	}
+	opts.TxOptions.DelaySecs = po.DelaySecs
+	tx := fio.NewTransaction(actions, opts)
+	tx.SetExpiration(expires)
+	compression := fio.CompressionNone
+	if po.Compress {
+		compression = fio.CompressionZlib
+	}
+	packed, err := eos.NewSignedTransaction(tx).Pack(compression)
+	if err != nil {
+		return nil, err
+	}
+	return &OfflineTx{
+		ChainId:    opts.ChainID,
+		Expiration: tx.Expiration.Time,
+		Abis:       abis,
+		Packed:     packed,
+	}, nil
+}
+
+// Review decodes the transaction using the abis that came with it, so the signer can see what they are signing
+// without a connection. It's built from the packed bytes, not from anything the exporter claims they contain.
+func (o *OfflineTx) Review() (json.RawMessage, error) {
+	if o.Packed == nil {
+		return nil, errors.New("the file does not hold a transaction")
+	}
+	stx, err := o.Packed.Unpack()
+	if err != nil {
+		return nil, err
+	}
+	actions := make([]previewAction, len(stx.Actions))
+	for i, a := range stx.Actions {
+		abi := o.Abis[string(a.Account)]
+		if abi == nil {
+			return nil, fmt.Errorf("action %d (%s::%s): the abi was not exported", i+1, a.Account, a.Name)
+		}
+		data, err := abi.DecodeAction(a.HexData, a.Name)
+		if err != nil {
+			return nil, fmt.Errorf("action %d (%s::%s): %s", i+1, a.Account, a.Name, err)
+		}
+		actions[i] = previewAction{Account: string(a.Account), Name: string(a.Name), Authorization: a.Authorization, Data: data}
+	}
+	signedBy := make([]string, 0)
+	if keys, err := stx.SignedByKeys(o.ChainId); err == nil {
+		for _, k := range keys {
+			signedBy = append(signedBy, "FIO"+k.String()[3:])
+		}
+	}
+	id, _ := o.Packed.ID()
+	return json.MarshalIndent(struct {
+		Id         string          `json:"transaction_id"`
+		ChainId    eos.Checksum256 `json:"chain_id"`
+		Expiration time.Time       `json:"expiration"`
+		RefBlock   uint16          `json:"ref_block_num"`
+		DelaySecs  uint32          `json:"delay_sec"`
+		SignedBy   []string        `json:"signed_by"`
+		Actions    []previewAction `json:"actions"`
+	}{
+		Id:         id.String(),
+		ChainId:    o.ChainId,
+		Expiration: stx.Expiration.Time,
+		RefBlock:   stx.RefBlockNum,
+		DelaySecs:  uint32(stx.DelaySec),
+		SignedBy:   signedBy,
+		Actions:    actions,
+	}, "", "  ")
+}
+
+// Sign adds a signature for key from signer, a key bag, keosd or an external signer. Nothing but the signer
+// touches the network. Existing signatures are kept so several offline keys can
+// sign in turn.
+func (o *OfflineTx) Sign(signer eos.Signer, key ecc.PublicKey) error {
+	if signer == nil {
+		return errors.New("no signer was loaded")
+	}
+	if o.Packed == nil {
+		return errors.New("the file does not hold a transaction")
+	}
+	stx, err := o.Packed.Unpack()
+	if err != nil {
+		return err
+	}
+	if time.Now().After(stx.Expiration.Time) {
+		return fmt.Errorf("the transaction expired at %s, export it again", stx.Expiration.Time.Format(time.RFC3339))
+	}
+	txdata, cfd, err := stx.PackedTransactionAndCFD()
+	if err != nil {
+		return err
+	}
+	digest := eos.SigDigest(o.ChainId, txdata, cfd)
+	for _, s := range o.Packed.Signatures {
+		if k, e := s.PublicKey(digest); e == nil && k.String() == key.String() {
+			return errors.New("the transaction is already signed by this key")
+		}
+	}
+	stx.Signatures = nil
+	signed, err := signer.Sign(stx, o.ChainId, key)
+	if err != nil {
+		return err
+	}
+	// keep only a signature that's from key for this transaction, whatever the signer is
+	for _, sig := range signed.Signatures {
+		if k, e := sig.PublicKey(digest); e == nil && k.String() == key.String() {
+			o.Packed.Signatures = append(o.Packed.Signatures, sig)
+			return nil
+		}
+	}
+	return errors.New("the signer did not return a signature for FIO" + key.String()[3:])
+}
+
+func (o *OfflineTx) Signed() bool {
+	return o.Packed != nil && len(o.Packed.Signatures) > 0
+}
+
+func (o *OfflineTx) Json() ([]byte, error) {
+	return json.MarshalIndent(o, "", "  ")
+}
+
+// offlineFramePrefix starts every qr frame, followed by the frame number, the count, and the data
+const offlineFramePrefix = "CRYPTONYM-TX:"
+
+// Frames splits the transaction into strings that are small enough for a qr code each, they can be scanned in
+// any order. The json is gzipped, abis compress well.
+func (o *OfflineTx) Frames(size int) ([]string, error) {
+	if size < 64 {
+		size = 64
+	}
+	j, err := json.Marshal(o)
+	if err != nil {
+		return nil, err
+	}
+	buf := bytes.NewBuffer(nil)
+	gz := gzip.NewWriter(buf)
+	if _, err = gz.Write(j); err != nil {
+		return nil, err
+	}
+	if err = gz.Close(); err != nil {
+		return nil, err
+	}
+	data := base64.StdEncoding.EncodeToString(buf.Bytes())
+	count := (len(data) + size - 1) / size
+	frames := make([]string, count)
+	for i := range frames {
+		end := (i + 1) * size
+		if end > len(data) {
+			end = len(data)
+		}
+		frames[i] = fmt.Sprintf("%s%d/%d:%s", offlineFramePrefix, i+1, count, data[i*size:end])
+	}
+	return frames, nil
+}
+
+// ParseOfflineTx reads either the json from OfflineTx.Json, or the text of every qr frame, one per line
+func ParseOfflineTx(b []byte) (*OfflineTx, error) {
+	b = bytes.TrimSpace(b)
+	if !bytes.HasPrefix(b, []byte(offlineFramePrefix)) {
+		o := &OfflineTx{}
+		if err := json.Unmarshal(b, o); err != nil {
+			return nil, err
+		}
+		if o.Packed == nil {
+			return nil, errors.New("the file does not hold a transaction")
+		}
+		return o, nil
+	}
+
+	var parts []string
+	for _, line := range strings.Split(string(b), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		header := strings.SplitN(strings.TrimPrefix(line, offlineFramePrefix), ":", 2)
+		nums := strings.Split(header[0], "/")
+		if !strings.HasPrefix(line, offlineFramePrefix) || len(header) != 2 || len(nums) != 2 {
+			return nil, errors.New("not a transaction frame: " + line)
+		}
+		n, e1 := strconv.Atoi(nums[0])
+		count, e2 := strconv.Atoi(nums[1])
+		if e1 != nil || e2 != nil || n < 1 || n > count {
+			return nil, errors.New("not a transaction frame: " + line)
+		}
+		if parts == nil {
+			parts = make([]string, count)
+		}
+		if count != len(parts) {
+			return nil, errors.New("frames are from different transactions")
+		}
+		parts[n-1] = header[1]
+	}
+	for i := range parts {
+		if parts[i] == "" {
+			return nil, fmt.Errorf("frame %d of %d is missing", i+1, len(parts))
+		}
+	}
+	gzipped, err := base64.StdEncoding.DecodeString(strings.Join(parts, ""))
+	if err != nil {
+		return nil, err
+	}
+	gz, err := gzip.NewReader(bytes.NewReader(gzipped))
+	if err != nil {
+		return nil, err
+	}
+	j, err := ioutil.ReadAll(gz)
+	if err != nil {
+		return nil, err
+	}
+	return ParseOfflineTx(j)
+}
@@ -0,0 +1,92 @@
+package engine
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/fioprotocol/fio-go"
+	"github.com/fioprotocol/fio-go/eos/ecc"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestOfflineTx(t *testing.T) {
+	server := testChain()
+	defer server.Close()
+
+	account, _ := fio.NewAccountFromWif("5KC6Edd4BcKTLnRuGj2c8TRT9oLuuXLd3ZuCGxM9iNngc3D8S93")
+	s, err := NewSession(context.Background(), server.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	api, err := s.Api(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := NewComposition()
+	c.Add(Composed{Action: NewAction("fio.address", "first", []Field{{Name: "memo", Type: "string", SendAs: "form value", Variation: "as is", Input: "hello"}}, nil)})
+	payloads, err := c.Generate(account, server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	unsigned, err := ExportUnsigned(api, s.Opts(), account, payloads, PackOptions{Compress: true}, 2*time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if unsigned.Signed() || unsigned.Expiration.After(time.Now().Add(MaxOfflineExpiration)) {
+		t.Error("expected an unsigned transaction expiring within the hour")
+	}
+
+	// everything after this is offline, the server isn't needed
+	server.Close()
+	frames, err := unsigned.Frames(200)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(frames) < 2 {
+		t.Fatal("expected several frames")
+	}
+	// scanned out of order
+	frames[0], frames[1] = frames[1], frames[0]
+	offline, err := ParseOfflineTx([]byte(strings.Join(frames, "\n")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = ParseOfflineTx([]byte(strings.Join(frames[1:], "\n"))); err == nil {
+		t.Error("expected an error for a missing frame")
+	}
+	review, err := offline.Review()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(review), `"memo": "hello"`) {
+		t.Error("review should decode the action data", string(review))
+	}
+	pub, _ := ecc.NewPublicKey(account.PubKey)
+	if err = offline.Sign(account.KeyBag, pub); err != nil {
+		t.Fatal(err)
+	}
+	if err = offline.Sign(account.KeyBag, pub); err == nil {
+		t.Error("signing twice with one key should fail")
+	}
+
+	j, err := offline.Json()
+	if err != nil {
+		t.Fatal(err)
+	}
+	signed, err := ParseOfflineTx(j)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !signed.Signed() || signed.Packed.PackedTransaction.String() != unsigned.Packed.PackedTransaction.String() {
+		t.Error("the signed transaction should be the same bytes as the export")
+	}
+	review, _ = signed.Review()
+	r := struct {
+		SignedBy []string `json:"signed_by"`
+	}{}
+	_ = json.Unmarshal(review, &r)
+	if len(r.SignedBy) != 1 || r.SignedBy[0] != account.PubKey {
+		t.Error("expected the signature to be from", account.PubKey, "got", r.SignedBy)
+	}
+}
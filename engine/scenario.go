@@ -0,0 +1,474 @@
+package engine
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"gopkg.in/yaml.v3"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Scenario is a list of steps run in order, each checked against its Expect. Variables in the form ${name}
+// are replaced in field inputs, api bodies and table bounds, ${actor} and ${pub_key} are the session's account.
+type Scenario struct {
+	Name          string            `json:"name" yaml:"name"`
+	Vars          map[string]string `json:"vars,omitempty" yaml:"vars,omitempty"`
+	StopOnFailure bool              `json:"stop_on_failure,omitempty" yaml:"stop_on_failure,omitempty"`
+	Steps         []Step            `json:"steps" yaml:"steps"`
+}
+
+// Step has exactly one of Action, Api or Table set
+type Step struct {
+	Name   string      `json:"name,omitempty" yaml:"name,omitempty"`
+	Wait   string      `json:"wait,omitempty" yaml:"wait,omitempty"` // duration to sleep before running, ie "1s" to get past a block
+	Action *ActionStep `json:"action,omitempty" yaml:"action,omitempty"`
+	Api    *ApiStep    `json:"api,omitempty" yaml:"api,omitempty"`
+	Table  *TableQuery `json:"table,omitempty" yaml:"table,omitempty"`
+	Expect Expect      `json:"expect,omitempty" yaml:"expect,omitempty"`
+}
+
+// ActionStep is an action file inline, Values is a shortcut for fields sent "as is"
+type ActionStep struct {
+	Action   `yaml:",inline"`
+	Values   map[string]string `json:"values,omitempty" yaml:"values,omitempty"`
+	Endpoint string            `json:"endpoint,omitempty" yaml:"endpoint,omitempty"`
+	Compress bool              `json:"compress,omitempty" yaml:"compress,omitempty"`
+}
+
+// Build copies the action with Values added as fields and vars replaced in the inputs, vars may be nil.
+// FillFromAbi still needs to be called before generating a payload.
+func (as *ActionStep) Build(vars *strings.Replacer) *Action {
+	if vars == nil {
+		vars = strings.NewReplacer()
+	}
+	a := as.Action
+	a.Fields = make([]Field, 0, len(as.Fields)+len(as.Values))
+	for _, f := range as.Fields {
+		f.Input = vars.Replace(f.Input)
+		a.Fields = append(a.Fields, f)
+	}
+	for name, v := range as.Values {
+		a.Fields = append(a.Fields, Field{Name: name, SendAs: "form value", Variation: "as is", Input: vars.Replace(v)})
+	}
+	return &a
+}
+
+func (as *ActionStep) PushEndpoint() string {
+	if as.Endpoint == "" {
+		return "/v1/chain/push_transaction"
+	}
+	return as.Endpoint
+}
+
+// ApiStep posts Body to a chain api endpoint, Body can be a string or a map that is sent as json. If it
+// is empty the Runner's DefaultBody is used.
+type ApiStep struct {
+	Endpoint string      `json:"endpoint" yaml:"endpoint"`
+	Body     interface{} `json:"body,omitempty" yaml:"body,omitempty"`
+}
+
+// Expect is what a step must do to pass, with no expectations a step only has to succeed
+type Expect struct {
+	Success   *bool       `json:"success,omitempty" yaml:"success,omitempty"`
+	ErrorCode string      `json:"error_code,omitempty" yaml:"error_code,omitempty"` // matches the error code, error name, or FIO error type
+	Status    int         `json:"status,omitempty" yaml:"status,omitempty"`         // http status for api calls
+	Contains  []string    `json:"contains,omitempty" yaml:"contains,omitempty"`     // substrings of the response
+	Rows      *RowsExpect `json:"rows,omitempty" yaml:"rows,omitempty"`
+}
+
+// RowsExpect checks the rows returned by a table query
+type RowsExpect struct {
+	Count *int `json:"count,omitempty" yaml:"count,omitempty"`
+	Min   *int `json:"min,omitempty" yaml:"min,omitempty"`
+	Max   *int `json:"max,omitempty" yaml:"max,omitempty"`
+	// Match requires at least one row where every field equals the value given
+	Match map[string]string `json:"match,omitempty" yaml:"match,omitempty"`
+}
+
+// LoadScenario reads a scenario from a .yaml, .yml or .json file
+func LoadScenario(fileName string) (*Scenario, error) {
+	b, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		return nil, err
+	}
+	sc := &Scenario{}
+	switch strings.ToLower(filepath.Ext(fileName)) {
+	case ".json":
+		err = json.Unmarshal(b, sc)
+	default:
+		err = yaml.Unmarshal(b, sc)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", fileName, err.Error())
+	}
+	if sc.Name == "" {
+		sc.Name = strings.TrimSuffix(filepath.Base(fileName), filepath.Ext(fileName))
+	}
+	if err = sc.Validate(); err != nil {
+		return nil, fmt.Errorf("%s: %s", fileName, err.Error())
+	}
+	return sc, nil
+}
+
+// Validate checks that every step can be run
+func (sc *Scenario) Validate() error {
+	if len(sc.Steps) == 0 {
+		return errors.New("scenario has no steps")
+	}
+	for i, step := range sc.Steps {
+		kinds := 0
+		if step.Action != nil {
+			kinds += 1
+			if step.Action.Contract == "" || step.Action.Action.Action == "" {
+				return fmt.Errorf("step %d: contract and action are required", i+1)
+			}
+		}
+		if step.Api != nil {
+			kinds += 1
+			if !strings.HasPrefix(step.Api.Endpoint, "/") {
+				return fmt.Errorf("step %d: api endpoint should look like /v1/chain/get_info", i+1)
+			}
+		}
+		if step.Table != nil {
+			kinds += 1
+			if step.Table.Contract == "" || step.Table.Table == "" {
+				return fmt.Errorf("step %d: table queries need a contract and table", i+1)
+			}
+		}
+		if kinds != 1 {
+			return fmt.Errorf("step %d: needs exactly one of action, api or table", i+1)
+		}
+		if step.Wait != "" {
+			if _, err := time.ParseDuration(step.Wait); err != nil {
+				return fmt.Errorf("step %d: wait: %s", i+1, err.Error())
+			}
+		}
+	}
+	return nil
+}
+
+// StepResult is the outcome of one step, Failures is empty if it passed
+type StepResult struct {
+	Step     int           `json:"step" yaml:"step"`
+	Name     string        `json:"name" yaml:"name"`
+	Kind     string        `json:"kind" yaml:"kind"`
+	Pass     bool          `json:"pass" yaml:"pass"`
+	Skipped  bool          `json:"skipped,omitempty" yaml:"skipped,omitempty"`
+	Failures []string      `json:"failures,omitempty" yaml:"failures,omitempty"`
+	Duration time.Duration `json:"duration" yaml:"duration"`
+	TxId     string        `json:"transaction_id,omitempty" yaml:"transaction_id,omitempty"`
+	Response string        `json:"-" yaml:"-"`
+}
+
+func (r *StepResult) fail(format string, a ...interface{}) {
+	r.Pass = false
+	r.Failures = append(r.Failures, fmt.Sprintf(format, a...))
+}
+
+// Report is the result of running a scenario
+type Report struct {
+	Scenario string        `json:"scenario" yaml:"scenario"`
+	Started  time.Time     `json:"started" yaml:"started"`
+	Duration time.Duration `json:"duration" yaml:"duration"`
+	Passed   int           `json:"passed" yaml:"passed"`
+	Failed   int           `json:"failed" yaml:"failed"`
+	Skipped  int           `json:"skipped,omitempty" yaml:"skipped,omitempty"`
+	Steps    []StepResult  `json:"steps" yaml:"steps"`
+}
+
+func (rep *Report) Ok() bool {
+	return rep.Failed == 0 && rep.Skipped == 0
+}
+
+// String is a plain text pass/fail report
+func (rep *Report) String() string {
+	buf := bytes.NewBuffer(nil)
+	for _, r := range rep.Steps {
+		status := "PASS"
+		switch {
+		case r.Skipped:
+			status = "SKIP"
+		case !r.Pass:
+			status = "FAIL"
+		}
+		fmt.Fprintf(buf, "%s  %d %s (%s) %v\n", status, r.Step, r.Name, r.Kind, r.Duration.Round(time.Millisecond))
+		for _, f := range r.Failures {
+			fmt.Fprintf(buf, "        %s\n", f)
+		}
+	}
+	fmt.Fprintf(buf, "%s: %d passed, %d failed", rep.Scenario, rep.Passed, rep.Failed)
+	if rep.Skipped > 0 {
+		fmt.Fprintf(buf, ", %d skipped", rep.Skipped)
+	}
+	fmt.Fprintf(buf, " in %v\n", rep.Duration.Round(time.Millisecond))
+	return buf.String()
+}
+
+// Runner executes scenarios against a session
+type Runner struct {
+	Session *Session
+	// DefaultBody is used for api steps without a body, the gui uses the same defaults as the api tab
+	DefaultBody func(endpoint string) string
+	// Progress is called after each step finishes
+	Progress func(r StepResult)
+}
+
+// Run executes every step, it stops early if the context is cancelled or StopOnFailure is set.
+func (rn *Runner) Run(ctx context.Context, sc *Scenario) *Report {
+	rep := &Report{Scenario: sc.Name, Started: time.Now(), Steps: make([]StepResult, 0, len(sc.Steps))}
+	vars := rn.vars(sc)
+	stop := false
+	for i := range sc.Steps {
+		step := sc.Steps[i]
+		r := StepResult{Step: i + 1, Name: step.Name, Kind: step.kind(), Pass: true}
+		if r.Name == "" {
+			r.Name = step.describe()
+		}
+		if stop || ctx.Err() != nil {
+			r.Pass, r.Skipped = false, true
+			rep.Skipped += 1
+			rep.Steps = append(rep.Steps, r)
+			continue
+		}
+		if step.Wait != "" {
+			d, _ := time.ParseDuration(step.Wait)
+			select {
+			case <-time.After(d):
+			case <-ctx.Done():
+			}
+		}
+		start := time.Now()
+		switch {
+		case step.Action != nil:
+			rn.runAction(ctx, step, vars, &r)
+		case step.Api != nil:
+			rn.runApi(ctx, step, vars, &r)
+		case step.Table != nil:
+			rn.runTable(ctx, step, vars, &r)
+		}
+		r.Duration = time.Now().Sub(start)
+		if r.Pass {
+			rep.Passed += 1
+		} else {
+			rep.Failed += 1
+			stop = sc.StopOnFailure
+		}
+		rep.Steps = append(rep.Steps, r)
+		if rn.Progress != nil {
+			rn.Progress(r)
+		}
+	}
+	rep.Duration = time.Now().Sub(rep.Started)
+	return rep
+}
+
+func (rn *Runner) vars(sc *Scenario) *strings.Replacer {
+	pairs := make([]string, 0)
+	if account := rn.Session.Account(); account != nil {
+		pairs = append(pairs, "${actor}", string(account.Actor), "${pub_key}", account.PubKey)
+	}
+	for k, v := range sc.Vars {
+		pairs = append(pairs, "${"+k+"}", v)
+	}
+	return strings.NewReplacer(pairs...)
+}
+
+func (step Step) kind() string {
+	switch {
+	case step.Action != nil:
+		return "action"
+	case step.Api != nil:
+		return "api"
+	}
+	return "table"
+}
+
+func (step Step) describe() string {
+	switch {
+	case step.Action != nil:
+		return step.Action.Contract + "::" + step.Action.Action.Action
+	case step.Api != nil:
+		return step.Api.Endpoint
+	}
+	return step.Table.Contract + " " + step.Table.Table
+}
+
+func (rn *Runner) runAction(ctx context.Context, step Step, vars *strings.Replacer, r *StepResult) {
+	a := step.Action.Build(vars)
+	api, err := rn.Session.Api(ctx)
+	if err != nil {
+		r.fail(err.Error())
+		return
+	}
+	if err = a.FillFromAbi(api); err != nil {
+		r.fail(err.Error())
+		return
+	}
+	summary, result, err := rn.Session.Send(ctx, a, PackOptions{Compress: step.Action.Compress}, step.Action.PushEndpoint())
+	if summary != nil {
+		r.TxId = summary.TransactionId
+	}
+	if err != nil && len(result) == 0 {
+		// never made it to the node, nothing to check
+		r.fail(err.Error())
+		return
+	}
+	r.Response = string(result)
+	codes := errorCodes(result)
+	if summary != nil && summary.ErrorCode != nil {
+		codes = append(codes, fmt.Sprint(summary.ErrorCode))
+	}
+	check(step.Expect, err == nil, 0, codes, result, r)
+}
+
+func (rn *Runner) runApi(ctx context.Context, step Step, vars *strings.Replacer, r *StepResult) {
+	var body string
+	switch b := step.Api.Body.(type) {
+	case nil:
+		if rn.DefaultBody != nil {
+			body = rn.DefaultBody(step.Api.Endpoint)
+		}
+	case string:
+		body = b
+	default:
+		j, err := json.Marshal(b)
+		if err != nil {
+			r.fail("could not encode body: " + err.Error())
+			return
+		}
+		body = string(j)
+	}
+	status, result, err := rn.Session.Call(ctx, step.Api.Endpoint, []byte(vars.Replace(body)))
+	if err != nil {
+		r.fail(err.Error())
+		return
+	}
+	r.Response = string(result)
+	check(step.Expect, status >= 200 && status < 300, status, errorCodes(result), result, r)
+}
+
+func (rn *Runner) runTable(ctx context.Context, step Step, vars *strings.Replacer, r *StepResult) {
+	q := *step.Table
+	q.Scope, q.Lower, q.Upper = vars.Replace(q.Scope), vars.Replace(q.Lower), vars.Replace(q.Upper)
+	rows, _, err := rn.Session.QueryTable(ctx, &q)
+	if err != nil {
+		r.Response = err.Error()
+		check(step.Expect, false, 0, nil, []byte(err.Error()), r)
+		return
+	}
+	r.Response = string(rows)
+	check(step.Expect, true, 0, nil, rows, r)
+	if step.Expect.Rows != nil {
+		checkRows(step.Expect.Rows, rows, vars, r)
+	}
+}
+
+func check(e Expect, success bool, status int, codes []string, result []byte, r *StepResult) {
+	want := true
+	if e.Success != nil {
+		want = *e.Success
+	} else if e.ErrorCode != "" {
+		want = false
+	}
+	if success != want {
+		msg := "expected success"
+		if !want {
+			msg = "expected failure"
+		}
+		if len(codes) > 0 {
+			msg = msg + ", error: " + strings.Join(codes, " ")
+		}
+		r.fail(msg)
+	}
+	if e.Status != 0 && e.Status != status {
+		r.fail("expected http status %d, got %d", e.Status, status)
+	}
+	if e.ErrorCode != "" {
+		found := false
+		for _, c := range codes {
+			if c == e.ErrorCode {
+				found = true
+				break
+			}
+		}
+		if !found {
+			r.fail("expected error code %s, got %q", e.ErrorCode, strings.Join(codes, " "))
+		}
+	}
+	for _, s := range e.Contains {
+		if !strings.Contains(string(result), s) {
+			r.fail("response does not contain %q", s)
+		}
+	}
+}
+
+// errorCodes pulls anything that looks like an error code out of a response, nodeos and the FIO api plugin
+// don't agree on where they go.
+func errorCodes(result []byte) []string {
+	e := struct {
+		Code      interface{} `json:"code"`
+		ErrorCode interface{} `json:"error_code"`
+		Type      string      `json:"type"`
+		Error     struct {
+			Code interface{} `json:"code"`
+			Name string      `json:"name"`
+		} `json:"error"`
+	}{}
+	d := json.NewDecoder(bytes.NewReader(result))
+	d.UseNumber()
+	if d.Decode(&e) != nil {
+		return nil
+	}
+	codes := make([]string, 0)
+	for _, c := range []interface{}{e.Error.Code, e.ErrorCode, e.Code} {
+		if c != nil {
+			codes = append(codes, fmt.Sprint(c))
+		}
+	}
+	for _, c := range []string{e.Error.Name, e.Type} {
+		if c != "" {
+			codes = append(codes, c)
+		}
+	}
+	return codes
+}
+
+func checkRows(e *RowsExpect, raw []byte, vars *strings.Replacer, r *StepResult) {
+	rows := make([]map[string]interface{}, 0)
+	d := json.NewDecoder(bytes.NewReader(raw))
+	d.UseNumber()
+	if err := d.Decode(&rows); err != nil {
+		r.fail("could not read rows: " + err.Error())
+		return
+	}
+	if e.Count != nil && len(rows) != *e.Count {
+		r.fail("expected %d rows, got %d", *e.Count, len(rows))
+	}
+	if e.Min != nil && len(rows) < *e.Min {
+		r.fail("expected at least %d rows, got %d", *e.Min, len(rows))
+	}
+	if e.Max != nil && len(rows) > *e.Max {
+		r.fail("expected at most %d rows, got %d", *e.Max, len(rows))
+	}
+	if len(e.Match) == 0 {
+		return
+	}
+	for _, row := range rows {
+		matched := true
+		for k, v := range e.Match {
+			if fmt.Sprint(row[k]) != vars.Replace(v) {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return
+		}
+	}
+	r.fail("no row matched %v", e.Match)
+}
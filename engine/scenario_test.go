@@ -0,0 +1,120 @@
+package engine
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const testScenario = `name: registration
+vars:
+  domain: dapix
+stop_on_failure: true
+steps:
+  - name: bad address
+    api:
+      endpoint: /v1/chain/avail_check
+      body:
+        fio_name: "bad@@${domain}"
+    expect:
+      status: 400
+      error_code: invalid_input
+  - table:
+      contract: fio.address
+      table: domains
+    expect:
+      rows:
+        count: 1
+        match:
+          name: ${domain}
+  - action:
+      contract: fio.address
+      action: regaddress
+      values:
+        max_fee: 800000000000
+    expect:
+      success: false
+    wait: 1s
+  - table:
+      contract: fio.address
+      table: domains
+    expect:
+      rows:
+        min: 2
+  - table:
+      contract: fio.address
+      table: domains
+`
+
+func TestScenario(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cryptonym-engine")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	fileName := filepath.Join(dir, "registration.yml")
+	if err = ioutil.WriteFile(fileName, []byte(testScenario), 0600); err != nil {
+		t.Fatal(err)
+	}
+	sc, err := LoadScenario(fileName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sc.Steps) != 5 || sc.Steps[2].Action.Values["max_fee"] != "800000000000" {
+		t.Fatalf("did not load scenario: %+v", sc)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/chain/get_info", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"chain_id":"b20901380af44ef59c5918439a1f9a41d83669020319a80574b804a5f95cbd7e","head_block_id":"0000000a5c5e4d2a2da1b4e5e9c9b2ad7b0c0a4b3e9e3f9a7b1a9d4c6f2a1b3c"}`))
+	})
+	mux.HandleFunc("/v1/chain/avail_check", func(w http.ResponseWriter, r *http.Request) {
+		b, _ := ioutil.ReadAll(r.Body)
+		if string(b) != `{"fio_name":"bad@@dapix"}` {
+			t.Errorf("vars were not replaced in body: %s", string(b))
+		}
+		w.WriteHeader(400)
+		_, _ = w.Write([]byte(`{"type":"invalid_input","message":"Invalid input","fields":[]}`))
+	})
+	mux.HandleFunc("/v1/chain/get_table_rows", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"rows":[{"id":0,"name":"dapix"}],"more":false}`))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	s, err := NewSession(context.Background(), server.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	progress := 0
+	rn := &Runner{Session: s, Progress: func(StepResult) { progress += 1 }}
+	rep := rn.Run(context.Background(), sc)
+	if rep.Passed != 2 || rep.Failed != 1 || rep.Skipped != 2 || rep.Ok() {
+		t.Error(rep.String())
+	}
+	if progress != 3 {
+		t.Errorf("expected progress for 3 steps, got %d", progress)
+	}
+	// the action step fails before sending since there is no account
+	if rep.Steps[2].Pass || len(rep.Steps[2].Failures) == 0 {
+		t.Error("action without an account should fail")
+	}
+
+	sc.StopOnFailure = false
+	sc.Steps = append(sc.Steps[:2], sc.Steps[3:]...)
+	rep = rn.Run(context.Background(), sc)
+	if rep.Passed != 3 || rep.Failed != 1 {
+		t.Error(rep.String())
+	}
+	if f := rep.Steps[2].Failures; len(f) != 1 || f[0] != "expected at least 2 rows, got 1" {
+		t.Errorf("unexpected failures %v", f)
+	}
+
+	if err = (&Scenario{Steps: []Step{{Name: "empty"}}}).Validate(); err == nil {
+		t.Error("step without an action, api or table should not validate")
+	}
+}
@@ -0,0 +1,230 @@
+package engine
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/fioprotocol/fio-go"
+	"github.com/fioprotocol/fio-go/eos"
+	"github.com/fioprotocol/fio-go/eos/ecc"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// UserAgent is sent with every request made by a session
+const UserAgent = "fio-cryptonym-wallet"
+
+var ErrNotConnected = errors.New("not connected to a node")
+
+// Session is a connection to a node and the account used for signing. It is safe to share between goroutines,
+// each call gets its own *fio.API so concurrent requests don't step on each other, and requests are cancelled
+// with the context.
+type Session struct {
+	mux       sync.RWMutex
+	url       string
+	opts      fio.TxOptions
+	account   *fio.Account
+	signer    eos.Signer
+	transport http.RoundTripper
+}
+
+// NewSession connects to url, account may be nil for read-only use
+func NewSession(ctx context.Context, url string, account *fio.Account) (*Session, error) {
+	s := &Session{}
+	s.SetAccount(account)
+	return s, s.Connect(ctx, url)
+}
+
+// Connect switches the session to another node, the chain id and ref block info are refreshed.
+func (s *Session) Connect(ctx context.Context, url string) error {
+	url = strings.TrimRight(url, "/")
+	api := eos.New(url)
+	transport := api.HttpClient.Transport
+	api.HttpClient = &http.Client{Transport: &ctxTransport{ctx: ctx, base: transport}}
+	api.Header.Set("User-Agent", UserAgent)
+	opts := &fio.TxOptions{}
+	if err := opts.FillFromChain(api); err != nil {
+		return err
+	}
+	s.mux.Lock()
+	s.url, s.opts, s.transport = url, *opts, transport
+	s.mux.Unlock()
+	return nil
+}
+
+func (s *Session) Connected() bool {
+	s.mux.RLock()
+	defer s.mux.RUnlock()
+	return s.url != ""
+}
+
+func (s *Session) Url() string {
+	s.mux.RLock()
+	defer s.mux.RUnlock()
+	return s.url
+}
+
+// Opts returns a copy of the transaction options, it's fine to change the copy
+func (s *Session) Opts() *fio.TxOptions {
+	s.mux.RLock()
+	defer s.mux.RUnlock()
+	o := s.opts
+	return &o
+}
+
+func (s *Session) Account() *fio.Account {
+	s.mux.RLock()
+	defer s.mux.RUnlock()
+	return s.account
+}
+
+// SetAccount switches the key used for signing, the account's key bag is used unless SetSigner is called after.
+func (s *Session) SetAccount(account *fio.Account) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	s.account = account
+	s.signer = nil
+	if account != nil && account.KeyBag != nil {
+		s.signer = account.KeyBag
+	}
+}
+
+// SetSigner replaces the signer, for keosd or an external signing process
+func (s *Session) SetSigner(signer eos.Signer) {
+	s.mux.Lock()
+	s.signer = signer
+	s.mux.Unlock()
+}
+
+// Api returns a client for the session's node that is only used by the caller. Its requests are cancelled
+// when ctx is done.
+func (s *Session) Api(ctx context.Context) (*fio.API, error) {
+	s.mux.RLock()
+	defer s.mux.RUnlock()
+	if s.url == "" {
+		return nil, ErrNotConnected
+	}
+	api := eos.New(s.url)
+	api.HttpClient = &http.Client{Transport: &ctxTransport{ctx: ctx, base: s.transport}}
+	api.Header.Set("User-Agent", UserAgent)
+	if s.signer != nil {
+		signer := s.signer
+		api.SetSigner(signer)
+		api.SetCustomGetRequiredKeys(func(tx *eos.Transaction) ([]ecc.PublicKey, error) {
+			return signer.AvailableKeys()
+		})
+	}
+	return &fio.API{API: api}, nil
+}
+
+// PackAndSign signs a payload from Action.Generate using the session's account
+func (s *Session) PackAndSign(ctx context.Context, p *Payload, po PackOptions) (json.RawMessage, *eos.PackedTransaction, error) {
+	api, err := s.Api(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	account := s.Account()
+	if account == nil {
+		return nil, nil, errors.New("no account has been loaded")
+	}
+	return PackAndSign(api, s.Opts(), account, p, po)
+}
+
+// DryRun reports sizes, fees and required keys for the payloads using the session's account, nothing is sent
+func (s *Session) DryRun(ctx context.Context, payloads []*Payload, po PackOptions) (*DryRun, error) {
+	api, err := s.Api(ctx)
+	if err != nil {
+		return nil, err
+	}
+	account := s.Account()
+	if account == nil {
+		return nil, errors.New("no account has been loaded")
+	}
+	return DryRunActions(api, s.Opts(), account, payloads, po)
+}
+
+func (s *Session) Push(ctx context.Context, endpoint string, tx *eos.PackedTransaction) (*TxSummary, []byte, error) {
+	api, err := s.Api(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	return Push(api, endpoint, tx)
+}
+
+// Send generates a payload for the action, then signs and pushes it
+func (s *Session) Send(ctx context.Context, a *Action, po PackOptions, endpoint string) (*TxSummary, []byte, error) {
+	account := s.Account()
+	if account == nil {
+		return nil, nil, errors.New("no account has been loaded")
+	}
+	p, err := a.Generate(account, s.Url())
+	if err != nil {
+		return nil, nil, err
+	}
+	_, tx, err := s.PackAndSign(ctx, p, po)
+	if err != nil {
+		return nil, nil, err
+	}
+	return s.Push(ctx, endpoint, tx)
+}
+
+func (s *Session) GetContracts(ctx context.Context) (*Contracts, error) {
+	api, err := s.Api(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return GetContracts(api)
+}
+
+func (s *Session) QueryTable(ctx context.Context, q *TableQuery) (rows json.RawMessage, more bool, err error) {
+	api, err := s.Api(ctx)
+	if err != nil {
+		return nil, false, err
+	}
+	return QueryTable(api, q)
+}
+
+func (s *Session) AccountSearch(ctx context.Context, searchFor string, value string) (*AccountInformation, error) {
+	api, err := s.Api(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return AccountSearch(api, searchFor, value)
+}
+
+// Call posts body to an api endpoint, the response is returned regardless of the http status.
+func (s *Session) Call(ctx context.Context, endpoint string, body []byte) (status int, result []byte, err error) {
+	api, err := s.Api(ctx)
+	if err != nil {
+		return 0, nil, err
+	}
+	req, err := http.NewRequest("POST", api.BaseURL+endpoint, bytes.NewReader(body))
+	if err != nil {
+		return 0, nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", UserAgent)
+	resp, err := api.HttpClient.Do(req)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer resp.Body.Close()
+	result, err = ioutil.ReadAll(resp.Body)
+	return resp.StatusCode, result, err
+}
+
+// ctxTransport attaches a context to every request, the fio-go client doesn't take one
+type ctxTransport struct {
+	ctx  context.Context
+	base http.RoundTripper
+}
+
+func (t *ctxTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if t.ctx == nil {
+		return t.base.RoundTrip(req)
+	}
+	return t.base.RoundTrip(req.WithContext(t.ctx))
+}
@@ -0,0 +1,80 @@
+package engine
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestSession(t *testing.T) {
+	block := make(chan struct{})
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/chain/get_info", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"chain_id":"b20901380af44ef59c5918439a1f9a41d83669020319a80574b804a5f95cbd7e","head_block_num":10,"last_irreversible_block_num":9,"head_block_id":"0000000a5c5e4d2a2da1b4e5e9c9b2ad7b0c0a4b3e9e3f9a7b1a9d4c6f2a1b3c","head_block_time":"2020-01-01T00:00:00.000","server_version":"v2.0.7"}`))
+	})
+	mux.HandleFunc("/v1/chain/get_abi", func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-block:
+		case <-r.Context().Done():
+		}
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+	defer close(block)
+
+	if _, err := (&Session{}).Api(context.Background()); err != ErrNotConnected {
+		t.Error("expected ErrNotConnected before connecting")
+	}
+
+	s, err := NewSession(context.Background(), server.URL+"/", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Url() != server.URL {
+		t.Errorf("trailing slash should be trimmed, got %s", s.Url())
+	}
+	if s.Opts().ChainID.String() != "b20901380af44ef59c5918439a1f9a41d83669020319a80574b804a5f95cbd7e" {
+		t.Error("chain id was not set")
+	}
+	s.Opts().ChainID = nil
+	if s.Opts().ChainID == nil {
+		t.Error("Opts should return a copy")
+	}
+
+	// a cancelled context should abort a request that would otherwise hang
+	ctx, cancel := context.WithCancel(context.Background())
+	api, err := s.Api(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	done := make(chan error, 1)
+	go func() {
+		_, err := api.GetABI("fio.token")
+		done <- err
+	}()
+	cancel()
+	select {
+	case err = <-done:
+		if err == nil {
+			t.Error("expected an error after cancelling")
+		}
+	case <-time.After(5 * time.Second):
+		t.Error("request was not cancelled")
+	}
+}
+
+func TestTableQueryRequest(t *testing.T) {
+	q := &TableQuery{Contract: "fio.address", Table: "fionames", Lower: "test@dapix", Transform: "hash"}
+	req, err := q.Request()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if req.Scope != "fio.address" || req.Limit != 10 {
+		t.Errorf("defaults not applied: %+v", req)
+	}
+	if req.LowerBound == "test@dapix" || req.LowerBound == "" {
+		t.Errorf("lower bound was not hashed: %s", req.LowerBound)
+	}
+}
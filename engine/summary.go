@@ -0,0 +1,65 @@
+package engine
+
+import (
+	"encoding/json"
+	"github.com/fioprotocol/fio-go"
+	"github.com/fioprotocol/fio-go/eos"
+)
+
+type TxSummary struct {
+	TransactionId string `json:"transaction_id" yaml:"Transaction Id"`
+	Processed     struct {
+		BlockNum  uint32 `json:"block_num" yaml:"Block Number"`
+		BlockTime string `json:"block_time" yaml:"Block Time"`
+		Receipt   struct {
+			Status string `json:"status" yaml:"Status"`
+		} `json:"receipt" yaml:"Receipt,omitempty"`
+	} `json:"processed" yaml:"Processed,omitempty"`
+	ErrorCode  interface{} `json:"error_code" yaml:"Error,omitempty"`                             // is this a string, int, varies on context?
+	TotalBytes int         `json:"total_bytes,omitempty" yaml:"TX Size of All Actions,omitempty"` // this is field we calculate later
+}
+
+// to get the *real* size of what was transacted, we need to dig into the action traces and look at the length
+// of the hex_data field, which is buried in the response.
+type txTraces struct {
+	Processed struct {
+		ActionTraces []struct {
+			Act struct {
+				HexData string `json:"hex_data"`
+			} `json:"act"`
+		} `json:"action_traces"`
+	} `json:"processed"`
+}
+
+func (tt txTraces) size() int {
+	if len(tt.Processed.ActionTraces) == 0 {
+		return 0
+	}
+	var sz int
+	for _, t := range tt.Processed.ActionTraces {
+		sz = sz + (len(t.Act.HexData) / 2)
+	}
+	return sz
+}
+
+// ParseTxSummary reads the summary from a push_transaction response, including the size of all actions
+func ParseTxSummary(result []byte) (*TxSummary, error) {
+	summary := &TxSummary{}
+	if err := json.Unmarshal(result, summary); err != nil {
+		return nil, err
+	}
+	sz := &txTraces{}
+	_ = json.Unmarshal(result, sz)
+	summary.TotalBytes = sz.size()
+	return summary, nil
+}
+
+// Push sends a signed transaction to endpoint, the full response is returned even when there is an error
+func Push(api *fio.API, endpoint string, tx *eos.PackedTransaction) (summary *TxSummary, result []byte, err error) {
+	result, err = api.PushEndpointRaw(endpoint, tx)
+	if err != nil {
+		return nil, result, err
+	}
+	summary, err = ParseTxSummary(result)
+	return summary, result, err
+}
@@ -0,0 +1,84 @@
+package engine
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"github.com/fioprotocol/fio-go"
+	"github.com/fioprotocol/fio-go/eos"
+)
+
+// TableQuery is a get_table_rows request. Transform is applied to the bounds before sending:
+// "name -> i64", "checksum256", or "hash" (the fio.address name hash.)
+type TableQuery struct {
+	Contract  string `json:"contract" yaml:"contract"`
+	Scope     string `json:"scope,omitempty" yaml:"scope,omitempty"`
+	Table     string `json:"table" yaml:"table"`
+	Index     string `json:"index,omitempty" yaml:"index,omitempty"`
+	KeyType   string `json:"key_type,omitempty" yaml:"key_type,omitempty"`
+	Lower     string `json:"lower,omitempty" yaml:"lower,omitempty"`
+	Upper     string `json:"upper,omitempty" yaml:"upper,omitempty"`
+	Transform string `json:"transform,omitempty" yaml:"transform,omitempty"`
+	Reverse   bool   `json:"reverse,omitempty" yaml:"reverse,omitempty"`
+	Limit     uint32 `json:"limit,omitempty" yaml:"limit,omitempty"`
+}
+
+// Request is the get_table_rows body that will be sent, with the bounds transformed
+func (q *TableQuery) Request() (*fio.GetTableRowsOrderRequest, error) {
+	lower, upper := q.Lower, q.Upper
+	switch q.Transform {
+	case "name -> i64":
+		u, err := eos.StringToName(upper)
+		if err != nil {
+			return nil, err
+		}
+		l, err := eos.StringToName(lower)
+		if err != nil {
+			return nil, err
+		}
+		upper = fmt.Sprintf("%d", u)
+		lower = fmt.Sprintf("%d", l)
+	case "checksum256":
+		ub := sha256.Sum256([]byte(upper))
+		lb := sha256.Sum256([]byte(lower))
+		upper = hex.EncodeToString(ub[:])
+		lower = hex.EncodeToString(lb[:])
+	case "hash":
+		upper = FioDomainNameHash(upper)
+		lower = FioDomainNameHash(lower)
+	}
+	scope := q.Scope
+	if scope == "" {
+		scope = q.Contract
+	}
+	limit := q.Limit
+	if limit == 0 {
+		limit = 10
+	}
+	return &fio.GetTableRowsOrderRequest{
+		Code:       q.Contract,
+		Scope:      scope,
+		Table:      q.Table,
+		LowerBound: lower,
+		UpperBound: upper,
+		Limit:      limit,
+		KeyType:    q.KeyType,
+		Index:      q.Index,
+		JSON:       true,
+		Reverse:    q.Reverse,
+	}, nil
+}
+
+// QueryTable returns the rows as a json array
+func QueryTable(api *fio.API, q *TableQuery) (rows json.RawMessage, more bool, err error) {
+	gtr, err := q.Request()
+	if err != nil {
+		return nil, false, err
+	}
+	resp, err := api.GetTableRowsOrder(*gtr)
+	if err != nil {
+		return nil, false, err
+	}
+	return resp.Rows, resp.More, nil
+}
@@ -0,0 +1,223 @@
+package engine
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// ErrorSignature is a failure with the parts that change between requests removed, so the same bug found by
+// different payloads ends up in one bucket.
+type ErrorSignature struct {
+	Code    string `json:"code" yaml:"code"`
+	Message string `json:"message" yaml:"message"`
+	Field   string `json:"field,omitempty" yaml:"field,omitempty"`
+}
+
+func (s ErrorSignature) String() string {
+	out := s.Code
+	if s.Field != "" {
+		out = out + " " + s.Field
+	}
+	return out + ": " + s.Message
+}
+
+func (s ErrorSignature) key() string {
+	return s.Code + "\x00" + s.Field + "\x00" + s.Message
+}
+
+// nodeosError covers the nodeos error response, and the FIO api plugin's invalid_input response
+type nodeosError struct {
+	Code    interface{}  `json:"code"`
+	Type    string       `json:"type"`
+	Message string       `json:"message"`
+	Fields  []fioField   `json:"fields"`
+	Error   *nodeosInner `json:"error"`
+}
+
+type nodeosInner struct {
+	Code    interface{} `json:"code"`
+	Name    string      `json:"name"`
+	What    string      `json:"what"`
+	Details []struct {
+		Message string `json:"message"`
+	} `json:"details"`
+}
+
+type fioField struct {
+	Name  string `json:"name"`
+	Error string `json:"error"`
+}
+
+// Signature finds the error code, message and failing field in a response. Responses that aren't JSON (errors
+// from generating or signing) are used as the message.
+func Signature(resp []byte) ErrorSignature {
+	// the client sometimes prefixes the body with its own error
+	if i := bytes.IndexByte(resp, '{'); i > 0 && json.Valid(resp[i:]) {
+		resp = resp[i:]
+	}
+	e := nodeosError{}
+	d := json.NewDecoder(bytes.NewReader(resp))
+	d.UseNumber()
+	if d.Decode(&e) != nil {
+		return ErrorSignature{Code: "error", Message: NormalizeError(string(resp))}
+	}
+	sig := ErrorSignature{Code: e.Type, Message: e.Message}
+	if e.Error != nil {
+		switch {
+		case e.Error.Name != "":
+			sig.Code = e.Error.Name
+		case e.Error.Code != nil:
+			sig.Code = fmt.Sprint(e.Error.Code)
+		}
+		sig.Message = e.Error.What
+		if len(e.Error.Details) > 0 && e.Error.Details[0].Message != "" {
+			sig.Message = strings.TrimPrefix(e.Error.Details[0].Message, "assertion failure with message: ")
+		}
+	}
+	// FIO contracts put the invalid field in the assertion as JSON
+	fields := e.Fields
+	if strings.HasPrefix(strings.TrimSpace(sig.Message), "{") {
+		inner := nodeosError{}
+		if json.Unmarshal([]byte(sig.Message), &inner) == nil && len(inner.Fields) > 0 {
+			fields = inner.Fields
+		}
+	}
+	if len(fields) > 0 {
+		sig.Field = fields[0].Name
+		if fields[0].Error != "" {
+			sig.Message = fields[0].Error
+		}
+	}
+	if sig.Code == "" && e.Code != nil {
+		sig.Code = fmt.Sprint(e.Code)
+	}
+	if sig.Code == "" {
+		sig.Code = "error"
+	}
+	sig.Message = NormalizeError(sig.Message)
+	return sig
+}
+
+var normalizers = []struct {
+	re  *regexp.Regexp
+	rep string
+}{
+	{regexp.MustCompile(`\b(FIO|EOS|PUB_K1_|PUB_R1_)[1-9A-HJ-NP-Za-km-z]{30,}`), "<key>"},
+	{regexp.MustCompile(`\bSIG_\w+`), "<sig>"},
+	{regexp.MustCompile(`"[^"]*"|'[^']*'|` + "`[^`]*`"), `"*"`},
+	{regexp.MustCompile(`\S+@\S+`), "<address>"},
+	{regexp.MustCompile(`\b[0-9a-fA-F]{16,}\b`), "<hex>"},
+	{regexp.MustCompile(`[\w.]*\d[\w.]*`), "#"},
+	{regexp.MustCompile(`\s+`), " "},
+}
+
+// NormalizeError strips the values from an error message: keys, quoted strings, addresses, hex and anything
+// with a number in it.
+func NormalizeError(msg string) string {
+	for _, n := range normalizers {
+		msg = n.re.ReplaceAllString(msg, n.rep)
+	}
+	msg = strings.TrimSpace(msg)
+	if len(msg) > 256 {
+		// cut on a rune boundary so the signature stays valid utf-8
+		end := 256
+		for end > 0 && !utf8.RuneStart(msg[end]) {
+			end--
+		}
+		msg = msg[:end] + "..."
+	}
+	return msg
+}
+
+// Reproducer is enough to send a failure again: the action data as it was sent, and the seed that built it
+type Reproducer struct {
+	Contract  string          `json:"contract" yaml:"contract"`
+	Action    string          `json:"action" yaml:"action"`
+	Endpoint  string          `json:"endpoint,omitempty" yaml:"endpoint,omitempty"`
+	Seed      int64           `json:"seed" yaml:"seed"`
+	Iteration int             `json:"iteration" yaml:"iteration"`
+	Time      time.Time       `json:"time" yaml:"time"`
+	Signature ErrorSignature  `json:"signature" yaml:"signature"`
+	Data      json.RawMessage `json:"data,omitempty" yaml:"-"`
+	Response  string          `json:"response,omitempty" yaml:"response,omitempty"`
+}
+
+// Bucket is every failure with the same signature
+type Bucket struct {
+	Signature ErrorSignature `json:"signature" yaml:"signature"`
+	Count     int            `json:"count" yaml:"count"`
+	First     Reproducer     `json:"first" yaml:"first"`
+	Last      Reproducer     `json:"last" yaml:"last"`
+}
+
+// Triage groups failures by signature, it only keeps the first and last example of each so it can run for
+// as long as the fuzzer does.
+type Triage struct {
+	mux     sync.Mutex
+	buckets map[string]*Bucket
+}
+
+func NewTriage() *Triage {
+	return &Triage{buckets: make(map[string]*Bucket)}
+}
+
+// Add files a failure, resp is the response or error. isNew is true the first time a signature is seen.
+func (t *Triage) Add(r Reproducer, resp []byte) (sig ErrorSignature, isNew bool) {
+	r.Signature = Signature(resp)
+	if r.Time.IsZero() {
+		r.Time = time.Now()
+	}
+	if r.Response == "" {
+		r.Response = string(resp)
+	}
+	t.mux.Lock()
+	defer t.mux.Unlock()
+	b := t.buckets[r.Signature.key()]
+	if b == nil {
+		t.buckets[r.Signature.key()] = &Bucket{Signature: r.Signature, Count: 1, First: r, Last: r}
+		return r.Signature, true
+	}
+	b.Count += 1
+	b.Last = r
+	return r.Signature, false
+}
+
+// Buckets returns a copy of the buckets, the most common first
+func (t *Triage) Buckets() []Bucket {
+	t.mux.Lock()
+	out := make([]Bucket, 0, len(t.buckets))
+	for _, b := range t.buckets {
+		out = append(out, *b)
+	}
+	t.mux.Unlock()
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Count == out[j].Count {
+			return out[i].First.Time.Before(out[j].First.Time)
+		}
+		return out[i].Count > out[j].Count
+	})
+	return out
+}
+
+// Failures is the total of all buckets
+func (t *Triage) Failures() (n int) {
+	t.mux.Lock()
+	defer t.mux.Unlock()
+	for _, b := range t.buckets {
+		n += b.Count
+	}
+	return
+}
+
+func (t *Triage) Reset() {
+	t.mux.Lock()
+	t.buckets = make(map[string]*Bucket)
+	t.mux.Unlock()
+}
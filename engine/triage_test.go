@@ -0,0 +1,61 @@
+package engine
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestTriage(t *testing.T) {
+	assert := func(msg string) []byte {
+		return []byte(`{"code":500,"message":"Internal Service Error","error":{"code":3050003,"name":"eosio_assert_message_exception","what":"eosio_assert_message assertion failure","details":[{"message":"assertion failure with message: ` + msg + `","file":"cf_system.cpp","line_number":14,"method":"eosio_assert"}]}}`)
+	}
+	fioInvalid := func(value string) []byte {
+		return []byte(`{"type":"invalid_input","message":"An invalid request was sent in, verify all parameters.","fields":[{"name":"fio_address","value":"` + value + `","error":"Invalid FIO Address"}]}`)
+	}
+
+	for _, test := range []struct {
+		a, b []byte
+		same bool
+	}{
+		{assert("missing authority of abcdefg12345"), assert("missing authority of zz1234512345"), true},
+		{assert("Fee exceeds supplied maximum: 1000 > 10"), assert("Fee exceeds supplied maximum: 5 > 2"), true},
+		{assert(`unknown key FIO6G9pXXM92Gy5eMwNquGULoCj3ZStwPLPdEb9mVXyEHqWN7HSuA`), assert(`unknown key FIO5oBUYbtGTxMS66pPkjC2p8pbA3zCtc8XD4dq9cMut867GRdh82`), true},
+		{fioInvalid("a@b"), fioInvalid("!!!@dapixdev"), true},
+		{[]byte(`could not pack: Encode: field "amount" 123`), []byte(`could not pack: Encode: field "max_fee" 5`), true},
+		{assert("missing authority of abcdefg12345"), assert("Fee exceeds supplied maximum: 5 > 2"), false},
+		{fioInvalid("a@b"), assert("Invalid FIO Address"), false},
+	} {
+		a, b := Signature(test.a), Signature(test.b)
+		if (a == b) != test.same {
+			t.Errorf("expected same=%v:\n%s\n%s", test.same, a, b)
+		}
+	}
+
+	if s := Signature(fioInvalid("x")); s.Code != "invalid_input" || s.Field != "fio_address" || s.Message != "Invalid FIO Address" {
+		t.Errorf("unexpected signature for invalid_input %+v", s)
+	}
+	embedded := assert(`{\"fields\":[{\"name\":\"max_fee\",\"value\":\"1\",\"error\":\"Fee exceeds supplied maximum.\"}]}`)
+	if s := Signature(embedded); s.Code != "eosio_assert_message_exception" || s.Field != "max_fee" {
+		t.Errorf("unexpected signature for a fio assertion %+v", s)
+	}
+
+	if n := NormalizeError("x" + strings.Repeat("é", 200)); !utf8.ValidString(n) || len(n) > 259 {
+		t.Errorf("expected a long message to be cut on a rune boundary, got %q", n)
+	}
+
+	tr := NewTriage()
+	for i := 0; i < 100; i++ {
+		tr.Add(Reproducer{Iteration: i}, assert("missing authority of abcdefg12345"))
+		if i%10 == 0 {
+			tr.Add(Reproducer{Iteration: i}, fioInvalid("nope"))
+		}
+	}
+	buckets := tr.Buckets()
+	if len(buckets) != 2 || buckets[0].Count != 100 || buckets[1].Count != 10 || tr.Failures() != 110 {
+		t.Fatalf("unexpected buckets %+v", buckets)
+	}
+	if buckets[0].First.Iteration != 0 || buckets[0].Last.Iteration != 99 || buckets[1].Last.Iteration != 90 {
+		t.Error("wrong examples kept")
+	}
+}
@@ -0,0 +1,244 @@
+package engine
+
+import (
+	"github.com/blockpane/cryptonym/fuzzer"
+	"strings"
+)
+
+// SendAsTypes are the generators offered in the action editor, a Field's Variation and Len are chosen from
+// the lists below.
+var SendAsTypes = []string{
+	"form value",
+	"actor",
+	"pub key",
+	"fio types",
+	"number",
+	"bytes/string",
+	"abi struct",
+	"boundary",
+	"obt content",
+	"load file",
+}
+
+var BytesVar = []string{
+	"bytes",
+	"bytes: base64 encoded",
+	"bytes: hex encoded",
+	"random checksum",
+	"string",
+}
+
+// BoundaryPrefix keeps the boundary variations apart from the bytes/string ones, "string" is in both.
+const BoundaryPrefix = "boundary: "
+
+// BoundaryVar has a variation for each type with edge cases, the Len is the edge case
+var BoundaryVar = func() []string {
+	v := make([]string, len(fuzzer.BoundaryTypes))
+	for i := range fuzzer.BoundaryTypes {
+		v[i] = BoundaryPrefix + fuzzer.BoundaryTypes[i]
+	}
+	return v
+}()
+
+// ContentPrefix is on the obt content variations, the Len picks newfundsreq or recordobt content
+const ContentPrefix = "encrypted: "
+
+var ContentVar = func() []string {
+	v := make([]string, len(fuzzer.ContentCases))
+	for i := range fuzzer.ContentCases {
+		v[i] = ContentPrefix + fuzzer.ContentCases[i]
+	}
+	return v
+}()
+
+var BytesLen = []string{
+	"random length",
+	"8",
+	"12",
+	"16",
+	"32",
+	"64",
+	"128",
+	"256",
+	"512",
+	"2,048",
+	"4,096",
+	//"8,192",
+	//"16,384",
+	//"32,768",
+	//"65,536",
+	//"131,072",
+	//"262,144",
+	//"524,288",
+	//"1,048,576",
+	//"2,097,152",
+	//"4,194,304",
+	//"8,388,608",
+	//"16,777,216",
+}
+
+var FormVar = []string{
+	"as is",
+	"FIO -> suf",
+	"json -> struct",
+	"base64 -> byte[]",
+	"checksum256",
+	"fio address@ (invalid)",
+	"fio address@ (valid)",
+	"fio address@ (valid, max size)",
+	"hex -> byte[]",
+	"signature",
+}
+
+var ActorVar = []string{
+	"mine",
+	"random",
+}
+
+var NumericVar = []string{
+	"incrementing float",
+	"incrementing int",
+	"random float",
+	"random int",
+	"overflow int",
+	"random number (mixed)",
+	"max int",
+}
+
+var MaxIntVar = []string{
+	"int8",
+	"uint8",
+	"int16",
+	"uint16",
+	"int32",
+	"uint32",
+	"int64",
+	"uint64",
+}
+
+var FioVar = []string{
+	"invalid fio domain",
+	"valid fio domain",
+	"valid fio domain (max size)",
+	"max length: newfundsreq.content",
+	"max length: recordobt.content",
+	"max length: regproducer.url",
+	"max length: voteproducer.producers",
+	"max length: addaddress.public_addresses",
+	"variable length: addaddress.public_addresses",
+	"chain code",
+	"token code",
+	"on chain: expired domain",
+	"on chain: active domain",
+	"on chain: public domain",
+	"on chain: private domain",
+	"on chain: someone else's domain",
+	"on chain: someone else's address",
+	"on chain: address with zero bundles",
+	"on chain: actor with balance",
+	"on chain: actor without balance",
+	"on chain: someone else's pending request id",
+	//TODO:
+	//"string[] of existing fio address",
+}
+
+// TODO: "string[] of existing fio address"....
+var AddressLen = []string{
+	"2",
+	"4",
+	"8",
+	"16",
+	"32",
+}
+
+var FloatLen = []string{
+	"32",
+	"64",
+}
+
+var IntLen = []string{
+	"8",
+	"16",
+	"32",
+	"64",
+	"128",
+}
+
+var OverflowLen = []string{
+	"8",
+	"16",
+	"32",
+}
+
+var NumAddresses = []string{
+	"1",
+	"2",
+	"3",
+	"4",
+	"5",
+	"10",
+	"50",
+	"100",
+	"1000",
+}
+
+// SendAsVariant returns the variations for a generator, and the one selected by default
+func SendAsVariant(kind string) (options []string, selected string) {
+	switch kind {
+	case "form value":
+		return FormVar, "as is"
+	case "actor":
+		return ActorVar, "mine"
+	case "pub key":
+		return ActorVar, "mine"
+	case "number":
+		return NumericVar, "random int"
+	case "bytes/string":
+		return BytesVar, "string"
+	case "fio types":
+		return FioVar, "invalid fio domain"
+	case "abi struct":
+		return fuzzer.ModeNames, "valid"
+	case "boundary":
+		return BoundaryVar, BoundaryPrefix + "string"
+	case "obt content":
+		return ContentVar, ContentPrefix + "valid"
+	case "load file":
+		return fuzzer.FileFormats, "wordlist"
+	}
+	return []string{}, "--"
+}
+
+// LengthFor returns the lengths that can be picked for a variation, if any
+func LengthFor(what string) (show bool, values []string, selected string) {
+	switch {
+	case what == "random float":
+		return true, FloatLen, "32"
+	case what == "variable length addaddress.public_addresses":
+		return true, NumAddresses, "1"
+	case what == "random int":
+		return true, IntLen, "32"
+	case what == "overflow int":
+		return true, OverflowLen, "16"
+	case what == "max int":
+		return true, MaxIntVar, "int32"
+	case what == "random number (mixed)":
+		return false, []string{""}, ""
+	case what == "wordlist" || what == "jsonl" || what == "csv column":
+		return true, fuzzer.FileOrders, "sequential"
+	case strings.HasPrefix(what, ContentPrefix):
+		return true, fuzzer.ContentKinds, "newfundsreq"
+	case strings.HasPrefix(what, BoundaryPrefix):
+		cases := fuzzer.BoundaryCases(strings.TrimPrefix(what, BoundaryPrefix))
+		if len(cases) == 0 {
+			return
+		}
+		return true, cases, cases[0]
+	case strings.HasPrefix(what, "string") ||
+		strings.HasPrefix(what, "bytes") ||
+		strings.HasPrefix(what, "nop") ||
+		strings.HasPrefix(what, "many"):
+		return true, BytesLen, "64"
+	}
+	return
+}
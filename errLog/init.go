@@ -4,6 +4,7 @@ import (
 	"fyne.io/fyne/widget"
 	"log"
 	"strings"
+	"sync"
 	"time"
 )
 
@@ -14,8 +15,22 @@ var (
 	ErrMsgs        = widget.NewMultiLineEntry()
 	RefreshChan    = make(chan bool)
 	Connected      bool
+	errTxtMux      sync.RWMutex
 )
 
+// Events returns a copy of the event log, newest first
+func Events() []string {
+	errTxtMux.RLock()
+	defer errTxtMux.RUnlock()
+	events := make([]string, 0, len(ErrTxt))
+	for _, e := range ErrTxt {
+		if e != "" {
+			events = append(events, e)
+		}
+	}
+	return events
+}
+
 func init() {
 	go func(msg chan string, disconnected chan bool) {
 		last := time.Now()
@@ -27,11 +42,15 @@ func init() {
 				time.Sleep(250 * time.Millisecond)
 			case m := <-msg:
 				log.Println(m)
+				errTxtMux.Lock()
 				ErrTxt = append([]string{time.Now().Format(time.Stamp) + " -- " + m}, ErrTxt[:len(ErrTxt)-1]...)
+				errTxtMux.Unlock()
 				last = time.Now()
 			case <-t.C:
 				if time.Now().After(last.Add(500 * time.Millisecond)) {
+					errTxtMux.RLock()
 					txt := strings.Join(ErrTxt, "\n")
+					errTxtMux.RUnlock()
 					func(s string) {
 						ErrMsgs.OnChanged = func(string) {
 							ErrMsgs.SetText(s)
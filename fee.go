@@ -0,0 +1,61 @@
+package cryptonym
+
+import (
+	"fmt"
+	"github.com/blockpane/cryptonym/engine"
+	errs "github.com/blockpane/cryptonym/errLog"
+	"github.com/fioprotocol/fio-go"
+	"strconv"
+)
+
+// Fees is the live fee table, fees shown in the ui come from here instead of fio-go's copy that's loaded once
+var Fees = engine.NewFeeService(engine.DefaultFeeWindow)
+
+// liveFee is the table fee for an endpoint in FIO, it falls back to fio.GetMaxFee if the table can't be read
+func liveFee(api *fio.API, endpoint string) float64 {
+	return liveFeeFor(api, endpoint, "")
+}
+
+// liveFeeFor is what the owner of fioAddress will be charged, nothing if it has bundled transactions left
+func liveFeeFor(api *fio.API, endpoint string, fioAddress string) float64 {
+	fee, err := Fees.Fee(api, endpoint, fioAddress)
+	if err != nil {
+		return fio.GetMaxFee(endpoint)
+	}
+	return float64(fee) / 1_000_000_000.0
+}
+
+// withLiveFee sets the max_fee of an action from one of fio-go's constructors
+func withLiveFee(api *fio.API, a *fio.Action, endpoint string) *fio.Action {
+	engine.SetMaxFee(a, fio.Tokens(liveFee(api, endpoint)))
+	return a
+}
+
+// checkMaxFee warns if the editor's max_fee won't cover what the action costs the account. It's only a warning,
+// sending a low fee is sometimes the point.
+func checkMaxFee(api *fio.API, account *fio.Account) {
+	FormState.mux.RLock()
+	action := FormState.Action
+	FormState.mux.RUnlock()
+	for _, f := range FormState.Fields() {
+		if f.Name != "max_fee" || f.SendAs != "form value" {
+			continue
+		}
+		v, err := f.GenerateWith(account, Uri, nil)
+		if err != nil {
+			return
+		}
+		maxFee, err := strconv.ParseUint(fmt.Sprint(v.V), 10, 64)
+		if err != nil {
+			return
+		}
+		fee, err := Fees.ForAction(api, action, Fees.PayerAddress(api, account.Actor))
+		if err != nil {
+			return
+		}
+		if maxFee < fee {
+			errs.ErrChan <- fmt.Sprintf("warning: max_fee %s is below the live fee %s for %s", engine.FioString(maxFee), engine.FioString(fee), action)
+		}
+		return
+	}
+}
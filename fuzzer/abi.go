@@ -0,0 +1,447 @@
+package fuzzer
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/fioprotocol/fio-go/eos"
+	"github.com/fioprotocol/fio-go/eos/btcsuite/btcutil/base58"
+	"github.com/fioprotocol/fio-go/eos/ecc"
+	"golang.org/x/crypto/ripemd160"
+	"math"
+	"math/big"
+	"math/rand"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Mode is the kind of values an AbiGen produces
+type Mode uint8
+
+const (
+	// ModeValid values should pass the contract's checks, or at least look like they could
+	ModeValid Mode = iota
+	// ModeBoundary values are the smallest, largest and empty values for a type
+	ModeBoundary
+	// ModeInvalid values still serialize but are wrong for the type, ie negative amounts or malformed names.
+	// Types where nothing can be wrong on the wire (bool) fall back to boundary values.
+	ModeInvalid
+)
+
+var ModeNames = []string{"valid", "boundary", "invalid"}
+
+func (m Mode) String() string {
+	if int(m) < len(ModeNames) {
+		return ModeNames[m]
+	}
+	return "unknown"
+}
+
+// ParseMode is the reverse of String
+func ParseMode(s string) (Mode, error) {
+	for i := range ModeNames {
+		if ModeNames[i] == s {
+			return Mode(i), nil
+		}
+	}
+	return ModeValid, fmt.Errorf("unknown fuzzer mode %q, expected one of %s", s, strings.Join(ModeNames, ", "))
+}
+
+// AbiGen walks an abi's structs, building JSON that EncodeAction will accept. It handles nested structs,
+// arrays (`[]`), optionals (`?`), binary extensions (`$`), variants and type aliases.
+type AbiGen struct {
+	Abi  *eos.ABI
+	Mode Mode
+	Rand *rand.Rand
+	// MaxDepth limits nested structs, arrays and optionals are left empty when it is reached
+	MaxDepth int
+	// MaxArray is the most elements a valid array gets, invalid arrays are much larger
+	MaxArray int
+}
+
+func NewAbiGen(abi *eos.ABI, mode Mode) *AbiGen {
+	return &AbiGen{
+		Abi:      abi,
+		Mode:     mode,
+		Rand:     rand.New(rand.NewSource(rng.Int63())),
+		MaxDepth: 8,
+		MaxArray: 4,
+	}
+}
+
+// Action generates the data for an action
+func (g *AbiGen) Action(name string) (json.RawMessage, error) {
+	a := g.Abi.ActionForName(eos.ActionName(name))
+	if a == nil {
+		return nil, fmt.Errorf("abi does not have an action named %s", name)
+	}
+	return g.Type(a.Type)
+}
+
+// Type generates a value for any abi type, including suffixes like `[]` or `?`
+func (g *AbiGen) Type(typeName string) (json.RawMessage, error) {
+	v, present, err := g.value(typeName, 0)
+	if err != nil {
+		return nil, err
+	}
+	if !present {
+		return json.RawMessage("null"), nil
+	}
+	return json.Marshal(v)
+}
+
+// object keeps the field order from the abi when marshalled
+type object []member
+
+type member struct {
+	name  string
+	value interface{}
+}
+
+func (o object) MarshalJSON() ([]byte, error) {
+	buf := bytes.NewBufferString("{")
+	for i, m := range o {
+		if i > 0 {
+			buf.WriteString(",")
+		}
+		k, _ := json.Marshal(m.name)
+		v, err := json.Marshal(m.value)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s", m.name, err.Error())
+		}
+		buf.Write(k)
+		buf.WriteString(":")
+		buf.Write(v)
+	}
+	buf.WriteString("}")
+	return buf.Bytes(), nil
+}
+
+func (g *AbiGen) value(typeName string, depth int) (v interface{}, present bool, err error) {
+	if depth > g.MaxDepth {
+		return nil, false, fmt.Errorf("%s: nested more than %d levels deep", typeName, g.MaxDepth)
+	}
+	switch {
+	case strings.HasSuffix(typeName, "?"):
+		if depth == g.MaxDepth || g.Mode == ModeBoundary || (g.Mode == ModeValid && g.Rand.Intn(2) == 0) {
+			return nil, false, nil
+		}
+		return g.value(strings.TrimSuffix(typeName, "?"), depth)
+	case strings.HasSuffix(typeName, "$"):
+		// the encoder needs binary extensions to be present
+		return g.value(strings.TrimSuffix(typeName, "$"), depth)
+	case strings.HasSuffix(typeName, "[]"):
+		var n int
+		switch {
+		case depth == g.MaxDepth:
+		case g.Mode == ModeValid:
+			n = 1 + g.Rand.Intn(g.MaxArray)
+		case g.Mode == ModeBoundary:
+			n = g.Rand.Intn(2)
+		case g.Mode == ModeInvalid:
+			n = 16 * g.MaxArray
+		}
+		arr := make([]interface{}, 0, n)
+		for i := 0; i < n; i++ {
+			elem, _, err := g.value(strings.TrimSuffix(typeName, "[]"), depth+1)
+			if err != nil {
+				return nil, false, err
+			}
+			arr = append(arr, elem)
+		}
+		return arr, true, nil
+	}
+
+	if resolved, isAlias := g.Abi.TypeNameForNewTypeName(typeName); isAlias && resolved != typeName {
+		return g.value(resolved, depth)
+	}
+	if variant := g.Abi.VariantForName(typeName); variant != nil {
+		if len(variant.Types) == 0 {
+			return nil, false, fmt.Errorf("variant %s has no types", typeName)
+		}
+		t := variant.Types[g.Rand.Intn(len(variant.Types))]
+		inner, _, err := g.value(t, depth+1)
+		if err != nil {
+			return nil, false, err
+		}
+		return []interface{}{t, inner}, true, nil
+	}
+	if s := g.Abi.StructForName(typeName); s != nil {
+		obj, err := g.structFields(s, depth+1)
+		return obj, err == nil, err
+	}
+	v, err = g.scalar(typeName)
+	return v, err == nil, err
+}
+
+func (g *AbiGen) structFields(s *eos.StructDef, depth int) (object, error) {
+	obj := make(object, 0, len(s.Fields))
+	if s.Base != "" {
+		base := g.Abi.StructForName(s.Base)
+		if base == nil {
+			return nil, fmt.Errorf("%s: base struct %s is not in the abi", s.Name, s.Base)
+		}
+		baseFields, err := g.structFields(base, depth)
+		if err != nil {
+			return nil, err
+		}
+		obj = append(obj, baseFields...)
+	}
+	for _, f := range s.Fields {
+		v, present, err := g.value(f.Type, depth)
+		if err != nil {
+			return nil, fmt.Errorf("%s.%s: %s", s.Name, f.Name, err.Error())
+		}
+		if present {
+			obj = append(obj, member{name: f.Name, value: v})
+		}
+	}
+	return obj, nil
+}
+
+// outOfRange is limit+by as a decimal string, it can be past the range of an int64 or uint64
+func outOfRange(limit interface{}, by int64) string {
+	i := new(big.Int)
+	switch l := limit.(type) {
+	case int64:
+		i.SetInt64(l)
+	case uint64:
+		i.SetUint64(l)
+	}
+	return i.Add(i, big.NewInt(by)).String()
+}
+
+// pick chooses one of the values for the current mode
+func (g *AbiGen) pick(valid func() interface{}, boundary []interface{}, invalid []interface{}) interface{} {
+	switch {
+	case g.Mode == ModeBoundary || (g.Mode == ModeInvalid && len(invalid) == 0):
+		return boundary[g.Rand.Intn(len(boundary))]
+	case g.Mode == ModeInvalid:
+		return invalid[g.Rand.Intn(len(invalid))]
+	}
+	return valid()
+}
+
+func (g *AbiGen) scalar(typeName string) (interface{}, error) {
+	switch typeName {
+	case "bool":
+		return g.pick(func() interface{} { return g.Rand.Intn(2) == 1 }, []interface{}{false, true}, nil), nil
+
+	case "int8", "int16", "int32", "varint32", "int64":
+		bits := intBits(typeName)
+		max := int64(math.MaxInt64 >> (64 - bits))
+		min := -max - 1
+		return g.pick(
+			func() interface{} { return g.Rand.Int63n(smaller(max, 1_000_000_000_000)) },
+			[]interface{}{min, min + 1, int64(-1), int64(0), int64(1), max - 1, max},
+			// out of range, as strings since they don't fit in an int64
+			[]interface{}{outOfRange(min, -1), outOfRange(max, 1), outOfRange(max, 1+g.Rand.Int63n(1_000_000))},
+		), nil
+
+	case "uint8", "uint16", "uint32", "varuint32", "uint64":
+		bits := intBits(typeName)
+		max := uint64(math.MaxUint64 >> (64 - bits))
+		return g.pick(
+			func() interface{} { return uint64(g.Rand.Int63n(smaller(int64(max>>1), 1_000_000_000_000))) },
+			[]interface{}{uint64(0), uint64(1), max - 1, max},
+			[]interface{}{outOfRange(max, 1), "-1", strconv.FormatInt(-1-g.Rand.Int63n(1_000_000), 10)},
+		), nil
+
+	case "int128", "uint128", "float128":
+		random := func() interface{} { return int128(g.Rand.Uint64(), 0) }
+		return g.pick(random,
+			[]interface{}{int128(0, 0), int128(1, 0), int128(math.MaxUint64, math.MaxUint64), int128(math.MaxUint64, math.MaxInt64)},
+			[]interface{}{int128(math.MaxUint64, math.MaxUint64), int128(0, 1<<63)},
+		), nil
+
+	case "float32":
+		return g.pick(
+			func() interface{} { return g.Rand.Float32() * 1000 },
+			[]interface{}{float32(0), float32(math.SmallestNonzeroFloat32), float32(math.MaxFloat32)},
+			[]interface{}{float32(-math.MaxFloat32), float32(-1)},
+		), nil
+	case "float64":
+		return g.pick(
+			func() interface{} { return g.Rand.Float64() * 1_000_000 },
+			[]interface{}{float64(0), math.SmallestNonzeroFloat64, math.MaxFloat64},
+			[]interface{}{-math.MaxFloat64, float64(-1)},
+		), nil
+
+	case "name":
+		return g.pick(
+			func() interface{} { return g.name(1 + g.Rand.Intn(12)) },
+			[]interface{}{"", "a", "zzzzzzzzzzzz", "111111111111", "eosio"},
+			[]interface{}{".", ".abc", "abc.", "a..b", "ABCDEFGH", "abc-def", "6789"},
+		), nil
+
+	case "string":
+		switch g.Mode {
+		case ModeBoundary:
+			return []string{"", " ", "a", strings.Repeat("a", 63), strings.Repeat("a", 64), strings.Repeat("a", 255), strings.Repeat("a", 256)}[g.Rand.Intn(7)], nil
+		case ModeInvalid:
+			// built only when needed, a few of these are large
+			switch g.Rand.Intn(8) {
+			case 0:
+				return strings.Repeat("💩", 2048), nil
+			case 1:
+				return strings.Repeat("a", 65536), nil
+			}
+			return []string{"\x00", "a\x00b", "\u202egnp.exe", "💩💩💩", "\r\n", `"'<>&`}[g.Rand.Intn(6)], nil
+		}
+		return g.name(1 + g.Rand.Intn(32)), nil
+
+	case "bytes":
+		switch g.Mode {
+		case ModeBoundary:
+			return []string{"", "00", strings.Repeat("ff", 256)}[g.Rand.Intn(3)], nil
+		case ModeInvalid:
+			return g.hex(16384 + g.Rand.Intn(49152)), nil
+		}
+		return g.hex(1 + g.Rand.Intn(32)), nil
+
+	case "checksum160", "checksum256", "checksum512":
+		size := map[string]int{"checksum160": 20, "checksum256": 32, "checksum512": 64}[typeName]
+		zero, ff := strings.Repeat("00", size), strings.Repeat("ff", size)
+		return g.pick(func() interface{} { return g.hex(size) }, []interface{}{zero, ff}, []interface{}{zero}), nil
+
+	case "public_key":
+		key, err := ecc.NewDeterministicPrivateKey(g.Rand)
+		if err != nil {
+			return nil, err
+		}
+		return g.pick(
+			func() interface{} { return key.PublicKey().String() },
+			[]interface{}{r1Key(g.bytes(33))},
+			[]interface{}{k1Key(append([]byte{5}, g.bytes(32)...)), k1Key(make([]byte, 33))},
+		), nil
+
+	case "signature":
+		key, err := ecc.NewDeterministicPrivateKey(g.Rand)
+		if err != nil {
+			return nil, err
+		}
+		digest := g.bytes(32)
+		if g.Mode == ModeInvalid {
+			digest = make([]byte, 32)
+		}
+		sig, err := key.Sign(digest)
+		if err != nil {
+			return nil, err
+		}
+		return sig.String(), nil
+
+	case "symbol":
+		return g.pick(
+			func() interface{} { return "9,FIO" },
+			[]interface{}{"0,A", "18,ZZZZZZZ"},
+			[]interface{}{"9,fio", "255,FIO", "4,F-O"},
+		), nil
+
+	case "symbol_code":
+		// FIO as a little endian uint64
+		return g.pick(
+			func() interface{} { return uint64(0x4f4946) },
+			[]interface{}{uint64(0), uint64(math.MaxUint64)},
+			[]interface{}{uint64(0x6f6966)},
+		), nil
+
+	case "asset":
+		return g.pick(
+			func() interface{} { return asset(g.Rand.Int63n(10_000_000_000_000)) },
+			[]interface{}{asset(0), asset(1), asset(1<<62 - 1)},
+			[]interface{}{asset(-1), asset(-1_000_000_000), asset(math.MinInt64)},
+		), nil
+	case "extended_asset":
+		a, _ := g.scalar("asset")
+		n, _ := g.scalar("name")
+		return object{{name: "asset", value: a}, {name: "contract", value: n}}, nil
+
+	case "time_point_sec", "time_point", "block_timestamp_type":
+		layout := map[string]string{
+			"time_point_sec":       "2006-01-02T15:04:05",
+			"time_point":           "2006-01-02T15:04:05.000",
+			"block_timestamp_type": "2006-01-02T15:04:05.000000-07:00",
+		}[typeName]
+		return g.pick(
+			func() interface{} {
+				return time.Now().UTC().Add(time.Duration(g.Rand.Int63n(int64(24*time.Hour))) - 12*time.Hour).Format(layout)
+			},
+			[]interface{}{time.Unix(0, 0).UTC().Format(layout), time.Unix(math.MaxUint32, 0).UTC().Format(layout)},
+			[]interface{}{time.Time{}.Format(layout), time.Date(9999, 12, 31, 23, 59, 59, 0, time.UTC).Format(layout)},
+		), nil
+	}
+	return nil, errors.New("no generator for type " + typeName)
+}
+
+func intBits(typeName string) int {
+	switch {
+	case strings.HasSuffix(typeName, "int8"):
+		return 8
+	case strings.HasSuffix(typeName, "int16"):
+		return 16
+	case strings.HasSuffix(typeName, "int32"):
+		return 32
+	}
+	return 64
+}
+
+func smaller(a int64, b int64) int64 {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func int128(lo uint64, hi uint64) string {
+	j, _ := eos.Uint128{Lo: lo, Hi: hi}.MarshalJSON()
+	return strings.Trim(string(j), `"`)
+}
+
+func asset(amount int64) string {
+	sign := ""
+	if amount < 0 {
+		sign = "-"
+	}
+	abs := uint64(amount)
+	if amount < 0 {
+		abs = uint64(-(amount + 1)) + 1
+	}
+	return fmt.Sprintf("%s%d.%09d FIO", sign, abs/1_000_000_000, abs%1_000_000_000)
+}
+
+// name is a valid eosio name of the length requested, without a trailing dot
+func (g *AbiGen) name(length int) string {
+	const chars = ".12345abcdefghijklmnopqrstuvwxyz"
+	b := make([]byte, length)
+	for i := range b {
+		b[i] = chars[1+g.Rand.Intn(len(chars)-1)]
+		if i > 0 && i < length-1 && g.Rand.Intn(8) == 0 {
+			b[i] = '.'
+		}
+	}
+	return string(b)
+}
+
+func (g *AbiGen) bytes(n int) []byte {
+	b := make([]byte, n)
+	_, _ = g.Rand.Read(b)
+	return b
+}
+
+func (g *AbiGen) hex(n int) string {
+	return hex.EncodeToString(g.bytes(n))
+}
+
+func r1Key(data []byte) string {
+	return ecc.PublicKeyR1Prefix + base58.Encode(append(data, ecc.Ripemd160checksumHashCurve(data, ecc.CurveR1)...))
+}
+
+// k1Key encodes anything 33 bytes long as a FIO public key, it doesn't have to be a point on the curve
+func k1Key(data []byte) string {
+	h := ripemd160.New()
+	_, _ = h.Write(data)
+	return ecc.PublicKeyPrefixCompat + base58.Encode(append(data, h.Sum(nil)[:4]...))
+}
@@ -0,0 +1,440 @@
+package fuzzer
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/fioprotocol/fio-go/eos/btcsuite/btcutil/base58"
+	"github.com/fioprotocol/fio-go/eos/ecc"
+	"math"
+	"strings"
+	"time"
+)
+
+// BoundaryTypes have edge cases in Boundary, it's the types the editor offers plus the newer eosio types.
+var BoundaryTypes = []string{
+	"asset",
+	"authority",
+	"block_timestamp_type",
+	"bool",
+	"byte",
+	"byte[]",
+	"bytes",
+	"checksum160",
+	"checksum256",
+	"checksum512",
+	"extended_asset",
+	"float128",
+	"float32",
+	"float64",
+	"hex_bytes",
+	"int128",
+	"int16",
+	"int32",
+	"int64",
+	"int8",
+	"name",
+	"public_key",
+	"signature",
+	"string",
+	"string[]",
+	"symbol",
+	"symbol_code",
+	"time",
+	"time_point",
+	"time_point_sec",
+	"timestamp",
+	"uint128",
+	"uint16",
+	"uint32",
+	"uint64",
+	"uint8",
+	"varint32",
+	"varuint32",
+}
+
+// boundary is one edge case, abiType is set when the value can't be serialized as the type it's for: an
+// overflow is sent as the next larger int for example.
+type boundary struct {
+	name    string
+	abiType string
+	value   func() interface{}
+}
+
+func fixed(name string, v interface{}) boundary {
+	return boundary{name: name, value: func() interface{} { return v }}
+}
+
+func as(abiType string, name string, v interface{}) boundary {
+	return boundary{name: name, abiType: abiType, value: func() interface{} { return v }}
+}
+
+// BoundaryCases lists the edge cases for a type, the first is the default
+func BoundaryCases(typeName string) []string {
+	cases := boundaries(typeName)
+	names := make([]string, len(cases))
+	for i := range cases {
+		names[i] = cases[i].name
+	}
+	return names
+}
+
+// Boundary builds an edge case as JSON, abiType is what it has to be encoded as, which isn't always typeName.
+func Boundary(typeName string, edge string) (j json.RawMessage, abiType string, err error) {
+	for _, b := range boundaries(typeName) {
+		if b.name != edge {
+			continue
+		}
+		abiType = b.abiType
+		if abiType == "" {
+			abiType = typeName
+		}
+		j, err = json.Marshal(b.value())
+		return j, abiType, err
+	}
+	return nil, "", fmt.Errorf("no edge case %q for %s", edge, typeName)
+}
+
+func boundaries(typeName string) []boundary {
+	switch typeName {
+	case "int8", "int16", "int32", "varint32", "int64":
+		return signedBoundaries(typeName)
+	case "uint8", "uint16", "uint32", "varuint32", "uint64":
+		return unsignedBoundaries(typeName)
+	case "byte":
+		// byte isn't a type the encoder knows, it's a uint8 on the wire
+		cases := unsignedBoundaries("uint8")
+		for i := range cases {
+			if cases[i].abiType == "" {
+				cases[i].abiType = "uint8"
+			}
+		}
+		return cases
+
+	case "int128":
+		return []boundary{
+			fixed("zero", int128(0, 0)),
+			fixed("one", int128(1, 0)),
+			fixed("-1", int128(math.MaxUint64, math.MaxUint64)),
+			fixed("max", int128(math.MaxUint64, math.MaxInt64)),
+			fixed("min", int128(0, 1<<63)),
+			fixed("max int64 + 1", int128(1<<63, 0)),
+			fixed("min int64 - 1", int128(math.MaxInt64, math.MaxUint64)),
+		}
+	case "uint128":
+		return []boundary{
+			fixed("zero", int128(0, 0)),
+			fixed("one", int128(1, 0)),
+			fixed("max", int128(math.MaxUint64, math.MaxUint64)),
+			fixed("max - 1", int128(math.MaxUint64-1, math.MaxUint64)),
+			fixed("max uint64 + 1", int128(0, 1)),
+			as("int128", "-1 (int128)", int128(math.MaxUint64, math.MaxUint64)),
+		}
+	case "float128":
+		// ieee 754 quad precision, little endian
+		return []boundary{
+			fixed("zero", int128(0, 0)),
+			fixed("negative zero", int128(0, 1<<63)),
+			fixed("one", int128(0, 0x3fff000000000000)),
+			fixed("infinity", int128(0, 0x7fff000000000000)),
+			fixed("negative infinity", int128(0, 0xffff000000000000)),
+			fixed("nan", int128(0, 0x7fff800000000000)),
+			fixed("max", int128(math.MaxUint64, 0x7ffeffffffffffff)),
+			fixed("smallest subnormal", int128(1, 0)),
+		}
+	case "float32":
+		return []boundary{
+			fixed("zero", float32(0)),
+			fixed("max", float32(math.MaxFloat32)),
+			fixed("negative max", float32(-math.MaxFloat32)),
+			fixed("smallest", float32(math.SmallestNonzeroFloat32)),
+			fixed("-1", float32(-1)),
+			fixed("largest exact int (2^24)", float32(1<<24)),
+			as("float64", "max x 2 (float64)", float64(math.MaxFloat32)*2),
+		}
+	case "float64":
+		return []boundary{
+			fixed("zero", float64(0)),
+			fixed("max", math.MaxFloat64),
+			fixed("negative max", -math.MaxFloat64),
+			fixed("smallest", math.SmallestNonzeroFloat64),
+			fixed("-1", float64(-1)),
+			fixed("largest exact int (2^53)", float64(1<<53)),
+			fixed("0.1 (inexact)", 0.1),
+		}
+	case "bool":
+		return []boundary{
+			fixed("false", false),
+			fixed("true", true),
+			as("uint8", "2 (uint8)", 2),
+			as("uint8", "255 (uint8)", 255),
+		}
+
+	case "bytes", "byte[]", "hex_bytes":
+		return []boundary{
+			as("bytes", "empty", ""),
+			as("bytes", "one zero byte", "00"),
+			as("bytes", "256 x 0xff", strings.Repeat("ff", 256)),
+			{name: "65,536 random bytes", abiType: "bytes", value: func() interface{} { return randHex(65536) }},
+			{name: "512 KB random bytes", abiType: "bytes", value: func() interface{} { return randHex(512 * 1024) }},
+		}
+	case "checksum160", "checksum256", "checksum512":
+		size := map[string]int{"checksum160": 20, "checksum256": 32, "checksum512": 64}[typeName]
+		return []boundary{
+			fixed("zero", strings.Repeat("00", size)),
+			fixed("0xff", strings.Repeat("ff", size)),
+			{name: "random", value: func() interface{} { return randHex(size) }},
+			{name: "one byte short (bytes)", abiType: "bytes", value: func() interface{} { return randHex(size - 1) }},
+			{name: "one byte long (bytes)", abiType: "bytes", value: func() interface{} { return randHex(size + 1) }},
+		}
+
+	case "name":
+		return []boundary{
+			fixed("empty", ""),
+			fixed("one char", "a"),
+			fixed("max (zzzzzzzzzzzz)", "zzzzzzzzzzzz"),
+			fixed("all dots", "............"),
+			fixed("leading dot", ".abc"),
+			fixed("trailing dot", "abc."),
+			fixed("digits only", "12345"),
+			fixed("twelve ones", "111111111111"),
+			as("string", "13 chars (string)", "abcdefghijklm"),
+			as("string", "upper case (string)", "ABCDEF"),
+			as("string", "invalid chars (string)", "a-b_c@d"),
+		}
+	case "string":
+		return []boundary{
+			fixed("empty", ""),
+			fixed("one space", " "),
+			fixed("null byte", "\x00"),
+			fixed("embedded null", "abc\x00def"),
+			fixed("right to left override", "‮gnp.exe"),
+			fixed("4 byte runes", strings.Repeat("💩", 64)),
+			fixed("control chars", "\r\n\t\b\f"),
+			fixed("json and html", `{"a":"<script>"}'`),
+			{name: "64 KB", value: func() interface{} { return strings.Repeat("a", 65536) }},
+			{name: "invalid utf-8", value: func() interface{} { return string([]byte{0xc3, 0x28, 0xa0, 0xa1}) }},
+		}
+	case "string[]":
+		return []boundary{
+			fixed("empty array", []string{}),
+			fixed("one empty string", []string{""}),
+			fixed("duplicates", []string{"a", "a", "a"}),
+			{name: "1,000 empty strings", value: func() interface{} { return make([]string, 1000) }},
+		}
+
+	case "public_key":
+		return []boundary{
+			{name: "K1", value: func() interface{} { return randPriv().PublicKey().String() }},
+			// the encoder only takes the legacy checksum, so the newer format can only go in a string
+			{name: "K1 PUB_K1_ format (string)", abiType: "string", value: func() interface{} { return pubK1(randPriv().PublicKey().Content) }},
+			{name: "R1", value: func() interface{} { return r1Key(append([]byte{2}, randBytes(32)...)) }},
+			{name: "K1 not on the curve", value: func() interface{} { return k1Key(append([]byte{5}, randBytes(32)...)) }},
+			fixed("K1 all zeros", k1Key(make([]byte, 33))),
+			{name: "bad checksum (string)", abiType: "string", value: func() interface{} {
+				k := randPriv().PublicKey().String()
+				return k[:len(k)-1] + string("12345678"[rng.Intn(8)])
+			}},
+			{name: "EOS prefix (string)", abiType: "string", value: func() interface{} {
+				return "EOS" + strings.TrimPrefix(randPriv().PublicKey().String(), "FIO")
+			}},
+			{name: "truncated (string)", abiType: "string", value: func() interface{} { return randPriv().PublicKey().String()[:40] }},
+			as("string", "empty (string)", ""),
+		}
+	case "signature":
+		return []boundary{
+			{name: "K1", value: func() interface{} {
+				sig, err := randPriv().Sign(randBytes(32))
+				if err != nil {
+					return ""
+				}
+				return sig.String()
+			}},
+			fixed("K1 all zeros", sigK1(make([]byte, 65))),
+			{name: "K1 random bytes", value: func() interface{} { return sigK1(randBytes(65)) }},
+			{name: "bad checksum (string)", abiType: "string", value: func() interface{} {
+				s := sigK1(randBytes(65))
+				return s[:len(s)-1] + string("12345678"[rng.Intn(8)])
+			}},
+			as("string", "empty (string)", ""),
+		}
+
+	case "symbol":
+		return []boundary{
+			fixed("FIO", "9,FIO"),
+			fixed("zero precision", "0,FIO"),
+			fixed("max precision (18)", "18,FIO"),
+			fixed("precision 255", "255,FIO"),
+			fixed("one char", "4,A"),
+			fixed("seven chars", "4,ZZZZZZZ"),
+			fixed("empty code", "9,"),
+		}
+	case "symbol_code":
+		// little endian uint64 of the ascii code
+		return []boundary{
+			fixed("FIO", uint64(0x4f4946)),
+			fixed("zero", uint64(0)),
+			fixed("max", uint64(math.MaxUint64)),
+			fixed("lower case fio", uint64(0x6f6966)),
+			fixed("seven chars", uint64(0x5a5a5a5a5a5a5a)),
+		}
+	case "asset":
+		return assetBoundaries()
+	case "extended_asset":
+		cases := make([]boundary, 0)
+		for _, a := range assetBoundaries() {
+			a := a
+			cases = append(cases, boundary{name: a.name, value: func() interface{} {
+				return map[string]interface{}{"asset": a.value(), "contract": "fio.token"}
+			}})
+		}
+		return append(cases,
+			fixed("empty contract", map[string]interface{}{"asset": asset(1), "contract": ""}),
+			fixed("contract eosio", map[string]interface{}{"asset": asset(1), "contract": "eosio"}),
+		)
+
+	case "time_point_sec", "time_point", "block_timestamp_type":
+		layout := map[string]string{
+			"time_point_sec":       "2006-01-02T15:04:05",
+			"time_point":           "2006-01-02T15:04:05.000",
+			"block_timestamp_type": "2006-01-02T15:04:05.000000-07:00",
+		}[typeName]
+		at := func(t time.Time) string {
+			return t.UTC().Format(layout)
+		}
+		return []boundary{
+			fixed("epoch", at(time.Unix(0, 0))),
+			{name: "now", value: func() interface{} { return at(time.Now()) }},
+			{name: "one second ago", value: func() interface{} { return at(time.Now().Add(-time.Second)) }},
+			{name: "one hour ahead", value: func() interface{} { return at(time.Now().Add(time.Hour)) }},
+			fixed("max uint32 seconds (2106)", at(time.Unix(math.MaxUint32, 0))),
+			fixed("max int32 seconds (2038)", at(time.Unix(math.MaxInt32, 0))),
+			fixed("leap day", at(time.Date(2020, 2, 29, 23, 59, 59, 0, time.UTC))),
+			fixed("year 1", at(time.Time{})),
+			fixed("year 9999", at(time.Date(9999, 12, 31, 23, 59, 59, 0, time.UTC))),
+		}
+	case "time", "timestamp":
+		// the old eosio types are seconds, or half second slots, as a uint32
+		return []boundary{
+			as("uint32", "zero", 0),
+			{name: "now", abiType: "uint32", value: func() interface{} {
+				if typeName == "timestamp" {
+					return (time.Now().UnixNano()/int64(time.Millisecond) - 946684800000) / 500
+				}
+				return time.Now().Unix()
+			}},
+			as("uint32", "max", uint32(math.MaxUint32)),
+			as("uint64", "max + 1 (uint64)", uint64(math.MaxUint32)+1),
+		}
+
+	case "authority":
+		key := func() string { return randPriv().PublicKey().String() }
+		perm := func(actor string, weight int) map[string]interface{} {
+			return map[string]interface{}{"permission": map[string]string{"actor": actor, "permission": "active"}, "weight": weight}
+		}
+		auth := func(threshold int, keys []interface{}, accounts []interface{}) map[string]interface{} {
+			return map[string]interface{}{"threshold": threshold, "keys": keys, "accounts": accounts, "waits": []interface{}{}}
+		}
+		return []boundary{
+			fixed("threshold zero", auth(0, []interface{}{}, []interface{}{perm("eosio", 1)})),
+			fixed("threshold above weights", auth(2, []interface{}{}, []interface{}{perm("eosio", 1)})),
+			fixed("no keys or accounts", auth(1, []interface{}{}, []interface{}{})),
+			fixed("max weight", auth(math.MaxUint16, []interface{}{}, []interface{}{perm("eosio", math.MaxUint16)})),
+			fixed("duplicate accounts", auth(1, []interface{}{}, []interface{}{perm("eosio", 1), perm("eosio", 1)})),
+			fixed("unsorted accounts", auth(1, []interface{}{}, []interface{}{perm("zzz", 1), perm("aaa", 1)})),
+			{name: "duplicate keys", value: func() interface{} {
+				k := key()
+				return auth(1, []interface{}{map[string]interface{}{"key": k, "weight": 1}, map[string]interface{}{"key": k, "weight": 1}}, []interface{}{})
+			}},
+		}
+	}
+	return nil
+}
+
+func signedBoundaries(typeName string) []boundary {
+	bits := intBits(typeName)
+	max := int64(math.MaxInt64 >> uint(64-bits))
+	min := -max - 1
+	cases := []boundary{
+		fixed("min", min),
+		fixed("min + 1", min+1),
+		fixed("-1", -1),
+		fixed("zero", 0),
+		fixed("one", 1),
+		fixed("max - 1", max-1),
+		fixed("max", max),
+	}
+	if bits == 64 {
+		return append(cases,
+			as("uint64", "max + 1 (uint64)", uint64(max)+1),
+			as("int128", "min - 1 (int128)", int128(math.MaxInt64, math.MaxUint64)),
+		)
+	}
+	wider := fmt.Sprintf("int%d", bits*2)
+	return append(cases,
+		as(wider, "max + 1 ("+wider+")", max+1),
+		as(wider, "min - 1 ("+wider+")", min-1),
+	)
+}
+
+func unsignedBoundaries(typeName string) []boundary {
+	bits := intBits(typeName)
+	max := uint64(math.MaxUint64 >> uint(64-bits))
+	signed := fmt.Sprintf("int%d", bits)
+	cases := []boundary{
+		fixed("zero", 0),
+		fixed("one", 1),
+		fixed("max - 1", max-1),
+		fixed("max", max),
+		fixed("max signed", max>>1),
+		fixed("max signed + 1", max>>1+1),
+		as(signed, "-1 ("+signed+")", -1),
+	}
+	if bits == 64 {
+		return append(cases, as("uint128", "max + 1 (uint128)", int128(0, 1)))
+	}
+	wider := fmt.Sprintf("uint%d", bits*2)
+	return append(cases, as(wider, "max + 1 ("+wider+")", max+1))
+}
+
+func assetBoundaries() []boundary {
+	return []boundary{
+		fixed("zero", asset(0)),
+		fixed("smallest (1 SUF)", asset(1)),
+		fixed("max (2^62 - 1)", asset(1<<62-1)),
+		fixed("max + 1", asset(1<<62)),
+		fixed("-1 SUF", asset(-1)),
+		fixed("min int64", asset(math.MinInt64)),
+		fixed("no decimals", "1 FIO"),
+		fixed("too many decimals", "1.0000000001 FIO"),
+		fixed("other symbol", "1.0000 EOS"),
+		fixed("lower case symbol", "1.000000000 fio"),
+	}
+}
+
+func randBytes(n int) []byte {
+	b := make([]byte, n)
+	randRead(b)
+	return b
+}
+
+func randHex(n int) string {
+	return hex.EncodeToString(randBytes(n))
+}
+
+func randPriv() *ecc.PrivateKey {
+	k, err := ecc.NewDeterministicPrivateKey(randReader{})
+	if err != nil {
+		panic(errors.New("could not create a key: " + err.Error()))
+	}
+	return k
+}
+
+func pubK1(data []byte) string {
+	return ecc.PublicKeyK1Prefix + base58.Encode(append(data, ecc.Ripemd160checksumHashCurve(data, ecc.CurveK1)...))
+}
+
+func sigK1(data []byte) string {
+	return "SIG_K1_" + base58.Encode(append(data, ecc.Ripemd160checksumHashCurve(data, ecc.CurveK1)...))
+}
@@ -0,0 +1,162 @@
+package fuzzer
+
+// ChainTokens are the chain codes, and the token codes for each, that wallets commonly map to a FIO address
+var ChainTokens = map[string][]string{
+	"ABBC": {"ABBC"},
+	"ADA":  {"ADA"},
+	"ALGO": {"ALGO"},
+	"ATOM": {"ATOM"},
+	"BAND": {"BAND"},
+	"BCH": {
+		"BCH",
+		"FLEX",
+	},
+	"BHD": {"BHD"},
+	"BNB": {
+		"ANKR",
+		"BNB",
+		"CHZ",
+		"ERD",
+		"ONE",
+		"RUNE",
+		"SWINGBY",
+	},
+	"BSV":  {"BSV"},
+	"BTC":  {"BTC"},
+	"BTM":  {"BTM"},
+	"CET":  {"CET"},
+	"CHX":  {"CHX"},
+	"CKB":  {"CKB"},
+	"DASH": {"DASH"},
+	"DOGE": {"DOGE"},
+	"DOT":  {"DOT"},
+	"EOS":  {"EOS"},
+	"ETC":  {"ETC"},
+	"ETH": {
+		"AERGO",
+		"AKRO",
+		"ALTBEAR",
+		"ALTBULL",
+		"BAND",
+		"BAT",
+		"BEPRO",
+		"BNBBEAR",
+		"BNBBULL",
+		"BOLT",
+		"BTCBEAR",
+		"BTCBULL",
+		"BTMX",
+		"BVOL",
+		"BXA",
+		"CELR",
+		"CET",
+		"CHR",
+		"COTI",
+		"COVA",
+		"CRO",
+		"CVNT",
+		"DAD",
+		"DEEP",
+		"DIA",
+		"DOS",
+		"DREP",
+		"DUO",
+		"ELF",
+		"EOSBEAR",
+		"EOSBULL",
+		"ETH",
+		"ETHBEAR",
+		"ETHBULL",
+		"EXCHBEAR",
+		"EXCHBULL",
+		"FET",
+		"FRM",
+		"FTM",
+		"FTT",
+		"GEEQ",
+		"GT",
+		"HT",
+		"IBVOL",
+		"INFT",
+		"JRT",
+		"KCS",
+		"LAMB",
+		"LAMBS",
+		"LBA",
+		"LFT",
+		"LINK",
+		"LTCBEAR",
+		"LTCBULL",
+		"LTO",
+		"MATIC",
+		"MITX",
+		"MIX",
+		"OKB",
+		"OLT",
+		"OM",
+		"ORN",
+		"PAX",
+		"PROM",
+		"QCX",
+		"RNT",
+		"SEELE",
+		"SLV",
+		"SRM",
+		"STAKE",
+		"STPT",
+		"SWAP",
+		"TOKO",
+		"UAT",
+		"USDC",
+		"USDT",
+		"VALOR",
+		"VRA",
+		"XRPBEAR",
+		"XRPBULL",
+		"ZRX",
+	},
+	"ETZ": {"ETZ"},
+	"FIAT": {
+		"ACH",
+		"IBAN",
+	},
+	"FIO":  {"FIO"},
+	"FSN":  {"FSN"},
+	"HPB":  {"HPB"},
+	"IOST": {"IOST"},
+	"KAVA": {"KAVA"},
+	"LTC":  {"LTC"},
+	"LTO":  {"LTO"},
+	"MHC":  {"MHC"},
+	"NEO": {
+		"GAS",
+		"NEO",
+	},
+	"OLT":  {"OLT"},
+	"OMNI": {"USDT"},
+	"ONE":  {"ONE"},
+	"ONT": {"ONG",
+		"ONT"},
+	"QTUM": {"QTUM"},
+	"RVN":  {"RVN"},
+	"SOL":  {"SOL"},
+	"TRX": {
+		"BTT",
+		"TRX",
+		"USDT",
+	},
+	"VET": {"VET"},
+	"WAN": {
+		"RVX",
+		"WAN",
+	},
+	"XEM": {"XEM"},
+	"XLM": {"XLM"},
+	"XMR": {"XMR"},
+	"XNS": {"XNS"},
+	"XRP": {"XRP"},
+	"XTZ": {"XTZ"},
+	"YAP": {"YAP"},
+	"ZEC": {"ZEC"},
+	"ZIL": {"ZIL"},
+}
@@ -0,0 +1,174 @@
+package fuzzer
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// FileFormats are how a file is split into values
+var FileFormats = []string{
+	"wordlist",
+	"jsonl",
+	"csv column",
+}
+
+// FileOrders are how values are picked. Sequential uses the iteration so the whole run walks the file once,
+// partitioned gives each worker its own slice of the file, random is seeded like the other generators.
+var FileOrders = []string{
+	"sequential",
+	"random",
+	"partitioned",
+}
+
+// ErrExhausted is returned once every value in a file has been used, runs stop when they see it
+var ErrExhausted = errors.New("file exhausted")
+
+type loadedFile struct {
+	values  []string
+	modTime time.Time
+	size    int64
+}
+
+var (
+	filesMux sync.Mutex
+	files    = make(map[string]*loadedFile)
+)
+
+// FromFile returns the next value from a file. For a csv the column goes after the path, by name if the
+// file has a header row ("bad.csv#address") or by number, starting at 0, if it doesn't ("bad.csv#2"). Jsonl
+// values are returned as json, the others as plain strings.
+func FromFile(spec string, format string, order string) (string, error) {
+	values, err := fileValues(spec, format)
+	if err != nil {
+		return "", err
+	}
+	if len(values) == 0 {
+		return "", errors.New(spec + " has no values")
+	}
+	iteration, w := current()
+	i := 0
+	switch order {
+	case "random":
+		i = rng.Intn(len(values))
+	case "partitioned":
+		per := (len(values) + w.Count - 1) / w.Count
+		i = w.Id*per + w.Sent
+		if w.Sent >= per || i >= len(values) {
+			return "", fmt.Errorf("%w: worker %d of %d used its %d values from %s", ErrExhausted, w.Id+1, w.Count, per, spec)
+		}
+	case "sequential", "":
+		i = iteration
+		if i >= len(values) {
+			return "", fmt.Errorf("%w: all %d values from %s were used", ErrExhausted, len(values), spec)
+		}
+	default:
+		return "", fmt.Errorf("unknown file order %q", order)
+	}
+	return values[i], nil
+}
+
+// fileValues reads a file once, and again if it changes
+func fileValues(spec string, format string) ([]string, error) {
+	path, column := spec, ""
+	if format == "csv column" {
+		if i := strings.LastIndex(spec, "#"); i > 0 {
+			path, column = spec[:i], spec[i+1:]
+		}
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	filesMux.Lock()
+	defer filesMux.Unlock()
+	key := format + "\x00" + spec
+	if f := files[key]; f != nil && f.modTime.Equal(info.ModTime()) && f.size == info.Size() {
+		return f.values, nil
+	}
+	body, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var values []string
+	switch format {
+	case "wordlist":
+		values = lines(body)
+	case "jsonl":
+		for n, l := range lines(body) {
+			if !json.Valid([]byte(l)) {
+				return nil, fmt.Errorf("%s line %d is not valid json", path, n+1)
+			}
+			values = append(values, l)
+		}
+	case "csv column":
+		if values, err = csvColumn(body, column); err != nil {
+			return nil, fmt.Errorf("%s: %s", path, err)
+		}
+	default:
+		return nil, fmt.Errorf("unknown file format %q", format)
+	}
+	Notify(fmt.Sprintf("loaded %d values from %s", len(values), path))
+	files[key] = &loadedFile{values: values, modTime: info.ModTime(), size: info.Size()}
+	return values, nil
+}
+
+// lines skips empty lines, everything else (including leading and trailing spaces) is kept
+func lines(body []byte) []string {
+	out := make([]string, 0)
+	s := bufio.NewScanner(bytes.NewReader(body))
+	s.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for s.Scan() {
+		l := strings.TrimSuffix(s.Text(), "\r")
+		if l != "" {
+			out = append(out, l)
+		}
+	}
+	return out
+}
+
+func csvColumn(body []byte, column string) ([]string, error) {
+	r := csv.NewReader(bytes.NewReader(body))
+	r.FieldsPerRecord = -1
+	if column == "" {
+		column = "0"
+	}
+	col, err := strconv.Atoi(column)
+	header := err != nil
+	out := make([]string, 0)
+	for row := 0; ; row++ {
+		rec, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if header && row == 0 {
+			col = -1
+			for i := range rec {
+				if strings.TrimSpace(rec[i]) == column {
+					col = i
+				}
+			}
+			if col < 0 {
+				return nil, fmt.Errorf("no column named %q", column)
+			}
+			continue
+		}
+		if col < len(rec) {
+			out = append(out, rec[col])
+		}
+	}
+	return out, nil
+}
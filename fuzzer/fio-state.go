@@ -0,0 +1,312 @@
+package fuzzer
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/fioprotocol/fio-go"
+	"github.com/fioprotocol/fio-go/eos"
+	"sort"
+	"sync"
+	"time"
+)
+
+// FioState is what the chain aware generators pick from: domains, addresses, balances and pending requests.
+// It's read once per node and kept for the session, querying the tables for every value would be far slower
+// than sending the transactions.
+type FioState struct {
+	Loaded    time.Time
+	Domains   []StateDomain
+	Addresses []StateAddress
+	// Funded and Unfunded are accounts that own a FIO address or domain, split by whether they hold any FIO
+	Funded   []string
+	Unfunded []string
+	// Requests are funds requests without a response
+	Requests []StateRequest
+}
+
+type StateDomain struct {
+	Name       string `json:"name"`
+	Account    string `json:"account"`
+	IsPublic   uint8  `json:"is_public"`
+	Expiration int64  `json:"expiration"`
+}
+
+func (d StateDomain) Expired() bool {
+	return time.Unix(d.Expiration, 0).Before(time.Now())
+}
+
+type StateAddress struct {
+	Name       string `json:"name"`
+	Domain     string `json:"domain"`
+	Owner      string `json:"owner_account"`
+	Expiration int64  `json:"expiration"`
+	Bundle     int64  `json:"bundleeligiblecountdown"`
+}
+
+type StateRequest struct {
+	Id       uint64 `json:"fio_request_id"`
+	Payer    string `json:"payer_fio_addr"`
+	PayerKey string `json:"payer_key"`
+	Payee    string `json:"payee_fio_addr"`
+	PayeeKey string `json:"payee_key"`
+}
+
+// stateRows is how many rows are read from each table, and stateBalances how many owners have their balance checked
+const (
+	stateRows     = 1000
+	stateBalances = 100
+)
+
+// stateLoad is one read of a node's tables, callers that need the same node while it's loading wait on done
+type stateLoad struct {
+	done  chan struct{}
+	state *FioState
+}
+
+var (
+	stateMux sync.Mutex
+	states   = make(map[string]*stateLoad)
+)
+
+// ChainState returns the cached state for a node, loading it the first time. Only the callers for the same
+// node wait for the load. If the node can't be reached nothing is cached, so the next call tries again.
+func ChainState(url string) *FioState {
+	stateMux.Lock()
+	l := states[url]
+	if l == nil {
+		l = &stateLoad{done: make(chan struct{})}
+		states[url] = l
+		stateMux.Unlock()
+		l.load(url)
+	} else {
+		stateMux.Unlock()
+	}
+	<-l.done
+	return l.state
+}
+
+func (l *stateLoad) load(url string) {
+	defer close(l.done)
+	Notify("reading domains, addresses, balances and requests from the chain, this is only done once")
+	s, err := loadChainState(url)
+	if err != nil {
+		Notify(err.Error())
+		l.state = &FioState{}
+		stateMux.Lock()
+		if states[url] == l {
+			delete(states, url)
+		}
+		stateMux.Unlock()
+		return
+	}
+	Notify(fmt.Sprintf("found %d domains, %d addresses, %d funded and %d unfunded owners, %d pending requests",
+		len(s.Domains), len(s.Addresses), len(s.Funded), len(s.Unfunded), len(s.Requests)))
+	l.state = s
+}
+
+// ForgetChainState drops the cached state, the next generator to need it reads the tables again. It's called
+// when the wallet connects or switches accounts so a session never picks from another session's state.
+func ForgetChainState() {
+	stateMux.Lock()
+	states = make(map[string]*stateLoad)
+	stateMux.Unlock()
+}
+
+func loadChainState(url string) (*FioState, error) {
+	api, _, err := fio.NewConnection(nil, url)
+	if err != nil {
+		return nil, err
+	}
+	api.Header.Set("User-Agent", "fio-cryptonym-wallet")
+	s := &FioState{Loaded: time.Now()}
+
+	rows := func(code string, table string, into interface{}) {
+		gtr, err := api.GetTableRows(eos.GetTableRowsRequest{
+			Code:  code,
+			Scope: code,
+			Table: table,
+			Limit: stateRows,
+			JSON:  true,
+		})
+		if err != nil {
+			Notify(table + ": " + err.Error())
+			return
+		}
+		if err = json.Unmarshal(gtr.Rows, into); err != nil {
+			Notify(table + ": " + err.Error())
+		}
+	}
+	rows("fio.address", "domains", &s.Domains)
+	rows("fio.address", "fionames", &s.Addresses)
+
+	// requests with a status row have been rejected, cancelled or paid
+	requests := make([]StateRequest, 0)
+	statuses := make([]struct {
+		Id uint64 `json:"fio_request_id"`
+	}, 0)
+	rows("fio.reqobt", "fioreqctxts", &requests)
+	rows("fio.reqobt", "fioreqstss", &statuses)
+	answered := make(map[uint64]bool)
+	for _, st := range statuses {
+		answered[st.Id] = true
+	}
+	for _, r := range requests {
+		if !answered[r.Id] {
+			s.Requests = append(s.Requests, r)
+		}
+	}
+
+	owners := make(map[string]bool)
+	for _, d := range s.Domains {
+		owners[d.Account] = true
+	}
+	for _, a := range s.Addresses {
+		owners[a.Owner] = true
+	}
+	accounts := make([]string, 0, len(owners))
+	for o := range owners {
+		if o != "" {
+			accounts = append(accounts, o)
+		}
+	}
+	sort.Strings(accounts)
+	if len(accounts) > stateBalances {
+		accounts = accounts[:stateBalances]
+	}
+	for _, a := range accounts {
+		bal, err := api.GetCurrencyBalance(eos.AccountName(a), "FIO", "fio.token")
+		if err != nil {
+			continue
+		}
+		if len(bal) > 0 && bal[0].Amount > 0 {
+			s.Funded = append(s.Funded, a)
+			continue
+		}
+		s.Unfunded = append(s.Unfunded, a)
+	}
+	return s, nil
+}
+
+func domainWhere(url string, what string, ok func(d StateDomain) bool) string {
+	matches := make([]string, 0)
+	for _, d := range ChainState(url).Domains {
+		if ok(d) {
+			matches = append(matches, d.Name)
+		}
+	}
+	if len(matches) == 0 {
+		Notify("did not find any " + what + " on chain")
+		return ""
+	}
+	return matches[rng.Intn(len(matches))]
+}
+
+func addressWhere(url string, what string, ok func(a StateAddress) bool) string {
+	matches := make([]string, 0)
+	for _, a := range ChainState(url).Addresses {
+		if ok(a) {
+			matches = append(matches, a.Name)
+		}
+	}
+	if len(matches) == 0 {
+		Notify("did not find any " + what + " on chain")
+		return ""
+	}
+	return matches[rng.Intn(len(matches))]
+}
+
+func ExpiredDomain(url string) string {
+	return domainWhere(url, "expired domains", func(d StateDomain) bool {
+		return d.Expired()
+	})
+}
+
+func ActiveDomain(url string) string {
+	return domainWhere(url, "active domains", func(d StateDomain) bool {
+		return !d.Expired()
+	})
+}
+
+func PublicDomain(url string) string {
+	return domainWhere(url, "public domains", func(d StateDomain) bool {
+		return d.IsPublic == 1 && !d.Expired()
+	})
+}
+
+// PrivateDomain only its owner can register addresses on
+func PrivateDomain(url string) string {
+	return domainWhere(url, "private domains", func(d StateDomain) bool {
+		return d.IsPublic == 0 && !d.Expired()
+	})
+}
+
+// OthersDomain is owned by an account other than actor
+func OthersDomain(url string, actor string) string {
+	return domainWhere(url, "domains owned by someone else", func(d StateDomain) bool {
+		return d.Account != actor && !d.Expired()
+	})
+}
+
+// OthersAddress is owned by an account other than actor
+func OthersAddress(url string, actor string) string {
+	return addressWhere(url, "addresses owned by someone else", func(a StateAddress) bool {
+		return a.Owner != actor
+	})
+}
+
+// ZeroBundleAddress has used all of its free transactions, so everything it does needs a fee
+func ZeroBundleAddress(url string) string {
+	return addressWhere(url, "addresses without bundled transactions", func(a StateAddress) bool {
+		return a.Bundle == 0
+	})
+}
+
+func FundedActor(url string) string {
+	s := ChainState(url)
+	if len(s.Funded) == 0 {
+		Notify("did not find any accounts with a balance on chain")
+		return ""
+	}
+	return s.Funded[rng.Intn(len(s.Funded))]
+}
+
+// UnfundedActor owns a name but has no FIO, when there aren't any a new random actor is used instead
+func UnfundedActor(url string) string {
+	s := ChainState(url)
+	if len(s.Unfunded) == 0 {
+		return string(RandomActor())
+	}
+	return s.Unfunded[rng.Intn(len(s.Unfunded))]
+}
+
+// OthersPendingRequest is a request id where pubKey is not the payer, so responding to it should fail
+func OthersPendingRequest(url string, pubKey string) (id uint64, found bool) {
+	ids := make([]uint64, 0)
+	for _, r := range ChainState(url).Requests {
+		if r.PayerKey != pubKey {
+			ids = append(ids, r.Id)
+		}
+	}
+	if len(ids) == 0 {
+		Notify("did not find any pending requests for someone else on chain")
+		return 0, false
+	}
+	return ids[rng.Intn(len(ids))], true
+}
+
+// ChainCode is a chain code from ChainTokens
+func ChainCode() string {
+	codes := make([]string, 0, len(ChainTokens))
+	for k := range ChainTokens {
+		codes = append(codes, k)
+	}
+	// map order is random, sorting keeps it repeatable with a seed
+	sort.Strings(codes)
+	return codes[rng.Intn(len(codes))]
+}
+
+// TokenCode is a token code from ChainTokens, it may belong to any chain
+func TokenCode() string {
+	tokens := ChainTokens[ChainCode()]
+	return tokens[rng.Intn(len(tokens))]
+}
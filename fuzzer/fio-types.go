@@ -3,10 +3,9 @@ package fuzzer
 import (
 	"encoding/json"
 	"fmt"
-	errs "github.com/blockpane/cryptonym/errLog"
 	"github.com/fioprotocol/fio-go"
 	"github.com/fioprotocol/fio-go/eos"
-	"math/rand"
+	"github.com/fioprotocol/fio-go/eos/ecc"
 	"sort"
 	"strings"
 )
@@ -48,17 +47,17 @@ func MaxProducerUrl() string {
 }
 
 func MaxVoteProducers(url string) interface{} {
-	errs.ErrChan <- "for this to be effective, you may need to register a lot of producers with long names"
-	errs.ErrChan <- "querying producers table to find up to 30 producers, with the longest fio addresses."
+	Notify("for this to be effective, you may need to register a lot of producers with long names")
+	Notify("querying producers table to find up to 30 producers, with the longest fio addresses.")
 	api, _, err := fio.NewConnection(nil, url)
 	if err != nil {
-		errs.ErrChan <- err.Error()
+		Notify(err.Error())
 		return []string{""}
 	}
 	api.Header.Set("User-Agent", "fio-cryptonym-wallet")
 	producers, err := api.GetFioProducers()
 	if err != nil {
-		errs.ErrChan <- err.Error()
+		Notify(err.Error())
 		return []string{""}
 	}
 	bpFioNames := make([]string, 0)
@@ -70,7 +69,7 @@ func MaxVoteProducers(url string) interface{} {
 		return len(bpFioNames[i]) > len(bpFioNames[j])
 	})
 	if len(producers.Producers) < 30 {
-		errs.ErrChan <- fmt.Sprintf("only found %d producers", len(producers.Producers))
+		Notify(fmt.Sprintf("only found %d producers", len(producers.Producers)))
 		return bpFioNames
 	}
 	return bpFioNames[:30]
@@ -81,10 +80,19 @@ type fioNamesResp struct {
 }
 
 func RandomExistingFioAddress(url string) string {
+	names := ExistingFioAddresses(url)
+	if len(names) == 0 {
+		return ""
+	}
+	return names[rng.Intn(len(names))]
+}
+
+// ExistingFioAddresses is up to 500 registered addresses
+func ExistingFioAddresses(url string) []string {
 	api, _, err := fio.NewConnection(nil, url)
 	if err != nil {
-		errs.ErrChan <- err.Error()
-		return ""
+		Notify(err.Error())
+		return nil
 	}
 	api.Header.Set("User-Agent", "fio-cryptonym-wallet")
 	gtr, err := api.GetTableRows(eos.GetTableRowsRequest{
@@ -96,19 +104,29 @@ func RandomExistingFioAddress(url string) string {
 		JSON:       true,
 	})
 	if err != nil {
-		errs.ErrChan <- err.Error()
-		return ""
+		Notify(err.Error())
+		return nil
 	}
 	names := make([]fioNamesResp, 0)
-	err = json.Unmarshal(gtr.Rows, &names)
-	if len(names) == 0 {
-		return ""
+	_ = json.Unmarshal(gtr.Rows, &names)
+	out := make([]string, len(names))
+	for i := range names {
+		out[i] = names[i].Name
+	}
+	return out
+}
+
+// randomAccount uses the fuzzer source so seeded keys are the same each time
+func randomAccount() (*fio.Account, error) {
+	pk, err := ecc.NewDeterministicPrivateKey(randReader{})
+	if err != nil {
+		return nil, err
 	}
-	return names[rand.Intn(len(names))].Name
+	return fio.NewAccountFromWif(pk.String())
 }
 
 func RandomActor() eos.AccountName {
-	k, err := fio.NewRandomAccount()
+	k, err := randomAccount()
 	if err != nil {
 		nonBlockErr(err.Error())
 		return ""
@@ -118,7 +136,7 @@ func RandomActor() eos.AccountName {
 }
 
 func RandomFioPubKey() string {
-	k, err := fio.NewRandomAccount()
+	k, err := randomAccount()
 	if err != nil {
 		nonBlockErr(err.Error())
 		return ""
@@ -141,7 +159,7 @@ func InvalidFioAddressAt(domain string) string {
 	if !strings.HasPrefix(domain, "@") {
 		domain = "@" + domain
 	}
-	return word() + string(badChars[rand.Intn(len(badChars))]) + word() + domain
+	return word() + string(badChars[rng.Intn(len(badChars))]) + word() + domain
 }
 
 func FioDomain() string {
@@ -149,14 +167,14 @@ func FioDomain() string {
 }
 
 func InvalidFioDomain() string {
-	frontMiddleEnd := rand.Intn(3)
+	frontMiddleEnd := rng.Intn(3)
 	switch frontMiddleEnd {
 	case 0:
-		return string(badChars[rand.Intn(len(badChars))]) + word()
+		return string(badChars[rng.Intn(len(badChars))]) + word()
 	case 1:
-		return word() + string(badChars[rand.Intn(len(badChars))]) + word()
+		return word() + string(badChars[rng.Intn(len(badChars))]) + word()
 	case 2:
-		return word() + string(badChars[rand.Intn(len(badChars))])
+		return word() + string(badChars[rng.Intn(len(badChars))])
 	}
-	return word() + string(badChars[rand.Intn(len(badChars))]) + word()
+	return word() + string(badChars[rng.Intn(len(badChars))]) + word()
 }
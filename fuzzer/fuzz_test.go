@@ -2,11 +2,20 @@ package fuzzer
 
 import (
 	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"github.com/fioprotocol/fio-go"
+	"github.com/fioprotocol/fio-go/eos"
+	"io/ioutil"
+	"math/big"
 	"math/rand"
+	"os"
+	"path/filepath"
 	"strings"
+	"sync"
 	"testing"
+	"time"
 )
 
 func TestRandomString(t *testing.T) {
@@ -96,3 +105,416 @@ func TestSignatureFor(t *testing.T) {
 		}
 	}
 }
+
+func TestAbiGen(t *testing.T) {
+	abi := &eos.ABI{}
+	err := json.Unmarshal([]byte(`{
+		"version": "eosio::abi/1.1",
+		"types": [{"new_type_name": "amount", "type": "uint64"}],
+		"structs": [
+			{"name": "base", "base": "", "fields": [{"name": "owner", "type": "name"}]},
+			{"name": "endpoint", "base": "", "fields": [
+				{"name": "url", "type": "string"},
+				{"name": "port", "type": "uint16"},
+				{"name": "weight", "type": "float64?"}
+			]},
+			{"name": "register", "base": "base", "fields": [
+				{"name": "key", "type": "public_key"},
+				{"name": "fee", "type": "amount"},
+				{"name": "quantity", "type": "asset"},
+				{"name": "expires", "type": "time_point_sec"},
+				{"name": "hash", "type": "checksum256"},
+				{"name": "big", "type": "uint128"},
+				{"name": "endpoints", "type": "endpoint[]"},
+				{"name": "primary", "type": "endpoint?"},
+				{"name": "memo", "type": "string$"}
+			]},
+			{"name": "choose", "base": "", "fields": [{"name": "either", "type": "choice"}]}
+		],
+		"variants": [{"name": "choice", "types": ["uint8", "endpoint"]}],
+		"actions": [
+			{"name": "register", "type": "register", "ricardian_contract": ""},
+			{"name": "choose", "type": "choose", "ricardian_contract": ""}
+		]
+	}`), abi)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, mode := range ModeNames {
+		m, err := ParseMode(mode)
+		if err != nil {
+			t.Fatal(err)
+		}
+		g := NewAbiGen(abi, m)
+		for i := 0; i < 20; i++ {
+			j, err := g.Action("register")
+			if err != nil {
+				t.Fatal(mode, err)
+			}
+			// invalid ints are out of range, the encoder refuses them
+			if _, err = abi.EncodeAction("register", j); err != nil && m != ModeInvalid {
+				t.Error(mode, err, string(j))
+			}
+			// the encoder doesn't do variants, check they are [type, value]
+			j, err = g.Action("choose")
+			if err != nil {
+				t.Fatal(mode, err)
+			}
+			v := struct {
+				Either []json.RawMessage `json:"either"`
+			}{}
+			if err = json.Unmarshal(j, &v); err != nil || len(v.Either) != 2 {
+				t.Error(mode, "bad variant", string(j))
+			}
+		}
+	}
+	invalid := NewAbiGen(abi, ModeInvalid)
+	for _, typeName := range []string{"uint8", "uint16", "uint64", "int8", "int64"} {
+		for i := 0; i < 20; i++ {
+			v, _ := invalid.scalar(typeName)
+			s, _ := v.(string)
+			n, ok := new(big.Int).SetString(s, 10)
+			if !ok {
+				t.Fatalf("%s: expected a number as a string, got %v", typeName, v)
+			}
+			bits := intBits(typeName)
+			min, max := big.NewInt(0), new(big.Int).Lsh(big.NewInt(1), uint(bits))
+			if typeName[0] == 'i' {
+				min = new(big.Int).Neg(new(big.Int).Lsh(big.NewInt(1), uint(bits-1)))
+				max = new(big.Int).Lsh(big.NewInt(1), uint(bits-1))
+			}
+			if n.Cmp(min) >= 0 && n.Cmp(max) < 0 {
+				t.Errorf("%s: %s is in range", typeName, s)
+			}
+		}
+	}
+
+	if _, err := NewAbiGen(abi, ModeValid).Action("nope"); err == nil {
+		t.Error("expected an error for a missing action")
+	}
+	if _, err := ParseMode("nope"); err == nil {
+		t.Error("expected an error for an unknown mode")
+	}
+}
+
+func TestBoundary(t *testing.T) {
+	authority := `{"name": "authority", "base": "", "fields": [
+		{"name": "threshold", "type": "uint32"},
+		{"name": "keys", "type": "key_weight[]"},
+		{"name": "accounts", "type": "permission_level_weight[]"},
+		{"name": "waits", "type": "wait_weight[]"}
+	]},
+	{"name": "key_weight", "base": "", "fields": [{"name": "key", "type": "public_key"}, {"name": "weight", "type": "uint16"}]},
+	{"name": "permission_level", "base": "", "fields": [{"name": "actor", "type": "name"}, {"name": "permission", "type": "name"}]},
+	{"name": "permission_level_weight", "base": "", "fields": [{"name": "permission", "type": "permission_level"}, {"name": "weight", "type": "uint16"}]},
+	{"name": "wait_weight", "base": "", "fields": [{"name": "wait_sec", "type": "uint32"}, {"name": "weight", "type": "uint16"}]}`
+	for _, typeName := range BoundaryTypes {
+		cases := BoundaryCases(typeName)
+		if len(cases) == 0 {
+			t.Error("no edge cases for", typeName)
+		}
+		for _, edge := range cases {
+			j, abiType, err := Boundary(typeName, edge)
+			if err != nil {
+				t.Error(typeName, edge, err)
+				continue
+			}
+			abi := &eos.ABI{}
+			err = json.Unmarshal([]byte(`{"version": "eosio::abi/1.1", "structs": [`+authority+`,
+				{"name": "edge", "base": "", "fields": [{"name": "v", "type": "`+abiType+`"}]}],
+				"actions": [{"name": "edge", "type": "edge", "ricardian_contract": ""}]}`), abi)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err = abi.EncodeAction("edge", []byte(`{"v":`+string(j)+`}`)); err != nil {
+				t.Errorf("%s %q as %s did not encode: %s", typeName, edge, abiType, err)
+			}
+		}
+	}
+	if _, _, err := Boundary("int8", "not an edge case"); err == nil {
+		t.Error("expected an error for an unknown edge case")
+	}
+}
+
+// setChainState caches a state as if it was read from url
+func setChainState(url string, s *FioState) {
+	l := &stateLoad{done: make(chan struct{}), state: s}
+	close(l.done)
+	stateMux.Lock()
+	states[url] = l
+	stateMux.Unlock()
+}
+
+func TestChainStateUnreachable(t *testing.T) {
+	const url = "http://127.0.0.1:1"
+	wg := sync.WaitGroup{}
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if s := ChainState(url); s == nil || len(s.Domains) != 0 {
+				t.Error("expected an empty state for an unreachable node")
+			}
+		}()
+	}
+	wg.Wait()
+	stateMux.Lock()
+	defer stateMux.Unlock()
+	if states[url] != nil {
+		t.Error("a failed load should not be cached")
+	}
+}
+
+func TestFioState(t *testing.T) {
+	const url = "http://state.test"
+	setChainState(url, &FioState{
+		Domains: []StateDomain{
+			{Name: "mine", Account: "me", IsPublic: 1, Expiration: time.Now().Add(time.Hour).Unix()},
+			{Name: "theirs", Account: "them", IsPublic: 0, Expiration: time.Now().Add(time.Hour).Unix()},
+			{Name: "old", Account: "them", IsPublic: 1, Expiration: 1},
+		},
+		Addresses: []StateAddress{
+			{Name: "a@mine", Owner: "me", Bundle: 100},
+			{Name: "b@theirs", Owner: "them", Bundle: 0},
+		},
+		Funded:   []string{"me"},
+		Unfunded: []string{"them"},
+		Requests: []StateRequest{{Id: 1, PayerKey: "FIOme"}, {Id: 2, PayerKey: "FIOthem"}},
+	})
+	defer ForgetChainState()
+
+	for i := 0; i < 20; i++ {
+		if d := OthersDomain(url, "me"); d != "theirs" {
+			t.Error("expected theirs, got", d)
+		}
+		if d := ExpiredDomain(url); d != "old" {
+			t.Error("expected old, got", d)
+		}
+		if d := PublicDomain(url); d != "mine" {
+			t.Error("expected mine, got", d)
+		}
+		if d := PrivateDomain(url); d != "theirs" {
+			t.Error("expected theirs, got", d)
+		}
+		if a := OthersAddress(url, "me"); a != "b@theirs" {
+			t.Error("expected b@theirs, got", a)
+		}
+		if a := ZeroBundleAddress(url); a != "b@theirs" {
+			t.Error("expected b@theirs, got", a)
+		}
+		if a := UnfundedActor(url); a != "them" {
+			t.Error("expected them, got", a)
+		}
+		if id, ok := OthersPendingRequest(url, "FIOme"); !ok || id != 2 {
+			t.Error("expected request 2, got", id)
+		}
+		chain := ChainCode()
+		if ChainTokens[chain] == nil {
+			t.Error("unknown chain code", chain)
+		}
+		if TokenCode() == "" {
+			t.Error("empty token code")
+		}
+	}
+}
+
+func TestContent(t *testing.T) {
+	sender, err := randomAccount()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, kind := range ContentKinds {
+		for _, edge := range ContentCases {
+			c, err := Content(sender, "", kind, edge)
+			if err != nil {
+				t.Error(kind, edge, err)
+			}
+			if edge == "inner fields fill the max length" && (len(c) > contentMax[kind] || len(c) < contentMax[kind]-24) {
+				t.Errorf("%s content should be close to %d, got %d", kind, contentMax[kind], len(c))
+			}
+		}
+		c, _ := Content(sender, "", kind, "valid")
+		obt := fio.ObtRequestType
+		if kind == "recordobt" {
+			obt = fio.ObtResponseType
+		}
+		if _, err = fio.DecryptContent(sender, sender.PubKey, c, obt); err != nil {
+			t.Error(kind, "valid content did not decrypt:", err)
+		}
+		c, _ = Content(sender, "", kind, "bad hmac")
+		if _, err = fio.DecryptContent(sender, sender.PubKey, c, obt); err == nil {
+			t.Error(kind, "bad hmac should not decrypt")
+		}
+	}
+	if _, err = Content(&fio.Account{}, "", "newfundsreq", "valid"); err == nil {
+		t.Error("expected an error without a private key")
+	}
+}
+
+func TestFromFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cryptonym")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	write := func(name string, body string) string {
+		path := filepath.Join(dir, name)
+		if err := ioutil.WriteFile(path, []byte(body), 0600); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	words := write("words.txt", "one\r\n\ntwo\n three\n")
+	jsonl := write("corpus.jsonl", `{"a":1}`+"\n"+`["b"]`+"\n")
+	csv := write("bad.csv", "name,address\nx,a@b\ny,\"c,d@e\"\n")
+
+	next := func(spec string, format string, order string, iteration int, w Worker) (v string, err error) {
+		err = WithWorker(1, iteration, w, func() error {
+			v, err = FromFile(spec, format, order)
+			return err
+		})
+		return
+	}
+	for i, want := range []string{"one", "two", " three"} {
+		if v, err := next(words, "wordlist", "sequential", i, Worker{Count: 1}); err != nil || v != want {
+			t.Errorf("expected %q, got %q %v", want, v, err)
+		}
+	}
+	if _, err = next(words, "wordlist", "sequential", 3, Worker{Count: 1}); !errors.Is(err, ErrExhausted) {
+		t.Error("expected the wordlist to be exhausted, got", err)
+	}
+	if v, err := next(jsonl, "jsonl", "sequential", 1, Worker{Count: 1}); err != nil || v != `["b"]` {
+		t.Error("unexpected jsonl value", v, err)
+	}
+	if v, err := next(csv+"#address", "csv column", "sequential", 1, Worker{Count: 1}); err != nil || v != "c,d@e" {
+		t.Error("unexpected csv value", v, err)
+	}
+	if v, err := next(csv+"#0", "csv column", "sequential", 0, Worker{Count: 1}); err != nil || v != "name" {
+		t.Error("unexpected csv value", v, err)
+	}
+	if _, err = next(csv+"#nope", "csv column", "sequential", 0, Worker{Count: 1}); err == nil {
+		t.Error("expected an error for a missing column")
+	}
+
+	// two workers split three words: the first gets two, the second one
+	if v, _ := next(words, "wordlist", "partitioned", 0, Worker{Id: 1, Count: 2}); v != " three" {
+		t.Error("second worker should start at the third word, got", v)
+	}
+	if _, err = next(words, "wordlist", "partitioned", 0, Worker{Id: 1, Count: 2, Sent: 1}); !errors.Is(err, ErrExhausted) {
+		t.Error("expected the second worker to be exhausted, got", err)
+	}
+	a, _ := next(words, "wordlist", "random", 7, Worker{Count: 1})
+	b, _ := next(words, "wordlist", "random", 7, Worker{Count: 1})
+	if a != b {
+		t.Error("random order should repeat with the same seed")
+	}
+}
+
+// TestFromFileConcurrent is for go test -race, workers read a file while others are seeding
+func TestFromFileConcurrent(t *testing.T) {
+	f, err := ioutil.TempFile("", "cryptonym")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+	_, _ = f.WriteString("one\ntwo\nthree\nfour\n")
+	f.Close()
+
+	wg := sync.WaitGroup{}
+	for w := 0; w < 4; w++ {
+		wg.Add(2)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < 50; i++ {
+				_ = WithWorker(1, i%4, Worker{Id: w, Count: 4}, func() error {
+					_, err := FromFile(f.Name(), "wordlist", "sequential")
+					return err
+				})
+			}
+		}(w)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 50; i++ {
+				if _, err := FromFile(f.Name(), "wordlist", "partitioned"); err != nil && !errors.Is(err, ErrExhausted) {
+					t.Error(err)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestWithSeed(t *testing.T) {
+	abi := &eos.ABI{}
+	if err := json.Unmarshal([]byte(`{"structs":[{"name":"s","fields":[{"name":"a","type":"string[]"},{"name":"b","type":"asset?"},{"name":"c","type":"public_key"}]}]}`), abi); err != nil {
+		t.Fatal(err)
+	}
+	generate := func(seed int64, iteration int) (s string) {
+		_ = WithSeed(seed, iteration, func() error {
+			j, err := NewAbiGen(abi, ModeValid).Type("s")
+			if err != nil {
+				t.Fatal(err)
+			}
+			s = fmt.Sprint(RandomString(16), RandomBytes(32, EncodeHexString), RandomActor(), RandomFioPubKey(), IncrementingInt(), string(j))
+			return nil
+		})
+		return
+	}
+	seed := NewSeed()
+	first := generate(seed, 3)
+	_ = generate(seed, 4)
+	if again := generate(seed, 3); first != again {
+		t.Errorf("replay did not match:\n%s\n%s", first, again)
+	}
+	if other := generate(seed, 4); first == other {
+		t.Error("different iterations should not match")
+	}
+	if other := generate(seed+1, 3); first == other {
+		t.Error("different seeds should not match")
+	}
+}
+
+func TestMutate(t *testing.T) {
+	base := json.RawMessage(`{"fio_address":"alice@dapixdev","amount":1000000000,"public_addresses":[{"chain_code":"BTC","token_code":"BTC","public_address":"1abc"}],"tpid":"","actor":"aloha1234512"}`)
+	corpus := []json.RawMessage{[]byte(`{"fio_address":"bob@dapixdev","amount":5,"public_addresses":[],"tpid":"rewards@wallet","actor":"bobbob123451"}`)}
+	mutate := func(iteration int) (out string) {
+		_ = WithSeed(42, iteration, func() error {
+			j, applied, err := NewMutator(corpus, []string{"carol@dapixdev"}).Mutate(base)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(applied) == 0 {
+				t.Error("nothing was mutated")
+			}
+			out = string(j)
+			return nil
+		})
+		return
+	}
+	changed := 0
+	for i := 0; i < 50; i++ {
+		j := mutate(i)
+		if !json.Valid([]byte(j)) {
+			t.Fatal("invalid json", j)
+		}
+		if j != mutate(i) {
+			t.Error("mutation is not repeatable for the same seed")
+		}
+		v := make(map[string]interface{})
+		_ = json.Unmarshal([]byte(j), &v)
+		if len(v) != 5 {
+			t.Error("fields should not be added or removed", j)
+		}
+		var b map[string]interface{}
+		_ = json.Unmarshal(base, &b)
+		if fmt.Sprint(b) != fmt.Sprint(v) {
+			changed++
+		}
+	}
+	if changed < 40 {
+		t.Errorf("only %d of 50 payloads were different", changed)
+	}
+	if _, _, err := NewMutator(nil, nil).Mutate([]byte(`[1,2]`)); err == nil {
+		t.Error("expected an error mutating an array")
+	}
+}
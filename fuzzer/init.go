@@ -1,10 +1,128 @@
 package fuzzer
 
 import (
+	"log"
 	"math/rand"
+	"sync"
 	"time"
 )
 
+// Notify is called with progress and errors from the generators that query the chain, the gui sends these
+// to the event log.
+var Notify = func(msg string) {
+	log.Println(msg)
+}
+
 func init() {
 	rand.Seed(time.Now().UnixNano())
 }
+
+// lockedSource is safe to share between workers, rand.NewSource is not
+type lockedSource struct {
+	mux sync.Mutex
+	src rand.Source64
+}
+
+func (s *lockedSource) Int63() int64 {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	return s.src.Int63()
+}
+
+func (s *lockedSource) Uint64() uint64 {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	return s.src.Uint64()
+}
+
+func (s *lockedSource) Seed(seed int64) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	s.src.Seed(seed)
+}
+
+var (
+	source  = &lockedSource{src: rand.NewSource(time.Now().UnixNano()).(rand.Source64)}
+	rng     = rand.New(source)
+	seedMux sync.Mutex
+)
+
+// NewSeed picks a seed for a new fuzzing run
+func NewSeed() int64 {
+	return time.Now().UnixNano() & 0x7fffffffffffffff
+}
+
+// IterationSeed is the seed the generators use for one iteration of a run, each iteration can be replayed on
+// its own.
+func IterationSeed(seed int64, iteration int) int64 {
+	return seed ^ int64(uint64(iteration)*0x9e3779b97f4a7c15)
+}
+
+// WithSeed runs fn with every generator in this package seeded for the iteration, the same seed and iteration
+// build the same values. Seeded runs are serialized so workers don't consume each other's numbers. Values
+// that come from the chain (existing names) or the clock (timestamps) can still differ.
+func WithSeed(seed int64, iteration int, fn func() error) error {
+	return WithWorker(seed, iteration, Worker{Count: 1, Sent: iteration}, fn)
+}
+
+// Worker is which of a run's workers is generating, sources that split a file between workers use it
+type Worker struct {
+	Id    int
+	Count int
+	// Sent is how many payloads this worker has generated so far in the run
+	Sent int
+}
+
+// the iteration and worker for the WithSeed call in progress. They have their own lock because generators read
+// them while WithWorker holds seedMux.
+var (
+	nowMux       sync.RWMutex
+	iterationNow int
+	workerNow    = Worker{Count: 1}
+)
+
+// current is the iteration and worker set by the last WithWorker call
+func current() (int, Worker) {
+	nowMux.RLock()
+	defer nowMux.RUnlock()
+	return iterationNow, workerNow
+}
+
+// WithWorker is WithSeed for one of several workers in a run. Every worker waits for fn, so it should only
+// generate values, sign and send after it returns.
+func WithWorker(seed int64, iteration int, w Worker, fn func() error) error {
+	seedMux.Lock()
+	defer seedMux.Unlock()
+	source.Seed(IterationSeed(seed, iteration))
+	incrementingInt, incrementingFloat = int64(iteration), float64(iteration)*1.00001
+	if w.Count < 1 {
+		w.Count = 1
+	}
+	nowMux.Lock()
+	iterationNow, workerNow = iteration, w
+	nowMux.Unlock()
+	return fn()
+}
+
+// Intn is rand.Intn from the (possibly seeded) fuzzer source
+func Intn(n int) int {
+	return rng.Intn(n)
+}
+
+// randRead fills b from the fuzzer source, rand.Rand's Read keeps state and isn't safe for concurrent use
+func randRead(b []byte) {
+	for i := 0; i < len(b); i += 8 {
+		v := rng.Uint64()
+		for j := 0; j < 8 && i+j < len(b); j++ {
+			b[i+j] = byte(v >> (8 * j))
+		}
+	}
+}
+
+// randReader adapts randRead for key generation
+type randReader struct{}
+
+func (randReader) Read(b []byte) (int, error) {
+	randRead(b)
+	return len(b), nil
+}
@@ -0,0 +1,287 @@
+package fuzzer
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"math/rand"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Mutator changes a few values in action data that is known to work. Valid payloads get past the early
+// checks in a contract, so small changes reach logic that random values never do.
+type Mutator struct {
+	Rand *rand.Rand
+	// Corpus is data from other successful calls to the same action, values at the same path are swapped in
+	Corpus []json.RawMessage
+	// Names are existing on-chain names (addresses, domains, accounts,) strings are sometimes replaced with one
+	Names []string
+	// MaxMutations is the most changes made to one payload
+	MaxMutations int
+}
+
+// NewMutator uses the fuzzer source, so it is repeatable inside WithSeed
+func NewMutator(corpus []json.RawMessage, names []string) *Mutator {
+	return &Mutator{
+		Rand:         rand.New(rand.NewSource(rng.Int63())),
+		Corpus:       corpus,
+		Names:        names,
+		MaxMutations: 3,
+	}
+}
+
+// path is a list of object keys and array indexes
+type path []interface{}
+
+func (p path) String() string {
+	s := make([]string, len(p))
+	for i := range p {
+		switch k := p[i].(type) {
+		case string:
+			s[i] = k
+		case int:
+			s[i] = fmt.Sprintf("[%d]", k)
+		}
+	}
+	return strings.ReplaceAll(strings.Join(s, "."), ".[", "[")
+}
+
+// Mutate returns a changed copy of base, and a description of each change
+func (m *Mutator) Mutate(base json.RawMessage) (json.RawMessage, []string, error) {
+	root, err := decodeNumbers(base)
+	if err != nil {
+		return nil, nil, err
+	}
+	if _, ok := root.(map[string]interface{}); !ok {
+		return nil, nil, errors.New("can only mutate a json object")
+	}
+	applied := make([]string, 0)
+	n := 1
+	if m.MaxMutations > 1 {
+		n += m.Rand.Intn(m.MaxMutations)
+	}
+	for i := 0; i < n; i++ {
+		paths := make([]path, 0)
+		walk(root, path{}, &paths)
+		if len(paths) == 0 {
+			break
+		}
+		p := paths[m.Rand.Intn(len(paths))]
+		v, desc := m.mutate(root, p)
+		setPath(root, p, v)
+		applied = append(applied, p.String()+": "+desc)
+	}
+	out, err := json.Marshal(root)
+	return out, applied, err
+}
+
+func (m *Mutator) mutate(root interface{}, p path) (interface{}, string) {
+	v := getPath(root, p)
+	// a value from another successful call, only if it's the same kind of value
+	if len(m.Corpus) > 0 && m.Rand.Intn(4) == 0 {
+		if other, err := decodeNumbers(m.Corpus[m.Rand.Intn(len(m.Corpus))]); err == nil {
+			if o := getPath(other, p); o != nil && fmt.Sprintf("%T", o) == fmt.Sprintf("%T", v) {
+				return o, "swapped from another call"
+			}
+		}
+	}
+	// flip with a sibling of the same kind
+	if len(p) > 0 && m.Rand.Intn(5) == 0 {
+		if obj, ok := getPath(root, p[:len(p)-1]).(map[string]interface{}); ok {
+			same := make([]string, 0)
+			for k, sib := range obj {
+				if k != p[len(p)-1] && fmt.Sprintf("%T", sib) == fmt.Sprintf("%T", v) {
+					same = append(same, k)
+				}
+			}
+			if len(same) > 0 {
+				sort.Strings(same)
+				k := same[m.Rand.Intn(len(same))]
+				sib := obj[k]
+				obj[k] = v
+				return sib, "flipped with " + k
+			}
+		}
+	}
+
+	switch t := v.(type) {
+	case string:
+		return m.mutateString(t)
+	case json.Number:
+		return m.mutateNumber(t)
+	case bool:
+		return !t, "flipped bool"
+	case []interface{}:
+		return m.mutateArray(t)
+	case map[string]interface{}:
+		// nested values get picked on their own, changing the struct itself only leaves the shape
+		return t, "unchanged"
+	}
+	return v, "unchanged"
+}
+
+func (m *Mutator) mutateString(s string) (interface{}, string) {
+	switch m.Rand.Intn(8) {
+	case 0:
+		if len(m.Names) > 0 {
+			return m.Names[m.Rand.Intn(len(m.Names))], "existing name"
+		}
+		return "", "empty"
+	case 1:
+		if len(s) > 1 {
+			return s[:m.Rand.Intn(len(s))], "truncated"
+		}
+		return "", "empty"
+	case 2:
+		if len(s) > 0 {
+			return s[:len(s)-1], "one shorter"
+		}
+		return "a", "one longer"
+	case 3:
+		if len(s) > 0 {
+			return s + s[len(s)-1:], "one longer"
+		}
+		return "a", "one longer"
+	case 4:
+		return s + strings.Repeat(s+"x", 1+m.Rand.Intn(8)), "extended"
+	case 5:
+		return "", "empty"
+	case 6:
+		if len(s) > 0 {
+			b := []byte(s)
+			i := m.Rand.Intn(len(b))
+			b[i] = b[i] ^ byte(1<<uint(m.Rand.Intn(7)))
+			return string(b), "flipped a bit"
+		}
+		return " ", "whitespace"
+	}
+	if len(s) > 0 {
+		if strings.ToUpper(s) != s {
+			return strings.ToUpper(s), "upper case"
+		}
+		return " " + s, "leading space"
+	}
+	return " ", "whitespace"
+}
+
+func (m *Mutator) mutateNumber(n json.Number) (interface{}, string) {
+	i, ok := new(big.Int).SetString(n.String(), 10)
+	if !ok {
+		f, err := n.Float64()
+		if err != nil {
+			return n, "unchanged"
+		}
+		switch m.Rand.Intn(4) {
+		case 0:
+			return json.Number(strconv.FormatFloat(-f, 'f', -1, 64)), "negated"
+		case 1:
+			return json.Number("0"), "zero"
+		case 2:
+			return json.Number(strconv.FormatFloat(f*1000, 'f', -1, 64)), "times 1000"
+		}
+		return json.Number(strconv.FormatFloat(f+1, 'f', -1, 64)), "plus one"
+	}
+	switch m.Rand.Intn(6) {
+	case 0:
+		return json.Number(i.Sub(i, big.NewInt(1)).String()), "minus one"
+	case 1:
+		return json.Number("0"), "zero"
+	case 2:
+		return json.Number(i.Neg(i).String()), "negated"
+	case 3:
+		return json.Number(i.Mul(i, big.NewInt(2)).String()), "doubled"
+	case 4:
+		return json.Number(i.Div(i, big.NewInt(2)).String()), "halved"
+	}
+	return json.Number(i.Add(i, big.NewInt(1)).String()), "plus one"
+}
+
+func (m *Mutator) mutateArray(a []interface{}) (interface{}, string) {
+	out := make([]interface{}, len(a))
+	copy(out, a)
+	switch {
+	case len(a) == 0:
+		return out, "unchanged"
+	case m.Rand.Intn(4) == 0:
+		return out[:0], "emptied"
+	case m.Rand.Intn(3) == 0:
+		return out[:len(out)-1], "dropped last element"
+	case m.Rand.Intn(2) == 0 && len(out) > 1:
+		i, j := m.Rand.Intn(len(out)), m.Rand.Intn(len(out))
+		out[i], out[j] = out[j], out[i]
+		return out, "swapped elements"
+	}
+	for i := 1 + m.Rand.Intn(len(a)*2); i > 0; i-- {
+		out = append(out, a[m.Rand.Intn(len(a))])
+	}
+	return out, "extended"
+}
+
+func decodeNumbers(j []byte) (interface{}, error) {
+	var v interface{}
+	d := json.NewDecoder(bytes.NewReader(j))
+	d.UseNumber()
+	err := d.Decode(&v)
+	return v, err
+}
+
+func walk(v interface{}, p path, paths *[]path) {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		// sorted so a seed always picks the same path
+		keys := make([]string, 0, len(t))
+		for k := range t {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			child := append(append(path{}, p...), k)
+			*paths = append(*paths, child)
+			walk(t[k], child, paths)
+		}
+	case []interface{}:
+		for i := range t {
+			child := append(append(path{}, p...), i)
+			*paths = append(*paths, child)
+			walk(t[i], child, paths)
+		}
+	}
+}
+
+func getPath(v interface{}, p path) interface{} {
+	for _, k := range p {
+		switch t := v.(type) {
+		case map[string]interface{}:
+			key, _ := k.(string)
+			v = t[key]
+		case []interface{}:
+			i, _ := k.(int)
+			if i >= len(t) {
+				return nil
+			}
+			v = t[i]
+		default:
+			return nil
+		}
+	}
+	return v
+}
+
+func setPath(root interface{}, p path, value interface{}) {
+	if len(p) == 0 {
+		return
+	}
+	switch t := getPath(root, p[:len(p)-1]).(type) {
+	case map[string]interface{}:
+		key, _ := p[len(p)-1].(string)
+		t[key] = value
+	case []interface{}:
+		if i, _ := p[len(p)-1].(int); i < len(t) {
+			t[i] = value
+		}
+	}
+}
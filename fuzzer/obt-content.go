@@ -0,0 +1,254 @@
+package fuzzer
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/fioprotocol/fio-go"
+	"github.com/fioprotocol/fio-go/eos"
+	"strings"
+)
+
+// ContentCases are the ways Content breaks the encrypted content of a request or record. The ones marked
+// "valid hmac" are authenticated after being broken, so they get past the hmac check to the decryption.
+var ContentCases = []string{
+	"valid",
+	"encrypted to the wrong key",
+	"truncated iv (valid hmac)",
+	"truncated hmac",
+	"missing hmac",
+	"bad hmac",
+	"zero padding byte (valid hmac)",
+	"padding longer than a block (valid hmac)",
+	"not a block multiple (valid hmac)",
+	"shorter than iv and hmac",
+	"empty plaintext",
+	"inner json, not abi encoded",
+	"inner truncated",
+	"inner is the other obt type",
+	"inner oversized memo",
+	"inner fields fill the max length",
+	"not base64",
+	"empty",
+}
+
+// ContentKinds are the structs that can be encrypted: newfundsreq content, or recordobt content
+var ContentKinds = []string{"newfundsreq", "recordobt"}
+
+// max length of the content field in the contracts
+var contentMax = map[string]int{"newfundsreq": 296, "recordobt": 432}
+
+var obtAbi = func() *eos.ABI {
+	abi, err := eos.NewABI(strings.NewReader(fio.ObtAbiJson))
+	if err != nil {
+		panic(err)
+	}
+	return abi
+}()
+
+// Content builds the content field for a newfundsreq or recordobt, encrypted from sender to recipient and
+// then broken the way edge says. If recipient is empty it's encrypted to the sender's own key.
+func Content(sender *fio.Account, recipient string, kind string, edge string) (string, error) {
+	if sender == nil || !hasKey(sender) {
+		return "", errors.New("encrypting content needs a local private key")
+	}
+	if contentMax[kind] == 0 {
+		return "", fmt.Errorf("unknown content type %q", kind)
+	}
+	if recipient == "" {
+		recipient = sender.PubKey
+	}
+	other := "recordobt"
+	if kind == other {
+		other = "newfundsreq"
+	}
+
+	inner, err := obtInner(kind, "")
+	if err != nil {
+		return "", err
+	}
+	key, macKey, err := obtKeys(sender, recipient)
+	if err != nil {
+		return "", err
+	}
+	iv := randBytes(aes.BlockSize)
+	// sealed is iv + ciphertext + hmac, which is what EciesEncrypt does
+	sealed := func(plain []byte) []byte {
+		msg := append(append([]byte{}, iv...), cbc(key, iv, pkcs7(plain))...)
+		return append(msg, sign(macKey, msg)...)
+	}
+
+	var msg []byte
+	switch edge {
+	case "valid":
+		msg = sealed(inner)
+	case "encrypted to the wrong key":
+		if key, macKey, err = obtKeys(sender, RandomFioPubKey()); err != nil {
+			return "", err
+		}
+		msg = sealed(inner)
+	case "truncated iv (valid hmac)":
+		msg = append(append([]byte{}, iv[:aes.BlockSize/2]...), cbc(key, iv, pkcs7(inner))...)
+		msg = append(msg, sign(macKey, msg)...)
+	case "truncated hmac":
+		msg = sealed(inner)
+		msg = msg[:len(msg)-sha256.Size/2]
+	case "missing hmac":
+		msg = sealed(inner)
+		msg = msg[:len(msg)-sha256.Size]
+	case "bad hmac":
+		msg = sealed(inner)
+		msg[len(msg)-1-rng.Intn(sha256.Size)] ^= byte(1 << uint(rng.Intn(8)))
+	case "zero padding byte (valid hmac)", "padding longer than a block (valid hmac)":
+		padded := pkcs7(inner)
+		padded[len(padded)-1] = 0
+		if strings.HasPrefix(edge, "padding longer") {
+			padded[len(padded)-1] = aes.BlockSize * 2
+		}
+		msg = append(append([]byte{}, iv...), cbc(key, iv, padded)...)
+		msg = append(msg, sign(macKey, msg)...)
+	case "not a block multiple (valid hmac)":
+		msg = append(append([]byte{}, iv...), cbc(key, iv, pkcs7(inner))...)
+		msg = append(msg, randBytes(1+rng.Intn(aes.BlockSize-1))...)
+		msg = append(msg, sign(macKey, msg)...)
+	case "shorter than iv and hmac":
+		msg = randBytes(1 + rng.Intn(aes.BlockSize+sha256.Size-1))
+	case "empty plaintext":
+		msg = sealed(nil)
+	case "inner json, not abi encoded":
+		j, err := obtAbi.DecodeAction(inner, obtType(kind))
+		if err != nil {
+			return "", err
+		}
+		msg = sealed(j)
+	case "inner truncated":
+		msg = sealed(inner[:rng.Intn(len(inner))])
+	case "inner is the other obt type":
+		o, err := obtInner(other, "")
+		if err != nil {
+			return "", err
+		}
+		msg = sealed(o)
+	case "inner oversized memo":
+		big, err := obtInner(kind, strings.Repeat(word(), 65536/8))
+		if err != nil {
+			return "", err
+		}
+		msg = sealed(big)
+	case "inner fields fill the max length":
+		// grow the memo until the content is as long as the contract allows
+		v := obtValue(kind, "a")
+		for memo := "a"; ; memo += "a" {
+			switch c := v.(type) {
+			case *fio.ObtRequestContent:
+				c.Memo = memo
+			case *fio.ObtRecordContent:
+				c.Memo = memo
+			}
+			next, err := obtEncode(v)
+			if err != nil {
+				return "", err
+			}
+			if msg != nil && base64.StdEncoding.EncodedLen(len(sealed(next))) > contentMax[kind] {
+				break
+			}
+			msg = sealed(next)
+		}
+	case "not base64":
+		return RandomString(contentMax[kind]-2) + "!=", nil
+	case "empty":
+		return "", nil
+	default:
+		return "", fmt.Errorf("unknown content case %q", edge)
+	}
+	return base64.StdEncoding.EncodeToString(msg), nil
+}
+
+// obtInner is the abi encoded struct that gets encrypted, memo is random if empty
+func obtInner(kind string, memo string) ([]byte, error) {
+	return obtEncode(obtValue(kind, memo))
+}
+
+// obtValue is a random request or record, it's a pointer so the memo can be changed
+func obtValue(kind string, memo string) interface{} {
+	if memo == "" {
+		memo = word() + " " + word()
+	}
+	amount := fmt.Sprintf("%d.%02d", rng.Intn(10000), rng.Intn(100))
+	if kind == "recordobt" {
+		return &fio.ObtRecordContent{
+			PayerPublicAddress: RandomFioPubKey(),
+			PayeePublicAddress: RandomFioPubKey(),
+			Amount:             amount,
+			ChainCode:          ChainCode(),
+			TokenCode:          TokenCode(),
+			Status:             "sent_to_blockchain",
+			ObtId:              randHex(32),
+			Memo:               memo,
+		}
+	}
+	return &fio.ObtRequestContent{
+		PayeePublicAddress: RandomFioPubKey(),
+		Amount:             amount,
+		ChainCode:          ChainCode(),
+		TokenCode:          TokenCode(),
+		Memo:               memo,
+	}
+}
+
+func obtEncode(v interface{}) ([]byte, error) {
+	j, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := v.(*fio.ObtRecordContent); ok {
+		return obtAbi.EncodeAction(obtType("recordobt"), j)
+	}
+	return obtAbi.EncodeAction(obtType("newfundsreq"), j)
+}
+
+func obtType(kind string) eos.ActionName {
+	if kind == "recordobt" {
+		return "record_send_content"
+	}
+	return "new_funds_content"
+}
+
+// obtKeys are the aes and hmac keys, the shared secret hashed twice as the other sdks do
+func obtKeys(sender *fio.Account, recipient string) (key []byte, macKey []byte, err error) {
+	_, hash, err := fio.EciesSecret(sender, recipient)
+	if err != nil {
+		return nil, nil, err
+	}
+	keys := sha512.Sum512(hash[:])
+	return keys[:32], keys[32:], nil
+}
+
+func pkcs7(b []byte) []byte {
+	n := aes.BlockSize - len(b)%aes.BlockSize
+	return append(append([]byte{}, b...), bytes.Repeat([]byte{byte(n)}, n)...)
+}
+
+func cbc(key []byte, iv []byte, padded []byte) []byte {
+	block, _ := aes.NewCipher(key)
+	out := make([]byte, len(padded))
+	cipher.NewCBCEncrypter(block, iv).CryptBlocks(out, padded)
+	return out
+}
+
+func sign(macKey []byte, msg []byte) []byte {
+	h := hmac.New(sha256.New, macKey)
+	h.Write(msg)
+	return h.Sum(nil)
+}
+
+func hasKey(a *fio.Account) bool {
+	return a.KeyBag != nil && len(a.KeyBag.Keys) > 0
+}
@@ -3,12 +3,10 @@ package fuzzer
 import (
 	"bytes"
 	"context"
-	cr "crypto/rand"
 	"crypto/sha256"
 	"encoding/base64"
 	"encoding/hex"
 	"fmt"
-	errs "github.com/blockpane/cryptonym/errLog"
 	"github.com/btcsuite/btcd/chaincfg"
 	"github.com/btcsuite/btcutil"
 	"github.com/ethereum/go-ethereum/crypto"
@@ -16,7 +14,6 @@ import (
 	"github.com/fioprotocol/fio-go/eos"
 	"github.com/fioprotocol/fio-go/eos/ecc"
 	"math"
-	"math/rand"
 	"strconv"
 	"strings"
 	"time"
@@ -32,7 +29,7 @@ const (
 func RandomString(length int) string {
 	var payload string
 	for i := 0; i < length; i++ {
-		payload = payload + string(byte(rand.Intn(26)+97))
+		payload = payload + string(byte(rng.Intn(26)+97))
 	}
 	return payload
 }
@@ -68,17 +65,17 @@ func RandomNumber() RandomNumberResult {
 	rn := RandomNumberResult{}
 
 	negative := 1
-	if rand.Intn(2) > 0 {
+	if rng.Intn(2) > 0 {
 		signed = true
-		if rand.Intn(2) > 0 {
+		if rng.Intn(2) > 0 {
 			negative = -1
 		}
 	}
 
-	intOrFloat := rand.Intn(2)
+	intOrFloat := rng.Intn(2)
 	switch intOrFloat {
 	case 0:
-		l := intLens[rand.Intn(len(intLens))]
+		l := intLens[rng.Intn(len(intLens))]
 		result = RandomInteger(l)
 		rn.abi = fmt.Sprintf("int%d", l)
 		if !signed {
@@ -119,7 +116,7 @@ func RandomNumber() RandomNumberResult {
 			}
 		}
 	case 1:
-		fl := floatLen * (rand.Intn(2) + 1)
+		fl := floatLen * (rng.Intn(2) + 1)
 		if fl == 32 {
 			rn.abi = "float32"
 			result = float32(RandomFloat(fl) * float64(negative))
@@ -159,30 +156,30 @@ func MaxInt(size string) uint64 {
 func RandomInteger(size int) RandomNumberResult {
 	switch size {
 	case 8:
-		//return int8(rand.Intn(math.MaxInt8-1) + 1)
+		//return int8(rng.Intn(math.MaxInt8-1) + 1)
 		return RandomNumberResult{
 			abi:   "int8",
-			value: int8(rand.Intn(math.MaxInt8-1) + 1),
+			value: int8(rng.Intn(math.MaxInt8-1) + 1),
 		}
 	case 16:
 		return RandomNumberResult{
 			abi:   "int16",
-			value: int16(rand.Intn(math.MaxInt16-1) + 1),
+			value: int16(rng.Intn(math.MaxInt16-1) + 1),
 		}
 	case 32:
 		return RandomNumberResult{
 			abi:   "int32",
-			value: int32(rand.Intn(math.MaxInt32-1) + 1),
+			value: int32(rng.Intn(math.MaxInt32-1) + 1),
 		}
 	case 64:
 		return RandomNumberResult{
 			abi:   "int64",
-			value: int64(rand.Intn(math.MaxInt64-1) + 1),
+			value: int64(rng.Intn(math.MaxInt64-1) + 1),
 		}
 	}
 	return RandomNumberResult{
 		abi:   "int32",
-		value: int32(rand.Intn(math.MaxInt16-1)+1) * -1,
+		value: int32(rng.Intn(math.MaxInt16-1)+1) * -1,
 	}
 }
 
@@ -206,8 +203,8 @@ func OverFlowInt(size int, signed bool) string {
 
 func RandomInt128() string {
 	j, _ := eos.Int128{
-		Lo: rand.Uint64(),
-		Hi: rand.Uint64(),
+		Lo: rng.Uint64(),
+		Hi: rng.Uint64(),
 	}.MarshalJSON()
 	return string(j)
 }
@@ -215,18 +212,18 @@ func RandomInt128() string {
 func RandomFloat(size int) float64 {
 	switch size {
 	case 32:
-		f := rand.Float32()
+		f := rng.Float32()
 		if f < .4 {
-			f = f + rand.Float32()*1000.0
+			f = f + rng.Float32()*1000.0
 		}
 		return float64(f)
 	case 64:
-		f := rand.Float64()
+		f := rng.Float64()
 		if f < .4 {
-			f = f + rand.Float64()*10000.0
+			f = f + rng.Float64()*10000.0
 			// if we are sending a f64, make sure it's a big one.
 			if f < math.MaxFloat32 {
-				f = f + float64(math.MaxInt16+rand.Intn(math.MaxInt16))
+				f = f + float64(math.MaxInt16+rng.Intn(math.MaxInt16))
 			}
 		}
 		return f
@@ -235,15 +232,9 @@ func RandomFloat(size int) float64 {
 }
 
 func RandomBytes(size int, encode int8) interface{} {
-	var pl []byte
-	// reading MBs of data from urand is a bad idea, use math.rand instead
-	if size <= 4096 {
-		pl = make([]byte, size)
-		_, _ = cr.Read(pl)
-	} else {
-		pl = make([]byte, size)
-		_, _ = rand.Read(pl)
-	}
+	// not crypto/rand, so a seeded run can be replayed
+	pl := make([]byte, size)
+	randRead(pl)
 	switch encode {
 	case EncodeRaw:
 		return string(pl)
@@ -263,7 +254,7 @@ func RandomBytes(size int, encode int8) interface{} {
 
 func RandomChecksum() eos.Checksum256 {
 	cs := make([]byte, 32)
-	_, _ = cr.Read(cs)
+	randRead(cs)
 	return cs
 }
 
@@ -304,7 +295,7 @@ func SignatureFor(value string, key *fio.Account) string {
 func word() string {
 	var w string
 	for i := 0; i < 6; i++ {
-		w = w + string(byte(rand.Intn(26)+97))
+		w = w + string(byte(rng.Intn(26)+97))
 	}
 	return w
 }
@@ -338,7 +329,7 @@ func RandomAddAddress(count int) string {
 }
 
 func NewPubAddress(user *fio.Account) (address string, chain string) {
-	r := rand.Intn(3)
+	r := rng.Intn(3)
 	switch r {
 	case 0:
 		chain = "BTC"
@@ -370,7 +361,7 @@ func nonBlockErr(msg string) {
 	ctx, cancel := context.WithDeadline(context.Background(), d)
 	defer cancel()
 	go func(s string) {
-		errs.ErrChan <- s
+		Notify(s)
 	}(msg)
 	select {
 	case <-ctx.Done():
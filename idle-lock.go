@@ -0,0 +1,161 @@
+package cryptonym
+
+import (
+	"errors"
+	errs "github.com/blockpane/cryptonym/errLog"
+	"github.com/fioprotocol/fio-go"
+	"github.com/fioprotocol/fio-go/eos"
+	"github.com/fioprotocol/fio-go/eos/btcsuite/btcd/btcec"
+	"github.com/fioprotocol/fio-go/eos/ecc"
+	"math/big"
+	"reflect"
+	"sync"
+	"time"
+	"unsafe"
+)
+
+// LockChan is sent to when the idle timeout locks the app, so the key box can be cleared
+var LockChan = make(chan bool)
+
+var errLocked = errors.New("cryptonym is locked, enter the settings password to sign")
+
+var idle = struct {
+	sync.Mutex
+	last   time.Time
+	locked bool
+}{last: time.Now()}
+
+// MarkActivity resets the idle timer, it is called for key presses, tab changes, loading keys, and signing.
+func MarkActivity() {
+	idle.Lock()
+	idle.last = time.Now()
+	idle.Unlock()
+}
+
+func Locked() bool {
+	idle.Lock()
+	defer idle.Unlock()
+	return idle.locked
+}
+
+// Unlock is called once the password has been entered, or a new key loaded when there are no saved settings
+func Unlock() {
+	idle.Lock()
+	idle.locked = false
+	idle.last = time.Now()
+	idle.Unlock()
+}
+
+// StartIdleLock checks for an idle timeout until the process exits
+func StartIdleLock() {
+	go func() {
+		t := time.NewTicker(10 * time.Second)
+		for range t.C {
+			if Settings == nil || Settings.IdleLockMinutes <= 0 {
+				continue
+			}
+			timeout := time.Duration(Settings.IdleLockMinutes) * time.Minute
+			idle.Lock()
+			expired := !idle.locked && time.Since(idle.last) > timeout
+			idle.Unlock()
+			if expired {
+				LockNow()
+			}
+		}
+	}()
+}
+
+// LockNow scrubs keys from memory and asks for the password again
+func LockNow() {
+	idle.Lock()
+	if idle.locked {
+		idle.Unlock()
+		return
+	}
+	idle.locked = true
+	idle.Unlock()
+
+	scrubAccount(Account)
+	if Settings != nil {
+		Settings.scrub()
+	}
+	keosdSigner = nil
+	if externalSigner != nil {
+		externalSigner.Close()
+	}
+	errs.ErrChan <- "locked after being idle, private keys were removed from memory"
+	go func() {
+		LockChan <- true
+	}()
+	RequestUnlock()
+}
+
+// RequestUnlock asks for the settings password, returns true if there are no saved settings and the app was unlocked
+// without a password: a key will need to be imported again before signing.
+func RequestUnlock() bool {
+	if ok, fileLen, _, err := LoadEncryptedSettings(""); !ok && fileLen == 0 && err == nil {
+		Unlock()
+		return true
+	}
+	go PromptForPassword()
+	return false
+}
+
+// scrub removes the saved WIFs and keosd password. Go strings can't be safely overwritten, so this only drops
+// the references, the garbage collector will eventually re-use the memory.
+func (s *FioSettings) scrub() {
+	for _, k := range s.Keys {
+		if k != nil {
+			k.Wif = ""
+		}
+	}
+	s.Keys = make([]*SavedKey, 0)
+	s.KeosdPassword = ""
+}
+
+// scrubAccount zeros the private keys in the account's key bag, and removes them
+func scrubAccount(account *fio.Account) {
+	if account == nil || account.KeyBag == nil {
+		return
+	}
+	scrubKeyBag(account.KeyBag)
+}
+
+func scrubKeyBag(kb *eos.KeyBag) {
+	for _, k := range kb.Keys {
+		if d := privateScalar(k); d != nil {
+			bits := d.Bits()
+			for i := range bits {
+				bits[i] = 0
+			}
+			d.SetInt64(0)
+		}
+	}
+	kb.Keys = make([]*ecc.PrivateKey, 0)
+}
+
+// privateScalar digs the secret out of an ecc.PrivateKey, which doesn't export it. Returns nil if the
+// key isn't the expected type, ie a future version of fio-go changes the layout.
+func privateScalar(k *ecc.PrivateKey) (d *big.Int) {
+	defer func() {
+		if recover() != nil {
+			d = nil
+		}
+	}()
+	if k == nil {
+		return nil
+	}
+	inner := reflect.ValueOf(k).Elem().FieldByName("inner")
+	if !inner.IsValid() || inner.IsNil() || inner.Elem().Kind() != reflect.Ptr {
+		return nil
+	}
+	field := inner.Elem().Elem().FieldByName("privKey")
+	if !field.IsValid() {
+		return nil
+	}
+	priv, ok := reflect.NewAt(field.Type(), unsafe.Pointer(field.UnsafeAddr())).Elem().Interface().(*btcec.PrivateKey)
+	if !ok || priv == nil {
+		return nil
+	}
+	return priv.D
+}
@@ -0,0 +1,70 @@
+package cryptonym
+
+import (
+	"github.com/fioprotocol/fio-go"
+	"github.com/fioprotocol/fio-go/eos"
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+func TestScrubAccount(t *testing.T) {
+	acc, err := fio.NewAccountFromWif("5JBbUG5SDpLWxvBKihMeXLENinUzdNKNeozLas23Mj6ZNhz3hLS")
+	if err != nil {
+		t.Fatal(err)
+	}
+	key := acc.KeyBag.Keys[0]
+	d := privateScalar(key)
+	if d == nil || d.Sign() == 0 {
+		t.Fatal("could not find the private key scalar")
+	}
+	scrubAccount(acc)
+	if len(acc.KeyBag.Keys) != 0 {
+		t.Error("keys were not removed from the key bag")
+	}
+	if d.Sign() != 0 {
+		t.Error("private key was not zeroed")
+	}
+	for _, word := range d.Bits()[:cap(d.Bits())] {
+		if word != 0 {
+			t.Fatal("private key memory was not zeroed")
+		}
+	}
+
+	s := DefaultSettings()
+	wifs := s.Keys
+	s.KeosdPassword = "secret"
+	s.scrub()
+	if len(s.Keys) != 0 || s.KeosdPassword != "" || wifs[0].Wif != "" {
+		t.Error("settings were not scrubbed")
+	}
+}
+
+func TestLockedSigning(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cryptonym")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	for _, env := range []string{"XDG_CONFIG_HOME", "HOME"} {
+		defer os.Setenv(env, os.Getenv(env))
+		_ = os.Setenv(env, dir)
+	}
+
+	Settings = DefaultSettings()
+	acc, _ := fio.NewAccountFromWif("5JBbUG5SDpLWxvBKihMeXLENinUzdNKNeozLas23Mj6ZNhz3hLS")
+	api := &fio.API{API: eos.New("http://127.0.0.1:1")}
+	idle.Lock()
+	idle.locked = true
+	idle.Unlock()
+	if err = applySigner(api, acc); err != errLocked {
+		t.Errorf("expected signing to be refused while locked, got %v", err)
+	}
+	// without a settings file there is no password to ask for, so it is unlocked right away
+	if Locked() {
+		t.Error("should have unlocked without a settings file")
+	}
+	if err = applySigner(api, acc); err != nil {
+		t.Error(err)
+	}
+}
@@ -5,6 +5,9 @@ import (
 	"fmt"
 	"fyne.io/fyne/app"
 	"fyne.io/fyne/widget"
+	"github.com/blockpane/cryptonym/engine"
+	errs "github.com/blockpane/cryptonym/errLog"
+	"github.com/blockpane/cryptonym/fuzzer"
 	"github.com/fioprotocol/fio-go"
 	"golang.org/x/text/language"
 	"golang.org/x/text/message"
@@ -43,8 +46,11 @@ var (
 	TxResultBalanceChanOpen = false
 	useZlib                 = false
 	deferTx                 = false
+	fuzzSeed                = fuzzer.NewSeed()
+	newSeedEachSend         = true
 	Connected               bool
 	Uri                     = ""
+	Session                 = &engine.Session{}
 	Api                     = &fio.API{}
 	Opts                    = &fio.TxOptions{}
 	Account                 = func() *fio.Account {
@@ -70,6 +76,9 @@ func init() {
 		}
 	}()
 	startErrLog()
+	fuzzer.Notify = func(msg string) {
+		errs.ErrChan <- msg
+	}
 }
 
 type winSettings struct {
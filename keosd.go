@@ -0,0 +1,108 @@
+package cryptonym
+
+import (
+	"errors"
+	"fmt"
+	"github.com/fioprotocol/fio-go"
+	"github.com/fioprotocol/fio-go/eos"
+	"github.com/fioprotocol/fio-go/eos/ecc"
+	"strings"
+	"sync"
+)
+
+// KeosdSigner implements eos.Signer using a keosd compatible wallet, private keys are never loaded into cryptonym.
+type KeosdSigner struct {
+	Wallet string
+
+	password string
+	api      *eos.API
+	mux      sync.Mutex
+}
+
+func NewKeosdSigner(address string, wallet string, password string) *KeosdSigner {
+	if wallet == "" {
+		wallet = "default"
+	}
+	api := eos.New(strings.TrimRight(address, "/"))
+	api.Header.Set("User-Agent", "fio-cryptonym-wallet")
+	return &KeosdSigner{
+		Wallet:   wallet,
+		password: password,
+		api:      api,
+	}
+}
+
+// Unlock the wallet, an already unlocked wallet is not an error
+func (k *KeosdSigner) Unlock() error {
+	k.mux.Lock()
+	defer k.mux.Unlock()
+	err := k.api.WalletUnlock(k.Wallet, k.password)
+	if err != nil && !isAlreadyUnlocked(err) {
+		return err
+	}
+	return nil
+}
+
+func isAlreadyUnlocked(err error) bool {
+	if apiErr, ok := err.(eos.APIError); ok && apiErr.ErrorStruct.Code == 3120007 {
+		return true
+	}
+	return strings.Contains(strings.ToLower(err.Error()), "already unlocked")
+}
+
+func isLocked(err error) bool {
+	if apiErr, ok := err.(eos.APIError); ok && apiErr.ErrorStruct.Code == 3120003 {
+		return true
+	}
+	return strings.Contains(strings.ToLower(err.Error()), "locked wallet")
+}
+
+// AvailableKeys lists every public key held by keosd, keosd will only list keys for unlocked wallets.
+func (k *KeosdSigner) AvailableKeys() (out []ecc.PublicKey, err error) {
+	if err = k.Unlock(); err != nil {
+		return nil, err
+	}
+	return k.api.WalletPublicKeys()
+}
+
+// Sign asks keosd to sign, if the wallet timed out and re-locked itself it is unlocked and tried again.
+func (k *KeosdSigner) Sign(tx *eos.SignedTransaction, chainID []byte, requiredKeys ...ecc.PublicKey) (*eos.SignedTransaction, error) {
+	resp, err := k.api.WalletSignTransaction(tx, chainID, requiredKeys...)
+	if err != nil && isLocked(err) {
+		if err = k.Unlock(); err != nil {
+			return nil, err
+		}
+		resp, err = k.api.WalletSignTransaction(tx, chainID, requiredKeys...)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if resp == nil || len(resp.Signatures) == 0 {
+		return nil, errors.New("keosd did not return any signatures")
+	}
+	tx.Signatures = resp.Signatures
+	return tx, nil
+}
+
+// ImportPrivateKey is refused, keys should be imported using the wallet's own tools.
+func (k *KeosdSigner) ImportPrivateKey(wifPrivKey string) error {
+	return errors.New("will not import private keys into keosd, use clio or cleos")
+}
+
+func (k *KeosdSigner) Description() string {
+	return fmt.Sprintf("keosd wallet %q at %s", k.Wallet, k.api.BaseURL)
+}
+
+// RequiredKeys returns only the account's key, keosd signs with every key it is given and extra
+// signatures are rejected by nodeos as irrelevant.
+func (k *KeosdSigner) RequiredKeys(account *fio.Account) ([]ecc.PublicKey, error) {
+	return requireAccountKey(k, account, fmt.Sprintf("keosd wallet %q", k.Wallet))
+}
+
+func fioPubKey(key ecc.PublicKey) string {
+	s := key.String()
+	if len(s) == 53 {
+		return "FIO" + s[3:]
+	}
+	return s
+}
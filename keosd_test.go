@@ -0,0 +1,171 @@
+package cryptonym
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"github.com/fioprotocol/fio-go"
+	"github.com/fioprotocol/fio-go/eos"
+	"github.com/fioprotocol/fio-go/eos/ecc"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// keosdStandIn is just enough of the keosd wallet api to test signing, it holds a key bag and a password
+func keosdStandIn(wallet string, password string, keys *eos.KeyBag) *httptest.Server {
+	unlocked := false
+	apiErr := func(w http.ResponseWriter, code int, name string) {
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = fmt.Fprintf(w, `{"code":500,"message":"Internal Service Error","error":{"code":%d,"name":"%s","what":"%s","details":[]}}`, code, name, name)
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/wallet/unlock", func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		params := make([]string, 0)
+		if err := json.Unmarshal(body, &params); err != nil || len(params) != 2 {
+			apiErr(w, 3000000, "bad params")
+			return
+		}
+		switch {
+		case params[0] != wallet:
+			apiErr(w, 3120006, "wallet_nonexistent_exception")
+		case params[1] != password:
+			apiErr(w, 3120005, "wallet_invalid_password_exception")
+		case unlocked:
+			apiErr(w, 3120007, "wallet_unlocked_exception Already unlocked")
+		default:
+			unlocked = true
+			_, _ = w.Write([]byte("{}"))
+		}
+	})
+	mux.HandleFunc("/v1/wallet/get_public_keys", func(w http.ResponseWriter, r *http.Request) {
+		if !unlocked {
+			apiErr(w, 3120003, "wallet_locked_exception Locked wallet")
+			return
+		}
+		pubs := make([]string, 0)
+		available, _ := keys.AvailableKeys()
+		for _, k := range available {
+			pubs = append(pubs, k.String())
+		}
+		_ = json.NewEncoder(w).Encode(pubs)
+	})
+	mux.HandleFunc("/v1/wallet/sign_transaction", func(w http.ResponseWriter, r *http.Request) {
+		if !unlocked {
+			apiErr(w, 3120003, "wallet_locked_exception Locked wallet")
+			return
+		}
+		params := make([]json.RawMessage, 0)
+		body, _ := ioutil.ReadAll(r.Body)
+		if err := json.Unmarshal(body, &params); err != nil || len(params) != 3 {
+			apiErr(w, 3000000, "bad params")
+			return
+		}
+		tx := &eos.SignedTransaction{}
+		pubs := make([]string, 0)
+		var chainId string
+		_ = json.Unmarshal(params[0], tx)
+		_ = json.Unmarshal(params[1], &pubs)
+		_ = json.Unmarshal(params[2], &chainId)
+		// action data arrives as hex, put it back where the packer expects it
+		for _, a := range tx.Actions {
+			if s, ok := a.ActionData.Data.(string); ok {
+				b, _ := hex.DecodeString(s)
+				a.ActionData = eos.NewActionDataFromHexData(b)
+			}
+		}
+		required := make([]ecc.PublicKey, 0)
+		for _, p := range pubs {
+			k, err := ecc.NewPublicKey(p)
+			if err != nil {
+				apiErr(w, 3000000, err.Error())
+				return
+			}
+			required = append(required, k)
+		}
+		cid, _ := hex.DecodeString(chainId)
+		signed, err := keys.Sign(tx, cid, required...)
+		if err != nil {
+			apiErr(w, 3120001, err.Error())
+			return
+		}
+		_ = json.NewEncoder(w).Encode(signed)
+	})
+	return httptest.NewServer(mux)
+}
+
+func TestKeosdSigner(t *testing.T) {
+	acc, err := fio.NewAccountFromWif("5JBbUG5SDpLWxvBKihMeXLENinUzdNKNeozLas23Mj6ZNhz3hLS")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := keosdStandIn("test", "PW5secret", acc.KeyBag)
+	defer server.Close()
+
+	// no private key ever gets into the account used for signing
+	keosdAcc, err := NewPubKeyAccount(acc.PubKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if HasPrivateKey(keosdAcc) {
+		t.Fatal("keosd account should not have a private key")
+	}
+
+	bad := NewKeosdSigner(server.URL, "test", "wrong")
+	if _, err = bad.AvailableKeys(); err == nil {
+		t.Error("expected an invalid password error")
+	}
+
+	Settings = DefaultSettings()
+	Settings.SignerType = SignerKeosd
+	Settings.KeosdAddress = server.URL
+	Settings.KeosdWallet = "test"
+	Settings.KeosdPassword = "PW5secret"
+	defer func() { Settings = DefaultSettings() }()
+
+	pubs, err := SignerPublicKeys()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pubs) != 1 || pubs[0] != acc.PubKey {
+		t.Fatalf("expected wallet to list %s, got %v", acc.PubKey, pubs)
+	}
+
+	api := &fio.API{API: eos.New(server.URL)}
+	if err = applySigner(api, keosdAcc); err != nil {
+		t.Fatal(err)
+	}
+	opts := &fio.TxOptions{}
+	opts.ChainID, _ = hex.DecodeString(fio.ChainIdMainnet)
+	opts.HeadBlockID = make([]byte, 32)
+	tx := fio.NewTransaction(
+		[]*fio.Action{fio.NewTransferTokensPubKey(keosdAcc.Actor, "FIO6G9pXXM92Gy5eMwNquGULoCj3ZStwPLPdEb9mVXZz6nMBGd2VY", fio.Tokens(1.0))},
+		opts,
+	)
+	signed, _, err := api.SignTransaction(tx, opts.ChainID, fio.CompressionNone)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(signed.Signatures) != 1 {
+		t.Fatalf("expected one signature, got %d", len(signed.Signatures))
+	}
+	txdata, cfd, err := signed.PackedTransactionAndCFD()
+	if err != nil {
+		t.Fatal(err)
+	}
+	recovered, err := signed.Signatures[0].PublicKey(eos.SigDigest(opts.ChainID, txdata, cfd))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fioPubKey(recovered) != acc.PubKey {
+		t.Errorf("signature is from %s, expected %s", fioPubKey(recovered), acc.PubKey)
+	}
+
+	// a key that isn't in the wallet should be refused before anything is signed
+	other, _ := fio.NewRandomAccount()
+	if err = applySigner(api, other); err == nil {
+		t.Error("expected an error for a key that is not in the wallet")
+	}
+}
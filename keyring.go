@@ -0,0 +1,157 @@
+package cryptonym
+
+import (
+	"fmt"
+	"strings"
+)
+
+// SavedKey is a named WIF held in the encrypted settings keyring, tags are free-form and only used
+// to find keys in the picker (ie "voter", "bp", "msig", "locked")
+type SavedKey struct {
+	Name string   `json:"name"`
+	Wif  string   `json:"wif"`
+	Tags []string `json:"tags"`
+}
+
+// Matches is a case-insensitive substring match on the name and tags, an empty query matches everything
+func (k *SavedKey) Matches(query string) bool {
+	query = strings.ToLower(strings.TrimSpace(query))
+	if query == "" {
+		return true
+	}
+	if strings.Contains(strings.ToLower(k.Name), query) {
+		return true
+	}
+	for _, t := range k.Tags {
+		if strings.Contains(strings.ToLower(t), query) {
+			return true
+		}
+	}
+	return false
+}
+
+// TagString is used for editing tags in a single entry
+func (k *SavedKey) TagString() string {
+	return strings.Join(k.Tags, ", ")
+}
+
+// ParseTags splits a comma separated list of tags, dropping empty and duplicate values
+func ParseTags(s string) []string {
+	tags := make([]string, 0)
+	seen := make(map[string]bool)
+	for _, t := range strings.Split(s, ",") {
+		t = strings.TrimSpace(t)
+		if t == "" || seen[strings.ToLower(t)] {
+			continue
+		}
+		seen[strings.ToLower(t)] = true
+		tags = append(tags, t)
+	}
+	return tags
+}
+
+// DefaultSavedKey is the first key in the keyring, it is loaded automatically when settings are unlocked.
+func (s *FioSettings) DefaultSavedKey() *SavedKey {
+	for _, k := range s.Keys {
+		if k != nil && k.Wif != "" {
+			return k
+		}
+	}
+	return nil
+}
+
+// FindKey returns the key with the given name, or nil
+func (s *FioSettings) FindKey(name string) *SavedKey {
+	for _, k := range s.Keys {
+		if k != nil && k.Name == name {
+			return k
+		}
+	}
+	return nil
+}
+
+// SearchKeys returns keys matching the query in keyring order
+func (s *FioSettings) SearchKeys(query string) []*SavedKey {
+	found := make([]*SavedKey, 0)
+	for _, k := range s.Keys {
+		if k != nil && k.Matches(query) {
+			found = append(found, k)
+		}
+	}
+	return found
+}
+
+// KeyNames lists the names of keys matching the query, suitable for a select widget
+func (s *FioSettings) KeyNames(query string) []string {
+	names := make([]string, 0)
+	for _, k := range s.SearchKeys(query) {
+		names = append(names, k.Name)
+	}
+	return names
+}
+
+// AddKey appends a key to the keyring, the name is made unique if it is already in use.
+func (s *FioSettings) AddKey(name string, wif string, tags []string) *SavedKey {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		name = "key"
+	}
+	unique := name
+	for i := 2; s.FindKey(unique) != nil; i++ {
+		unique = fmt.Sprintf("%s (%d)", name, i)
+	}
+	k := &SavedKey{Name: unique, Wif: wif, Tags: tags}
+	s.Keys = append(s.Keys, k)
+	return k
+}
+
+// DeleteKey removes the key at index i
+func (s *FioSettings) DeleteKey(i int) bool {
+	if i < 0 || i >= len(s.Keys) {
+		return false
+	}
+	s.Keys = append(s.Keys[:i], s.Keys[i+1:]...)
+	return true
+}
+
+// MoveKey swaps the key at index i with its neighbor, a negative delta moves it towards the top
+func (s *FioSettings) MoveKey(i int, delta int) bool {
+	j := i + delta
+	if i < 0 || j < 0 || i >= len(s.Keys) || j >= len(s.Keys) {
+		return false
+	}
+	s.Keys[i], s.Keys[j] = s.Keys[j], s.Keys[i]
+	return true
+}
+
+// migrateKeys moves the four legacy quick-load slots from older settings files into the keyring,
+// returns true if anything was moved.
+func (s *FioSettings) migrateKeys() bool {
+	legacy := []struct {
+		wif  *string
+		desc *string
+	}{
+		{&s.DefaultKey, &s.DefaultKeyDesc},
+		{&s.FavKey2, &s.FavKey2Desc},
+		{&s.FavKey3, &s.FavKey3Desc},
+		{&s.FavKey4, &s.FavKey4Desc},
+	}
+	var moved bool
+	for i, l := range legacy {
+		if *l.wif != "" {
+			name := *l.desc
+			if name == "" {
+				name = fmt.Sprintf("key %d", i+1)
+			}
+			// the old default key stays the default by going to the top of the list
+			if i == 0 {
+				s.Keys = append([]*SavedKey{{Name: name, Wif: *l.wif, Tags: []string{}}}, s.Keys...)
+			} else {
+				s.AddKey(name, *l.wif, []string{})
+			}
+			moved = true
+		}
+		*l.wif, *l.desc = "", ""
+	}
+	return moved
+}
@@ -0,0 +1,81 @@
+package cryptonym
+
+import (
+	"errors"
+	"github.com/blockpane/cryptonym/engine"
+	"github.com/fioprotocol/fio-go"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// loadProfile reads the load test entries, any of them can be empty
+func loadProfile(tps string, ramp string, duration string, budget string) (engine.LoadProfile, error) {
+	p := engine.LoadProfile{}
+	var err error
+	if s := strings.TrimSpace(tps); s != "" {
+		if p.Tps, err = strconv.ParseFloat(s, 64); err != nil || p.Tps < 0 {
+			return p, errors.New("invalid tps: " + tps)
+		}
+	}
+	if p.Ramp, err = engine.ParseRamp(ramp, p.Tps); err != nil {
+		return p, err
+	}
+	if s := strings.TrimSpace(duration); s != "" {
+		if p.Duration, err = time.ParseDuration(s); err != nil {
+			return p, errors.New("invalid duration, use something like 90s or 1h30m: " + duration)
+		}
+	}
+	if s := strings.TrimSpace(budget); s != "" {
+		f, err := strconv.ParseFloat(s, 64)
+		if err != nil || f < 0 {
+			return p, errors.New("invalid budget: " + budget)
+		}
+		p.Budget = fio.Tokens(f)
+	}
+	return p, nil
+}
+
+// loadBalance is the account's available balance in SUFs, locked tokens can't pay fees
+func loadBalance(api *fio.API, account *fio.Account) func() (uint64, error) {
+	return func() (uint64, error) {
+		b, err := api.GetFioBalance(account.PubKey)
+		if err != nil {
+			return 0, err
+		}
+		return b.Available, nil
+	}
+}
+
+// loadFee estimates what one transaction from the results window costs. Actions without a fee are free, and a
+// proposal is charged the propose fee instead of its actions. The propose fee is per KB, so big proposals are
+// under estimated until the next balance read catches up.
+func loadFee(api *fio.API, account *fio.Account, compose *engine.Composition, msig bool) func() (uint64, error) {
+	return func() (uint64, error) {
+		if msig {
+			return Fees.Fee(api, fio.FeeMsigPropose, Fees.PayerAddress(api, account.Actor))
+		}
+		FormState.mux.RLock()
+		actions := []engine.Composed{{Action: &engine.Action{Action: FormState.Action}}}
+		FormState.mux.RUnlock()
+		if compose != nil {
+			actions = compose.Actions()
+		}
+		var total uint64
+		for _, a := range actions {
+			payer := account.Actor
+			if len(a.Authorization) > 0 {
+				payer = a.Authorization[0].Actor
+			}
+			fee, err := Fees.ForAction(api, a.Action.Action, Fees.PayerAddress(api, payer))
+			if errors.Is(err, engine.ErrNoFee) {
+				continue
+			}
+			if err != nil {
+				return 0, err
+			}
+			total += fee
+		}
+		return total, nil
+	}
+}
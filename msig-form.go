@@ -43,7 +43,7 @@ func UpdateAuthContent(container chan fyne.Container, api *fio.API, opts *fio.Tx
 		accountEntry := widget.NewEntry()
 		newAccount := &fio.Account{}
 		update := &widget.TabItem{}
-		fee := widget.NewLabelWithStyle(p.Sprintf("Required Fee: %s %G", fio.FioSymbol, fio.GetMaxFee(fio.FeeAuthUpdate)*2.0), fyne.TextAlignTrailing, fyne.TextStyle{})
+		fee := widget.NewLabelWithStyle(p.Sprintf("Required Fee: %s %G", fio.FioSymbol, liveFee(api, fio.FeeAuthUpdate)*2.0), fyne.TextAlignTrailing, fyne.TextStyle{})
 		warning := widget.NewHBox(
 			widget.NewIcon(theme.WarningIcon()),
 			widget.NewLabelWithStyle("Warning: converting active account to multi-sig!", fyne.TextAlignTrailing, fyne.TextStyle{Bold: true}),
@@ -53,12 +53,12 @@ func UpdateAuthContent(container chan fyne.Container, api *fio.API, opts *fio.Tx
 			if b {
 				newAccount, _ = fio.NewRandomAccount()
 				accountEntry.SetText(string(newAccount.Actor))
-				fee.SetText(p.Sprintf("Required Fee: %s%G", fio.FioSymbol, fio.GetMaxFee(fio.FeeAuthUpdate)*2.0+fio.GetMaxFee(fio.FeeTransferTokensPubKey)))
+				fee.SetText(p.Sprintf("Required Fee: %s%G", fio.FioSymbol, liveFee(api, fio.FeeAuthUpdate)*2.0+liveFee(api, fio.FeeTransferTokensPubKey)))
 				fee.Refresh()
 				warning.Hide()
 			} else {
 				accountEntry.SetText(string(account.Actor))
-				fee.SetText(p.Sprintf("Required Fee: %s%G", fio.FioSymbol, fio.GetMaxFee(fio.FeeAuthUpdate)*2.0))
+				fee.SetText(p.Sprintf("Required Fee: %s%G", fio.FioSymbol, liveFee(api, fio.FeeAuthUpdate)*2.0))
 				fee.Refresh()
 				warning.Show()
 			}
@@ -165,13 +165,14 @@ func UpdateAuthContent(container chan fyne.Container, api *fio.API, opts *fio.Tx
 				errs.ErrChan <- "Invalid threshold, refusing to continue"
 				return
 			}
-			ok, info, err := updateAuthResult(acc, signerSlice, t)
+			ok, info, err := updateAuthResult(acc, signerSlice, t, newRandCheck.Checked)
 			if ok {
 				dialog.ShowCustom("Success", "OK", info, Win)
 				return
 			}
 			dialog.ShowError(err, Win)
 		})
+		disableWatchOnly(submitButton)
 
 		update = widget.NewTabItem("Update Auth",
 			widget.NewScrollContainer(
@@ -186,7 +187,7 @@ func UpdateAuthContent(container chan fyne.Container, api *fio.API, opts *fio.Tx
 					signerGroup,
 					layout.NewSpacer(),
 					widget.NewHBox(layout.NewSpacer(), addSigner, resetSigners, layout.NewSpacer(), warning, fee, submitButton, layout.NewSpacer()),
-					widget.NewLabel(""),
+					watchOnlyNotice(),
 				),
 			))
 		tabs := widget.NewTabContainer(MsigRequestsContent(api, opts, account), update)
@@ -286,12 +287,16 @@ func fundRandMsig(msig *fio.Account, funder *fio.Account, count int, api *fio.AP
 		feeMultGuess = 1
 	}
 	if feeMultGuess > 1.0 {
-		errs.ErrChan <- fmt.Sprintf("NOTE: fees are increased due to number of signers, transaction will be %s %g", fio.FioSymbol, fio.GetMaxFee(fio.FeeAuthUpdate)*feeMultGuess*2)
+		errs.ErrChan <- fmt.Sprintf("NOTE: fees are increased due to number of signers, transaction will be %s %g", fio.FioSymbol, liveFee(api, fio.FeeAuthUpdate)*feeMultGuess*2)
 	}
 	errs.ErrChan <- "creating new msig account, sending funds, please wait"
+	if err = applySigner(api, funder); err != nil {
+		errs.ErrChan <- "Could not fund new msig account: " + err.Error()
+		return false, err
+	}
 	resp, err := api.SignPushTransaction(
 		fio.NewTransaction(
-			[]*fio.Action{fio.NewTransferTokensPubKey(funder.Actor, msig.PubKey, fio.Tokens((fio.GetMaxFee(fio.FeeAuthUpdate)*feeMultGuess*2.0)+fio.GetMaxFee(fio.FeeTransferTokensPubKey)))},
+			[]*fio.Action{fio.NewTransferTokensPubKey(funder.Actor, msig.PubKey, fio.Tokens((liveFee(api, fio.FeeAuthUpdate)*feeMultGuess*2.0)+liveFee(api, fio.FeeTransferTokensPubKey)))},
 			opts,
 		), opts.ChainID, fio.CompressionNone,
 	)
@@ -356,7 +361,9 @@ func getRequestsForAccount(actor string, limit int, lowerLimit string, account *
 	return nil, nil
 }
 
-func updateAuthResult(account *fio.Account, signers []signer, threshold int) (ok bool, result *widget.Box, err error) {
+// updateAuthResult converts the account to msig, localKey should be set for a newly generated account, otherwise
+// the signer from the settings is used.
+func updateAuthResult(account *fio.Account, signers []signer, threshold int, localKey bool) (ok bool, result *widget.Box, err error) {
 	ok, activePermLevel, _ := checkSigners(signers, "active")
 	ok, ownerPermLevel, _ := checkSigners(signers, "owner")
 	if !ok {
@@ -369,6 +376,12 @@ func updateAuthResult(account *fio.Account, signers []signer, threshold int) (ok
 		return false, nil, errors.New("update auth Failed, could not connect to server")
 	}
 	a.Header.Set("User-Agent", "fio-cryptonym-wallet")
+	if !localKey {
+		if e = applySigner(a, account); e != nil {
+			errs.ErrChan <- "Could not update auth: " + e.Error()
+			return false, nil, errors.New("Update Auth Failed: " + e.Error())
+		}
+	}
 	feeMultGuess := float64(42*len(activePermLevel)) / 1000.0
 	feeMultGuess = math.Ceil(feeMultGuess)
 	if feeMultGuess < 1 {
@@ -382,7 +395,7 @@ func updateAuthResult(account *fio.Account, signers []signer, threshold int) (ok
 			Threshold: uint32(threshold),
 			Accounts:  activePermLevel,
 		},
-		MaxFee: fio.Tokens(fio.GetMaxFee(fio.FeeAuthUpdate) * feeMultGuess),
+		MaxFee: fio.Tokens(liveFee(a, fio.FeeAuthUpdate) * feeMultGuess),
 	})
 	buf := bytes.NewBuffer(nil)
 	updateOwner := fio.NewActionWithPermission("eosio", "updateauth", account.Actor, "owner", fio.UpdateAuth{
@@ -393,7 +406,7 @@ func updateAuthResult(account *fio.Account, signers []signer, threshold int) (ok
 			Threshold: uint32(threshold),
 			Accounts:  ownerPermLevel,
 		},
-		MaxFee: fio.Tokens(fio.GetMaxFee(fio.FeeAuthUpdate) * feeMultGuess),
+		MaxFee: fio.Tokens(liveFee(a, fio.FeeAuthUpdate) * feeMultGuess),
 	})
 	_, tx, e := a.SignTransaction(
 		fio.NewTransaction(
@@ -402,16 +415,20 @@ func updateAuthResult(account *fio.Account, signers []signer, threshold int) (ok
 	)
 	if e != nil {
 		errs.ErrChan <- e.Error()
-		errs.ErrChan <- account.KeyBag.Keys[0].String()
-		errs.ErrChan <- "use this private key to recover funds."
+		if localKey {
+			errs.ErrChan <- account.KeyBag.Keys[0].String()
+			errs.ErrChan <- "use this private key to recover funds."
+		}
 		errs.ErrChan <- "Could not update auth for owner, sign transaction failed:"
 		return false, nil, errors.New("Update Auth Failed: " + e.Error())
 	}
 	out, e := a.PushTransactionRaw(tx)
 	if e != nil {
 		errs.ErrChan <- e.Error()
-		errs.ErrChan <- account.KeyBag.Keys[0].String()
-		errs.ErrChan <- "use this private key to recover funds."
+		if localKey {
+			errs.ErrChan <- account.KeyBag.Keys[0].String()
+			errs.ErrChan <- "use this private key to recover funds."
+		}
 		errs.ErrChan <- "Could not update auth for owner, push transaction failed:"
 		return false, nil, errors.New("Update Auth Failed: " + e.Error())
 	}
@@ -30,10 +30,10 @@ func MsigRequestsContent(api *fio.API, opts *fio.TxOptions, account *fio.Account
 
 func requestBox(proposer string, requests []*fio.MsigApprovalsInfo, index int, proposalWindow fyne.Window, api *fio.API, opts *fio.TxOptions, account *fio.Account) fyne.CanvasObject {
 	p := message.NewPrinter(language.AmericanEnglish)
-	aFee := fio.GetMaxFee(fio.FeeMsigApprove)
-	dFee := fio.GetMaxFee(fio.FeeMsigUnapprove)
-	cFee := fio.GetMaxFee(fio.FeeMsigCancel)
-	eFee := fio.GetMaxFee(fio.FeeMsigExec)
+	aFee := liveFee(api, fio.FeeMsigApprove)
+	dFee := liveFee(api, fio.FeeMsigUnapprove)
+	cFee := liveFee(api, fio.FeeMsigCancel)
+	eFee := liveFee(api, fio.FeeMsigExec)
 	proposalHash := eos.Checksum256{}
 
 	refresh := func() {
@@ -46,9 +46,14 @@ func requestBox(proposer string, requests []*fio.MsigApprovalsInfo, index int, p
 	}
 
 	approve := widget.NewButtonWithIcon(p.Sprintf("Approve %s %g", fio.FioSymbol, aFee), theme.ConfirmIcon(), func() {
+		if err := applySigner(api, account); err != nil {
+			errs.ErrChan <- err.Error()
+			resultPopup(err.Error(), proposalWindow)
+			return
+		}
 		_, tx, err := api.SignTransaction(
 			fio.NewTransaction([]*fio.Action{
-				fio.NewMsigApprove(eos.AccountName(proposer), requests[index].ProposalName, account.Actor, proposalHash),
+				withLiveFee(api, fio.NewMsigApprove(eos.AccountName(proposer), requests[index].ProposalName, account.Actor, proposalHash), fio.FeeMsigApprove),
 			}, opts),
 			opts.ChainID, fio.CompressionNone,
 		)
@@ -75,9 +80,14 @@ func requestBox(proposer string, requests []*fio.MsigApprovalsInfo, index int, p
 	})
 	approve.Hide()
 	deny := widget.NewButtonWithIcon(p.Sprintf("Un-Approve %s %g", fio.FioSymbol, dFee), theme.ContentUndoIcon(), func() {
+		if err := applySigner(api, account); err != nil {
+			errs.ErrChan <- err.Error()
+			resultPopup(err.Error(), proposalWindow)
+			return
+		}
 		_, tx, err := api.SignTransaction(
 			fio.NewTransaction([]*fio.Action{
-				fio.NewMsigUnapprove(eos.AccountName(proposer), requests[index].ProposalName, account.Actor),
+				withLiveFee(api, fio.NewMsigUnapprove(eos.AccountName(proposer), requests[index].ProposalName, account.Actor), fio.FeeMsigUnapprove),
 			}, opts),
 			opts.ChainID, fio.CompressionNone,
 		)
@@ -104,9 +114,14 @@ func requestBox(proposer string, requests []*fio.MsigApprovalsInfo, index int, p
 	})
 	deny.Hide()
 	cancel := widget.NewButtonWithIcon(p.Sprintf("Cancel %s %g", fio.FioSymbol, cFee), theme.DeleteIcon(), func() {
+		if err := applySigner(api, account); err != nil {
+			errs.ErrChan <- err.Error()
+			resultPopup(err.Error(), proposalWindow)
+			return
+		}
 		_, tx, err := api.SignTransaction(
 			fio.NewTransaction([]*fio.Action{
-				fio.NewMsigCancel(eos.AccountName(proposer), requests[index].ProposalName, account.Actor),
+				withLiveFee(api, fio.NewMsigCancel(eos.AccountName(proposer), requests[index].ProposalName, account.Actor), fio.FeeMsigCancel),
 			}, opts),
 			opts.ChainID, fio.CompressionNone,
 		)
@@ -127,6 +142,11 @@ func requestBox(proposer string, requests []*fio.MsigApprovalsInfo, index int, p
 	})
 	cancel.Hide()
 	execute := widget.NewButtonWithIcon(p.Sprintf("Execute %s %g", fio.FioSymbol, eFee), fioassets.NewFioLogoResource(), func() {
+		if err := applySigner(api, account); err != nil {
+			errs.ErrChan <- err.Error()
+			resultPopup(err.Error(), proposalWindow)
+			return
+		}
 		_, tx, err := api.SignTransaction(
 			fio.NewTransaction([]*fio.Action{
 				fio.NewMsigExec(eos.AccountName(proposer), requests[index].ProposalName, fio.Tokens(eFee), account.Actor),
@@ -220,9 +240,11 @@ func requestBox(proposer string, requests []*fio.MsigApprovalsInfo, index int, p
 	for _, approver := range requests[index].ProvidedApprovals {
 		approvers[string(approver.Level.Actor)] = true
 	}
+	disableWatchOnly(approve, deny, cancel, execute)
 	approversRows.Append(widget.NewHBox(
 		layout.NewSpacer(), approve, deny, cancel, execute, layout.NewSpacer(),
 	))
+	approversRows.Append(watchOnlyNotice())
 	approversRows.Append(
 		fyne.NewContainerWithLayout(layout.NewGridLayout(3),
 			fyne.NewContainerWithLayout(layout.NewGridLayout(2),
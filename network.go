@@ -0,0 +1,181 @@
+package cryptonym
+
+import (
+	"fmt"
+	"strings"
+)
+
+// KnownChains maps chain IDs to a friendly name, used by the server info tab and for checking network profiles
+var KnownChains = map[string]string{
+	"b20901380af44ef59c5918439a1f9a41d83669020319a80574b804a5f95cbd7e": "FIO Testnet",
+	"21dcae42c0182200e93f954a074011f9048a7624c6fe81d3c9541a614a88bd1c": "FIO Mainnet",
+	"e143d39294a14616dbbee394f1c159a4eb71b656b9ca1094ebf924dc3714d7ae": "Dapix Development Chain",
+}
+
+// ChainName returns the friendly name of a chain, or a shortened chain ID if it isn't known
+func ChainName(chainId string) string {
+	if KnownChains[chainId] != "" {
+		return KnownChains[chainId]
+	}
+	if len(chainId) > 12 {
+		return "unknown chain " + chainId[:12] + "..."
+	}
+	return "unknown chain " + chainId
+}
+
+// NetworkProfile holds the per-network connection settings. DefaultKey is the name of a key in the keyring.
+// An empty ChainId will accept whatever chain the server is on.
+type NetworkProfile struct {
+	Name        string   `json:"name"`
+	Servers     []string `json:"servers"`
+	ChainId     string   `json:"chain_id"`
+	DefaultKey  string   `json:"default_key"`
+	Tpid        string   `json:"tpid"`
+	MsigAccount string   `json:"msig_account"`
+}
+
+// Server is the preferred (first) server for the profile
+func (p *NetworkProfile) Server() string {
+	for _, s := range p.Servers {
+		if s = strings.TrimSpace(s); s != "" {
+			return s
+		}
+	}
+	return ""
+}
+
+// CheckChainId returns an error if the server is on a different chain than the profile expects
+func (p *NetworkProfile) CheckChainId(chainId string) error {
+	if p.ChainId == "" || strings.EqualFold(p.ChainId, chainId) {
+		return nil
+	}
+	return fmt.Errorf("refusing to connect: profile %q expects %s, but the server is on %s",
+		p.Name, ChainName(p.ChainId), ChainName(chainId))
+}
+
+func DefaultProfiles() []*NetworkProfile {
+	return []*NetworkProfile{
+		{
+			Name:        "devnet",
+			Servers:     []string{"http://127.0.0.1:8888"},
+			ChainId:     "e143d39294a14616dbbee394f1c159a4eb71b656b9ca1094ebf924dc3714d7ae",
+			DefaultKey:  "devnet - vote 1",
+			Tpid:        "tpid@blockpane",
+			MsigAccount: "eosio",
+		},
+		{
+			Name:        "testnet",
+			Servers:     []string{"https://testnet.fioprotocol.io"},
+			ChainId:     "b20901380af44ef59c5918439a1f9a41d83669020319a80574b804a5f95cbd7e",
+			MsigAccount: "eosio",
+		},
+		{
+			Name:        "mainnet",
+			Servers:     append([]string{}, MainnetApi...),
+			ChainId:     "21dcae42c0182200e93f954a074011f9048a7624c6fe81d3c9541a614a88bd1c",
+			Tpid:        "tpid@blockpane",
+			MsigAccount: "eosio",
+		},
+		{
+			Name:        "custom",
+			Servers:     []string{},
+			MsigAccount: "eosio",
+		},
+	}
+}
+
+// Profile returns the active network profile, it never returns nil.
+func (s *FioSettings) Profile() *NetworkProfile {
+	if p := s.FindProfile(s.ActiveProfile); p != nil {
+		return p
+	}
+	if len(s.Profiles) > 0 && s.Profiles[0] != nil {
+		return s.Profiles[0]
+	}
+	p := &NetworkProfile{Name: "custom", Servers: []string{}}
+	s.Profiles = append(s.Profiles, p)
+	return p
+}
+
+// FindProfile returns the profile with the given name, or nil
+func (s *FioSettings) FindProfile(name string) *NetworkProfile {
+	for _, p := range s.Profiles {
+		if p != nil && p.Name == name {
+			return p
+		}
+	}
+	return nil
+}
+
+// ProfileNames is used for select widgets
+func (s *FioSettings) ProfileNames() []string {
+	names := make([]string, 0)
+	for _, p := range s.Profiles {
+		if p != nil {
+			names = append(names, p.Name)
+		}
+	}
+	return names
+}
+
+// ProfileKey is the key that should be loaded when the active profile is selected, falling back to the
+// keyring's default if the profile doesn't name one.
+func (s *FioSettings) ProfileKey() *SavedKey {
+	if k := s.FindKey(s.Profile().DefaultKey); k != nil && k.Wif != "" {
+		return k
+	}
+	return s.DefaultSavedKey()
+}
+
+// ConnectTo is the server that should be used after settings are loaded, ok is false if the user asked
+// to defer connecting when unlocking.
+func (s *FioSettings) ConnectTo() (server string, ok bool) {
+	if s.deferConnect {
+		return "", false
+	}
+	if s.serverOverride != "" {
+		return s.serverOverride, true
+	}
+	server = s.Profile().Server()
+	return server, server != ""
+}
+
+// migrateProfiles creates the default profiles for older settings files, the legacy server, tpid, and msig
+// settings are moved into whichever default profile already lists the server, otherwise into custom.
+func (s *FioSettings) migrateProfiles() bool {
+	if len(s.Profiles) > 0 {
+		return false
+	}
+	s.Profiles = DefaultProfiles()
+	s.ActiveProfile = "devnet"
+	if s.Server == "" {
+		return true
+	}
+	target := s.FindProfile("custom")
+	servers := []string{s.Server}
+find:
+	for _, p := range s.Profiles {
+		for i, server := range p.Servers {
+			if strings.TrimRight(s.Server, "/") == server {
+				// keep the legacy server as the preferred one
+				target = p
+				servers = append(servers, p.Servers[:i]...)
+				servers = append(servers, p.Servers[i+1:]...)
+				break find
+			}
+		}
+	}
+	target.Servers = servers
+	if s.Tpid != "" {
+		target.Tpid = s.Tpid
+	}
+	if s.MsigAccount != "" {
+		target.MsigAccount = s.MsigAccount
+	}
+	if k := s.DefaultSavedKey(); k != nil {
+		target.DefaultKey = k.Name
+	}
+	s.ActiveProfile = target.Name
+	s.Server, s.Tpid, s.MsigAccount = "", "", ""
+	return true
+}
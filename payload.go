@@ -1,529 +1,167 @@
 package cryptonym
 
 import (
-	"bytes"
-	"encoding/base64"
-	"encoding/hex"
 	"encoding/json"
-	"errors"
-	"fmt"
-	errs "github.com/blockpane/cryptonym/errLog"
-	"github.com/blockpane/cryptonym/fuzzer"
+	"github.com/blockpane/cryptonym/engine"
 	"github.com/fioprotocol/fio-go"
 	"github.com/fioprotocol/fio-go/eos"
-	"math"
-	"math/rand"
-	"strconv"
-	"strings"
-	"time"
+	"github.com/fioprotocol/fio-go/eos/ecc"
 )
 
+// field copies the widget state for a row, the caller holds the read lock
+func (row *AbiFormItem) field() engine.Field {
+	f := engine.Field{Name: *row.Name}
+	if row.Type != nil {
+		f.Type = row.Type.Selected
+	}
+	if row.SendAs != nil {
+		f.SendAs = row.SendAs.Selected
+	}
+	if row.Variation != nil {
+		f.Variation = row.Variation.Selected
+	}
+	if row.Len != nil {
+		f.Len = row.Len.Selected
+	}
+	if row.Input != nil {
+		f.Input = row.Input.Text
+	}
+	return f
+}
+
 // TODO: rethink this, instead of calling this every request, maybe pass pointers to functions?
 func (abi *Abi) GeneratePayloads(key *fio.Account) error {
 	abi.mux.RLock()
-	defer abi.mux.RUnlock()
-
-	for i := 0; i < len(abi.Rows); i++ {
-		form := &abi.Rows[i]
-		err := func() error {
-			var v interface{}
-			v = nil
-			isSlice := false
-			if strings.HasSuffix(form.Type.Selected, `[]`) {
-				isSlice = true
-			}
-			switch form.SendAs.Selected {
-
-			case "form value":
-				switch form.Variation.Selected {
-				case "as is":
-					if strings.Contains(form.Type.Selected, "int") {
-						t, e := strconv.ParseInt(form.Input.Text, 10, 64)
-						if e != nil {
-							return errors.New(*form.Name + ": " + e.Error())
-						}
-						abi.mux.RUnlock()
-						FormState.UpdateValue(&i, t, isSlice, true)
-						abi.mux.RLock()
-						return nil
-					} else if strings.Contains(form.Type.Selected, "float") {
-						t, e := strconv.ParseFloat(form.Input.Text, 64)
-						if e != nil {
-							return errors.New(*form.Name + ": " + e.Error())
-						}
-						abi.mux.RUnlock()
-						FormState.UpdateValue(&i, t, isSlice, true)
-						abi.mux.RLock()
-						return nil
-					}
-					v = form.Input.Text
-					abi.mux.RUnlock()
-					FormState.UpdateValue(&i, v, isSlice, false)
-					abi.mux.RLock()
-					return nil
-				case "FIO -> suf":
-					unFriendly := strings.ReplaceAll(strings.ReplaceAll(form.Input.Text, ",", ""), "_", "")
-					f, e := strconv.ParseFloat(unFriendly, 64)
-					if e != nil {
-						return errors.New(*form.Name + ": " + e.Error())
-					}
-					t := uint64(f * 1_000_000_000.0)
-					abi.mux.RUnlock()
-					FormState.UpdateValue(&i, t, isSlice, true)
-					abi.mux.RLock()
-					return nil
-				case "json -> struct":
-					v = form.Input.Text
-					abi.mux.RUnlock()
-					FormState.UpdateValue(&i, v, false, true)
-					abi.mux.RLock()
-					return nil
-				case "hex -> byte[]":
-					h, e := hex.DecodeString(form.Input.Text)
-					if e != nil {
-						return errors.New(*form.Name + ": " + e.Error())
-					}
-					abi.mux.RUnlock()
-					FormState.UpdateValue(&i, h, isSlice, false)
-					abi.mux.RLock()
-					return nil
-				case "base64 -> byte[]":
-					buf := bytes.NewReader([]byte(form.Input.Text))
-					b64 := base64.NewDecoder(base64.StdEncoding, buf)
-					b := make([]byte, 0)
-					_, e := b64.Read(b)
-					if e != nil {
-						return errors.New(*form.Name + ": " + e.Error())
-					}
-					abi.mux.RUnlock()
-					FormState.UpdateValue(&i, b, isSlice, false)
-					abi.mux.RLock()
-					return nil
-				case "checksum256":
-					v = fuzzer.ChecksumOf(form.Input.Text)
-					abi.mux.RUnlock()
-					FormState.UpdateValue(&i, v, isSlice, false)
-					abi.mux.RLock()
-					return nil
-				case "signature":
-					v = fuzzer.SignatureFor(form.Input.Text, key)
-					abi.mux.RUnlock()
-					FormState.UpdateValue(&i, v, isSlice, false)
-					abi.mux.RLock()
-					return nil
-				case "fio address@ (valid)":
-					v = fuzzer.FioAddressAt(form.Input.Text)
-					abi.mux.RUnlock()
-					FormState.UpdateValue(&i, v, isSlice, false)
-					abi.mux.RLock()
-				case "fio address@ (valid, max size)":
-					v = fuzzer.MaxRandomFioAddressAt(form.Input.Text)
-					abi.mux.RUnlock()
-					FormState.UpdateValue(&i, v, isSlice, false)
-					abi.mux.RLock()
-				case "fio address@ (invalid)":
-					v = fuzzer.InvalidFioAddressAt(form.Input.Text)
-					abi.mux.RUnlock()
-					FormState.UpdateValue(&i, v, isSlice, false)
-					abi.mux.RLock()
-				}
-
-			case "actor":
-				switch form.Variation.Selected {
-				case "mine":
-					v = key.Actor
-					abi.mux.RUnlock()
-					FormState.UpdateValue(&i, v, isSlice, false)
-					abi.mux.RLock()
-					return nil
-				case "random":
-					v = fuzzer.RandomActor()
-					abi.mux.RUnlock()
-					FormState.UpdateValue(&i, v, isSlice, false)
-					abi.mux.RLock()
-					return nil
-				}
-
-			case "pub key":
-				switch form.Variation.Selected {
-				case "mine":
-					v = key.PubKey
-					abi.mux.RUnlock()
-					FormState.UpdateValue(&i, v, isSlice, false)
-					abi.mux.RLock()
-					return nil
-				case "random":
-					v = fuzzer.RandomFioPubKey()
-					abi.mux.RUnlock()
-					FormState.UpdateValue(&i, v, isSlice, false)
-					abi.mux.RLock()
-					return nil
-				}
-
-			case "fio types":
-				switch form.Variation.Selected {
-				// TODO:
-				//case "random array of existing fio address":
-				case "max length: addaddress.public_addresses":
-					v = fuzzer.MaxAddPubAddress()
-					fmt.Println(v)
-					abi.mux.RUnlock()
-					FormState.UpdateValue(&i, v, false, true)
-					abi.mux.RLock()
-					return nil
-				case "max length: voteproducer.producers":
-					v = fuzzer.MaxVoteProducers(Uri)
-					fmt.Println(v)
-					abi.mux.RUnlock()
-					FormState.UpdateValueWithConvert(&i, v, false, "string[]", false)
-					abi.mux.RLock()
-					return nil
-				case "variable length: addaddress.public_addresses":
-					var payloadLen int
-					var err error
-					if form.Len.Selected == "" {
-						payloadLen = 1
-						form.Len.SetSelected("1")
-					} else {
-						payloadLen, err = strconv.Atoi(form.Len.Selected)
-						if err != nil {
-							payloadLen = 1
-						}
-					}
-					v = fuzzer.RandomAddAddress(payloadLen)
-					abi.mux.RUnlock()
-					FormState.UpdateValue(&i, v, false, true)
-					abi.mux.RLock()
-					return nil
-				case "invalid fio domain":
-					v = fuzzer.InvalidFioDomain()
-				case "valid fio domain (max size)":
-					v = fuzzer.MaxRandomFioDomain()
-				case "valid fio domain":
-					v = fuzzer.FioDomain()
-				case "max length: newfundsreq.content":
-					v = fuzzer.MaxNewFundsContent()
-				case "max length: recordobt.content":
-					v = fuzzer.MaxRecObtContent()
-				case "max length: regproducer.url":
-					v = fuzzer.MaxProducerUrl()
-				case "random existing fio address":
-					v = fuzzer.RandomExistingFioAddress(Uri)
-				}
-				abi.mux.RUnlock()
-				FormState.UpdateValue(&i, v, isSlice, false)
-				abi.mux.RLock()
-				return nil
-
-			case "number":
-				var l int
-				var e error
-				if form.Variation.Selected != "random number (mixed)" && form.Variation.Selected != "max int" {
-					if form.Len.Selected == "" {
-						return errors.New(*form.Name + ": no number specified")
-					}
-					l, e = strconv.Atoi(form.Len.Selected)
-					if e != nil {
-						return errors.New(*form.Name + ": " + e.Error())
-					}
-				}
-				var noJsonEscape = true
-				switch form.Variation.Selected {
-				case "max int":
-					abiType := form.Len.Selected
-					if form.Type.Selected == "string" {
-						abiType = "string"
-						noJsonEscape = false
-					}
-					v = fuzzer.MaxInt(form.Len.Selected)
-					abi.mux.RUnlock()
-					//FormState.UpdateValue(&i, v, false, true)
-					FormState.UpdateValueWithConvert(&i, v, isSlice, abiType, noJsonEscape)
-					abi.mux.RLock()
-					return nil
-				case "incrementing float":
-					abiType := "float64"
-					if form.Type.Selected == "string" {
-						abiType = "string"
-						noJsonEscape = false
-					}
-					v = fuzzer.IncrementingFloat()
-					abi.mux.RUnlock()
-					FormState.UpdateValueWithConvert(&i, v, isSlice, abiType, noJsonEscape)
-					abi.mux.RLock()
-					return nil
-				case "incrementing int":
-					abiType := "int64"
-					if form.Type.Selected == "string" {
-						abiType = "string"
-						noJsonEscape = false
-					}
-					v = fuzzer.IncrementingInt()
-					abi.mux.RUnlock()
-					FormState.UpdateValueWithConvert(&i, v, isSlice, abiType, noJsonEscape)
-					abi.mux.RLock()
-					return nil
-				case "random float":
-					abiType := fmt.Sprintf("float%d", l)
-					if form.Type.Selected == "string" {
-						abiType = "string"
-						noJsonEscape = false
-					}
-					v = fuzzer.RandomFloat(l)
-					abi.mux.RUnlock()
-					FormState.UpdateValueWithConvert(&i, v, isSlice, abiType, noJsonEscape)
-					abi.mux.RLock()
-					return nil
-				case "random int":
-					switch l {
-					case 128:
-						v = fuzzer.RandomInt128()
-						abi.mux.RUnlock()
-						FormState.UpdateValueWithConvert(&i, v, isSlice, "string", false)
-					default:
-						abiType := fmt.Sprintf("int%d", l)
-						if form.Type.Selected == "string" {
-							abiType = "string"
-							noJsonEscape = false
-						}
-						v = fuzzer.RandomInteger(l).Interface()
-						abi.mux.RUnlock()
-						FormState.UpdateValueWithConvert(&i, v, isSlice, abiType, noJsonEscape)
-					}
-					abi.mux.RLock()
-					return nil
-				case "overflow int":
-					abiType := "uint64"
-					if form.Type.Selected == "string" {
-						abiType = "string"
-						noJsonEscape = false
-					}
-					signed := false
-					if strings.HasPrefix(form.Type.Selected, "int") {
-						signed = true
-					}
-					v = fuzzer.OverFlowInt(l, signed)
-					abi.mux.RUnlock()
-					FormState.UpdateValueWithConvert(&i, v, isSlice, abiType, noJsonEscape)
-					abi.mux.RLock()
-					return nil
-				case "random number (mixed)":
-					rn := fuzzer.RandomNumber()
-					abiType := rn.AbiType()
-					if form.Type.Selected == "string" {
-						abiType = "string"
-						noJsonEscape = false
-					}
-					abi.mux.RUnlock()
-					FormState.UpdateValueWithConvert(&i, rn.String(), false, abiType, noJsonEscape)
-					abi.mux.RLock()
-					return nil
-				}
-
-			case "bytes/string":
-				var hasLen bool
-				var payloadLen int
-				if form.Len.Selected != "" {
-					hasLen = true
-					e := func() error {
-						if form.Len.Selected == "random length" {
-							payloadLen = rand.Intn(math.MaxInt16 + 8)
-							return nil
-						}
-						var e error
-						payloadLen, e = strconv.Atoi(strings.ReplaceAll(form.Len.Selected, ",", ""))
-						if e != nil {
-							return errors.New(*form.Name + ": invalid number for payload length")
-						}
-						return nil
-					}()
-					if e != nil {
-						return e
-					}
-				}
-				lenError := func() error {
-					return errors.New(*form.Name + ": no length specified for random payload")
-				}
-				switch form.Variation.Selected {
-				case "string":
-					if !hasLen {
-						return lenError()
-					}
-					v = fuzzer.RandomString(payloadLen)
-					abi.mux.RUnlock()
-					FormState.UpdateValue(&i, v, isSlice, false)
-					abi.mux.RLock()
-					return nil
-				case "bytes":
-					if !hasLen {
-						return lenError()
-					}
-					v = fuzzer.RandomBytes(payloadLen, fuzzer.EncodeRaw)
-					abi.mux.RUnlock()
-					FormState.UpdateValue(&i, v, isSlice, false)
-					abi.mux.RLock()
-					return nil
-				case "bytes: hex encoded":
-					if !hasLen {
-						return lenError()
-					}
-					v = fuzzer.RandomBytes(payloadLen, fuzzer.EncodeHexString)
-					abi.mux.RUnlock()
-					FormState.UpdateValue(&i, v, isSlice, false)
-					abi.mux.RLock()
-					return nil
-				case "bytes: base64 encoded":
-					if !hasLen {
-						return lenError()
-					}
-					v = fuzzer.RandomBytes(payloadLen, fuzzer.EncodeBase64)
-					abi.mux.RUnlock()
-					FormState.UpdateValue(&i, v, isSlice, false)
-					abi.mux.RLock()
-					return nil
-				case "random checksum":
-					v = fuzzer.RandomChecksum()
-					abi.mux.RUnlock()
-					FormState.UpdateValue(&i, v, isSlice, false)
-					abi.mux.RLock()
-					return nil
-				}
-
-			case "load file":
-				return errors.New("load file is not implemented yet")
+	fields := make([]engine.Field, len(abi.Rows))
+	for i := range abi.Rows {
+		fields[i] = abi.Rows[i].field()
+	}
+	def := abi.Def
+	abi.mux.RUnlock()
 
-			default:
-				return errors.New("unknown generator provided")
-			}
-			return nil
-		}()
-		if err != nil {
+	for i := range fields {
+		v, err := fields[i].GenerateWith(key, Uri, def)
+		switch {
+		case err == engine.ErrUnknownVariation:
+			continue
+		case err != nil:
 			return err
+		case v.Convert:
+			abi.UpdateValueWithConvert(&i, v.V, v.IsSlice, v.AbiType, v.NoJsonEscape)
+		default:
+			abi.UpdateValue(&i, v.V, v.IsSlice, v.NoJsonEscape)
 		}
 	}
 	return nil
 }
 
+// payload is the last generated value for each row, with the type overrides applied
+func (abi *Abi) payload() *engine.Payload {
+	p := &engine.Payload{
+		Contract: abi.Contract,
+		Action:   abi.Action,
+		Fields:   make([]engine.Field, len(abi.Rows)),
+		Values:   make([]engine.Value, len(abi.Rows)),
+	}
+	for i := range abi.Rows {
+		row := &abi.Rows[i]
+		p.Fields[i] = row.field()
+		if row.typeOverride != "" {
+			p.Fields[i].Type = row.typeOverride
+		}
+		p.Values[i] = engine.Value{IsSlice: row.IsSlice, NoJsonEscape: row.noJsonEscape}
+		if row.Value != nil {
+			p.Values[i].V = *row.Value
+		}
+	}
+	return p
+}
+
 func (abi *Abi) PackAndSign(api *fio.API, opts *fio.TxOptions, account *fio.Account, msig bool) (json.RawMessage, *eos.PackedTransaction, error) {
-	if len(abi.Rows) == 0 {
+	p := abi.generated()
+	if p == nil {
 		return nil, nil, nil
 	}
+	return packPayload(api, opts, account, p, msig)
+}
+
+// generated is a copy of the last values from GeneratePayloads, nil if the form is empty
+func (abi *Abi) generated() *engine.Payload {
 	abi.mux.RLock()
 	defer abi.mux.RUnlock()
-
-	// build a json string
-	jsonString := "{"
-	for i, row := range abi.Rows {
-		if row.convert == nil {
-			row.convert = func(s interface{}) interface{} {
-				return s
-			}
-		}
-		b := make([]byte, 0)
-		var err error
-		switch {
-		case !row.IsSlice && !row.noJsonEscape:
-			b, err = json.Marshal(row.convert(row.Value))
-			if err != nil {
-				errs.ErrChan <- err.Error()
-			}
-		case !row.IsSlice && row.noJsonEscape:
-			b = []byte(fmt.Sprintf("%v", *row.Value))
-		//FIXME, this is converting everything to string arrays
-		case row.IsSlice:
-			payloads := func() []string {
-				p := strings.Split(fmt.Sprintf("%v", row.Input.Text), ",")
-				s := make([]string, len(p))
-				for i := range p {
-					s[i] = strings.TrimSpace(p[i])
-				}
-				return s
-			}()
-			b, err = json.Marshal(payloads)
-			if err != nil {
-				errs.ErrChan <- err.Error()
-			}
-		}
-		jsonString = jsonString + fmt.Sprintf(`"%s":%s`, *row.Name, string(b))
-		if i < len(abi.Rows)-1 {
-			jsonString = jsonString + ","
-		}
+	if len(abi.Rows) == 0 {
+		return nil
 	}
-	jsonString = jsonString + "}"
+	return abi.payload()
+}
 
-	rawJ := json.RawMessage([]byte(jsonString))
-	// make sure we can marshall the json we just created ...
-	if len(jsonString) >= 524288 {
-		rawJ = []byte(fmt.Sprintf(`{"message":"Not showing request: %d bytes is too large"}"`, len(jsonString)))
+// needsChainState is true if the editor, or the composition if set, has a field with an on chain fio type
+func needsChainState(compose *engine.Composition) bool {
+	fields := make([]engine.Field, 0)
+	if compose == nil {
+		fields = FormState.Fields()
 	} else {
-		err := json.Unmarshal([]byte(jsonString), &rawJ)
-		if err != nil {
-			return nil, nil, errors.New("could not marshal new data into json: " + err.Error())
+		for _, c := range compose.Actions() {
+			fields = append(fields, c.Action.Fields...)
 		}
 	}
-
-	// get the "real" abi, and we will update it with any changes:
-	newAbi, err := api.GetABI(eos.AccountName(abi.Rows[0].Contract))
-	if err != nil {
-		return nil, nil, err
-	}
-	newStructBytes := abi.DeriveJsonAbi()
-	newDef := eos.StructDef{}
-	err = json.Unmarshal(newStructBytes, &newDef)
-	for i, def := range newAbi.ABI.Structs {
-		if def.Name == abi.Action {
-			newAbi.ABI.Structs[i] = newDef
-			break
+	for _, f := range fields {
+		if f.SendAs == "fio types" {
+			return true
 		}
 	}
+	return false
+}
 
-	encoded, err := newAbi.ABI.EncodeAction(eos.ActionName(abi.Action), []byte(jsonString))
-	if err != nil {
-		return nil, nil, err
-	}
+// Fields are the names and types in the editor, including any changed types
+func (abi *Abi) Fields() []engine.Field {
+	abi.mux.RLock()
+	defer abi.mux.RUnlock()
+	return abi.payload().Fields
+}
 
-	actionData := eos.NewActionData(nil)
-	actionData.HexData = encoded
-	var finalActor string
-	for _, r := range abi.Rows {
-		if *r.Name == "actor" {
-			finalActor = fmt.Sprintf("%v", *r.Value)
-		}
+// packComposition is packPayload for the composer's actions
+func packComposition(api *fio.API, opts *fio.TxOptions, account *fio.Account, payloads []*engine.Payload, msig bool) (json.RawMessage, *eos.PackedTransaction, error) {
+	po := engine.PackOptions{Compress: useZlib, Msig: msig}
+	if deferTx {
+		po.DelaySecs = uint32(delayTxSec)
 	}
-	if finalActor == "" {
-		finalActor = string(account.Actor)
+	if err := applySigner(api, account); err != nil {
+		return nil, nil, err
 	}
-	action := &fio.Action{
-		Account: eos.AccountName(abi.Contract),
-		Name:    eos.ActionName(abi.Action),
-		Authorization: []eos.PermissionLevel{
-			{
-				Actor:      eos.AccountName(finalActor),
-				Permission: "active",
-			},
-		},
-		ActionData: actionData,
+	return engine.PackActions(api, opts, account, payloads, po)
+}
+
+// dryRunPayloads is packComposition without signing or sending
+func dryRunPayloads(api *fio.API, opts *fio.TxOptions, account *fio.Account, payloads []*engine.Payload, msig bool) (*engine.DryRun, error) {
+	po := engine.PackOptions{Compress: useZlib, Msig: msig}
+	if deferTx {
+		po.DelaySecs = uint32(delayTxSec)
 	}
-	compression := fio.CompressionNone
-	if useZlib {
-		compression = fio.CompressionZlib
+	// nothing is signed, a watch-only account only needs its public key listed for get_required_keys
+	if WatchOnly {
+		signer, err := newPubKeySigner(account)
+		if err != nil {
+			return nil, err
+		}
+		api.SetSigner(signer)
+		api.SetCustomGetRequiredKeys(func(tx *eos.Transaction) ([]ecc.PublicKey, error) {
+			return signer.AvailableKeys()
+		})
+	} else if err := applySigner(api, account); err != nil {
+		return nil, err
 	}
-	opts.TxOptions.DelaySecs = 0
+	return engine.DryRunActions(api, opts, account, payloads, po)
+}
+
+// packPayload signs with the editor's transaction options
+func packPayload(api *fio.API, opts *fio.TxOptions, account *fio.Account, p *engine.Payload, msig bool) (json.RawMessage, *eos.PackedTransaction, error) {
+	po := engine.PackOptions{Compress: useZlib, Msig: msig}
 	if deferTx {
-		opts.TxOptions.DelaySecs = uint32(delayTxSec)
-	}
-	signMe := fio.NewTransaction([]*fio.Action{action}, opts)
-	if msig {
-		signMe.Expiration.Time = time.Now().Add(time.Hour)
+		po.DelaySecs = uint32(delayTxSec)
 	}
-	_, packedTx, err := api.SignTransaction(
-		signMe,
-		opts.ChainID,
-		compression,
-	)
-	if err != nil {
+	if err := applySigner(api, account); err != nil {
 		return nil, nil, err
 	}
-
-	return rawJ, packedTx, nil
+	return engine.PackAndSign(api, opts, account, p, po)
 }
@@ -1,6 +1,7 @@
 package cryptonym
 
 import (
+	"errors"
 	"fmt"
 	"fyne.io/fyne"
 	"fyne.io/fyne/canvas"
@@ -9,6 +10,7 @@ import (
 	"fyne.io/fyne/theme"
 	"fyne.io/fyne/widget"
 	errs "github.com/blockpane/cryptonym/errLog"
+	"github.com/blockpane/cryptonym/fuzzer"
 	"github.com/fioprotocol/fio-go"
 	"sort"
 	"strconv"
@@ -62,15 +64,21 @@ func GetPending(refreshChan chan bool, account *fio.Account, api *fio.API) (form
 	refr := widget.NewButtonWithIcon("Refresh", theme.ViewRefreshIcon(), func() {
 		refreshChan <- true
 	})
+	disableWatchOnly(sendNew)
 	topDesc := widget.NewLabel("")
 	top := widget.NewHBox(
 		layout.NewSpacer(),
 		topDesc,
 		fyne.NewContainerWithLayout(layout.NewFixedGridLayout(refr.MinSize()), refr),
 		fyne.NewContainerWithLayout(layout.NewFixedGridLayout(sendNew.MinSize()), sendNew),
+		watchOnlyNotice(),
 		layout.NewSpacer(),
 	)
 
+	if account.PubKey == "" {
+		// watch-only actors without a single active key
+		return widget.NewVBox(top, widget.NewLabel("Requests are listed by public key, and "+string(account.Actor)+" does not have one.")), nil
+	}
 	pending, has, err := api.GetPendingFioRequests(account.PubKey, 101, 0)
 	if err != nil {
 		return widget.NewHBox(widget.NewLabel(err.Error())), err
@@ -128,7 +136,11 @@ func GetPending(refreshChan chan bool, account *fio.Account, api *fio.API) (form
 				d.Show()
 			})
 			rejectBtn := widget.NewButtonWithIcon("", theme.DeleteIcon(), func() {
-				_, err := api.SignPushActions(fio.NewRejectFndReq(account.Actor, strconv.FormatUint(req.FioRequestId, 10)))
+				if err := applySigner(api, account); err != nil {
+					errs.ErrChan <- err.Error()
+					return
+				}
+				_, err := api.SignPushActions(withLiveFee(api, fio.NewRejectFndReq(account.Actor, strconv.FormatUint(req.FioRequestId, 10)), fio.FeeRejectFundsRequest))
 				if err != nil {
 					errs.ErrChan <- err.Error()
 					return
@@ -137,17 +149,23 @@ func GetPending(refreshChan chan bool, account *fio.Account, api *fio.API) (form
 				refreshChan <- true
 			})
 			rejectBtn.HideShadow = true
+			disableWatchOnly(rejectBtn)
 			requests.AddObject(widget.NewHBox(view, layout.NewSpacer()))
 			requests.AddObject(id)
 			requests.AddObject(fr)
 			requests.AddObject(to)
-			obt, err := fio.DecryptContent(account, req.PayeeFioPublicKey, req.Content, fio.ObtRequestType)
+			obt, err := decryptContent(account, req.PayeeFioPublicKey, req.Content, fio.ObtRequestType)
 			var summary string
-			if err != nil {
+			switch {
+			case err == errNoLocalKey:
+				// watch-only and remote signers can see the request exists, but not what it is for
+				view.Hide()
+				summary = "encrypted"
+			case err != nil:
 				view.Hide()
 				summary = "invalid content"
 				errs.ErrChan <- err.Error()
-			} else {
+			default:
 				summary = obt.Request.ChainCode
 				if obt.Request.ChainCode != obt.Request.TokenCode {
 					summary += "/" + obt.Request.TokenCode
@@ -179,7 +197,7 @@ func ViewRequest(id uint64, closed chan interface{}, refresh chan bool, account
 	if err != nil {
 		return widget.NewLabel(err.Error())
 	}
-	decrypted, err := fio.DecryptContent(account, req.PayeeKey, req.Content, fio.ObtRequestType)
+	decrypted, err := decryptContent(account, req.PayeeKey, req.Content, fio.ObtRequestType)
 	if err != nil {
 		return widget.NewLabel(err.Error())
 	}
@@ -234,7 +252,15 @@ func ViewRequest(id uint64, closed chan interface{}, refresh chan bool, account
 	rejectBtn = widget.NewButtonWithIcon("Reject", theme.DeleteIcon(), func() {
 		rejectBtn.Disable()
 		respondBtn.Disable()
-		resp, err := api.SignPushActions(fio.NewRejectFndReq(account.Actor, strconv.FormatUint(id, 10)))
+		if err := applySigner(api, account); err != nil {
+			errIcon.Show()
+			errMsg.SetText(err.Error())
+			errMsg.Refresh()
+			rejectBtn.Enable()
+			respondBtn.Enable()
+			return
+		}
+		resp, err := api.SignPushActions(withLiveFee(api, fio.NewRejectFndReq(account.Actor, strconv.FormatUint(id, 10)), fio.FeeRejectFundsRequest))
 		if err != nil {
 			errIcon.Show()
 			errMsg.SetText(err.Error())
@@ -247,6 +273,7 @@ func ViewRequest(id uint64, closed chan interface{}, refresh chan bool, account
 		errMsg.SetText("Done. Transaction ID: " + resp.TransactionID)
 		refresh <- true
 	})
+	disableWatchOnly(respondBtn, rejectBtn)
 	buttons := widget.NewVBox(
 		widget.NewHBox(
 			layout.NewSpacer(),
@@ -282,6 +309,10 @@ func RespondRequest(req *fio.FundsReqTableResp, decrypted *fio.ObtRequestContent
 	}
 	bytesRemaining := widget.NewLabel("")
 	remaining := func(l *widget.Label) {
+		if !HasPrivateKey(account) {
+			l.SetText(errNoLocalKey.Error())
+			return
+		}
 		content, err := record.Encrypt(account, req.PayeeKey)
 		if err != nil {
 			l.SetText(err.Error())
@@ -328,12 +359,20 @@ func RespondRequest(req *fio.FundsReqTableResp, decrypted *fio.ObtRequestContent
 	errMsg := widget.NewLabel("")
 	sendResponse := &widget.Button{}
 	sendResponse = widget.NewButtonWithIcon("Send Response", theme.ConfirmIcon(), func() {
+		if !HasPrivateKey(account) {
+			errMsg.SetText("Encrypt response: " + errNoLocalKey.Error())
+			return
+		}
 		content, err := record.Encrypt(account, req.PayeeKey)
 		if err != nil {
 			errMsg.SetText("Encrypt response: " + err.Error())
 			return
 		}
-		resp, err := api.SignPushActions(fio.NewRecordSend(account.Actor, strconv.FormatUint(req.FioRequestId, 10), req.PayerFioAddress, req.PayeeFioAddress, content))
+		if err = applySigner(api, account); err != nil {
+			errMsg.SetText("Signer: " + err.Error())
+			return
+		}
+		resp, err := api.SignPushActions(withLiveFee(api, fio.NewRecordSend(account.Actor, strconv.FormatUint(req.FioRequestId, 10), req.PayerFioAddress, req.PayeeFioAddress, content), fio.FeeRecordObtData))
 		if err != nil {
 			errMsg.SetText("Push Action: " + err.Error())
 			return
@@ -477,13 +516,22 @@ func NewRequest(account *fio.Account, api *fio.API) fyne.CanvasObject {
 			}()
 		}()
 		send.Disable()
+		if !HasPrivateKey(account) {
+			errLabel.SetText(errNoLocalKey.Error())
+			return
+		}
 		content, err = nfr.Encrypt(account, payerPub)
 		if err != nil {
 			errLabel.SetText(err.Error())
 			errs.ErrChan <- err.Error()
 			return
 		}
-		resp, err := api.SignPushActions(fio.NewFundsReq(account.Actor, payerFio, payeeFio, content))
+		if err = applySigner(api, account); err != nil {
+			errLabel.SetText(err.Error())
+			errs.ErrChan <- err.Error()
+			return
+		}
+		resp, err := api.SignPushActions(withLiveFee(api, fio.NewFundsReq(account.Actor, payerFio, payeeFio, content), fio.FeeNewFundsRequest))
 		if err != nil {
 			errLabel.SetText(err.Error())
 			errs.ErrChan <- err.Error()
@@ -524,162 +572,20 @@ func GetTokens(s string) []string {
 	return chainTokens[s]
 }
 
-var chainTokens = map[string][]string{
-	"ABBC": {"ABBC"},
-	"ADA":  {"ADA"},
-	"ALGO": {"ALGO"},
-	"ATOM": {"ATOM"},
-	"BAND": {"BAND"},
-	"BCH": {
-		"BCH",
-		"FLEX",
-	},
-	"BHD": {"BHD"},
-	"BNB": {
-		"ANKR",
-		"BNB",
-		"CHZ",
-		"ERD",
-		"ONE",
-		"RUNE",
-		"SWINGBY",
-	},
-	"BSV":  {"BSV"},
-	"BTC":  {"BTC"},
-	"BTM":  {"BTM"},
-	"CET":  {"CET"},
-	"CHX":  {"CHX"},
-	"CKB":  {"CKB"},
-	"DASH": {"DASH"},
-	"DOGE": {"DOGE"},
-	"DOT":  {"DOT"},
-	"EOS":  {"EOS"},
-	"ETC":  {"ETC"},
-	"ETH": {
-		"AERGO",
-		"AKRO",
-		"ALTBEAR",
-		"ALTBULL",
-		"BAND",
-		"BAT",
-		"BEPRO",
-		"BNBBEAR",
-		"BNBBULL",
-		"BOLT",
-		"BTCBEAR",
-		"BTCBULL",
-		"BTMX",
-		"BVOL",
-		"BXA",
-		"CELR",
-		"CET",
-		"CHR",
-		"COTI",
-		"COVA",
-		"CRO",
-		"CVNT",
-		"DAD",
-		"DEEP",
-		"DIA",
-		"DOS",
-		"DREP",
-		"DUO",
-		"ELF",
-		"EOSBEAR",
-		"EOSBULL",
-		"ETH",
-		"ETHBEAR",
-		"ETHBULL",
-		"EXCHBEAR",
-		"EXCHBULL",
-		"FET",
-		"FRM",
-		"FTM",
-		"FTT",
-		"GEEQ",
-		"GT",
-		"HT",
-		"IBVOL",
-		"INFT",
-		"JRT",
-		"KCS",
-		"LAMB",
-		"LAMBS",
-		"LBA",
-		"LFT",
-		"LINK",
-		"LTCBEAR",
-		"LTCBULL",
-		"LTO",
-		"MATIC",
-		"MITX",
-		"MIX",
-		"OKB",
-		"OLT",
-		"OM",
-		"ORN",
-		"PAX",
-		"PROM",
-		"QCX",
-		"RNT",
-		"SEELE",
-		"SLV",
-		"SRM",
-		"STAKE",
-		"STPT",
-		"SWAP",
-		"TOKO",
-		"UAT",
-		"USDC",
-		"USDT",
-		"VALOR",
-		"VRA",
-		"XRPBEAR",
-		"XRPBULL",
-		"ZRX",
-	},
-	"ETZ": {"ETZ"},
-	"FIAT": {
-		"ACH",
-		"IBAN",
-	},
-	"FIO":  {"FIO"},
-	"FSN":  {"FSN"},
-	"HPB":  {"HPB"},
-	"IOST": {"IOST"},
-	"KAVA": {"KAVA"},
-	"LTC":  {"LTC"},
-	"LTO":  {"LTO"},
-	"MHC":  {"MHC"},
-	"NEO": {
-		"GAS",
-		"NEO",
-	},
-	"OLT":  {"OLT"},
-	"OMNI": {"USDT"},
-	"ONE":  {"ONE"},
-	"ONT": {"ONG",
-		"ONT"},
-	"QTUM": {"QTUM"},
-	"RVN":  {"RVN"},
-	"SOL":  {"SOL"},
-	"TRX": {
-		"BTT",
-		"TRX",
-		"USDT",
-	},
-	"VET": {"VET"},
-	"WAN": {
-		"RVX",
-		"WAN",
-	},
-	"XEM": {"XEM"},
-	"XLM": {"XLM"},
-	"XMR": {"XMR"},
-	"XNS": {"XNS"},
-	"XRP": {"XRP"},
-	"XTZ": {"XTZ"},
-	"YAP": {"YAP"},
-	"ZEC": {"ZEC"},
-	"ZIL": {"ZIL"},
+var chainTokens = fuzzer.ChainTokens
+
+var errNoLocalKey = errors.New("encrypted request content needs a local private key, not available when signing with keosd")
+
+// decryptContent checks for a local key first, fio.DecryptContent will panic on an empty key bag. It also
+// panics on content shorter than the iv and hmac, which anyone can send, so that is recovered.
+func decryptContent(account *fio.Account, pubKey string, content string, obtType fio.ObtType) (result *fio.ObtContentResult, err error) {
+	if !HasPrivateKey(account) {
+		return nil, errNoLocalKey
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			result, err = nil, fmt.Errorf("could not decrypt content: %v", r)
+		}
+	}()
+	return fio.DecryptContent(account, pubKey, content, obtType)
 }
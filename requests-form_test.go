@@ -0,0 +1,25 @@
+package cryptonym
+
+import (
+	"github.com/blockpane/cryptonym/fuzzer"
+	"github.com/fioprotocol/fio-go"
+	"testing"
+)
+
+func TestDecryptHostileContent(t *testing.T) {
+	acc, _ := fio.NewAccountFromWif("5KC6Edd4BcKTLnRuGj2c8TRT9oLuuXLd3ZuCGxM9iNngc3D8S93")
+	for _, kind := range fuzzer.ContentKinds {
+		obt := fio.ObtRequestType
+		if kind == "recordobt" {
+			obt = fio.ObtResponseType
+		}
+		for _, edge := range fuzzer.ContentCases {
+			content, err := fuzzer.Content(acc, "", kind, edge)
+			if err != nil {
+				t.Fatal(kind, edge, err)
+			}
+			// only checking that nothing panics, several of these do decrypt
+			_, _ = decryptContent(acc, acc.PubKey, content, obt)
+		}
+	}
+}
@@ -0,0 +1,121 @@
+package cryptonym
+
+import (
+	"context"
+	"fmt"
+	"fyne.io/fyne"
+	"fyne.io/fyne/dialog"
+	"fyne.io/fyne/layout"
+	"fyne.io/fyne/theme"
+	"fyne.io/fyne/widget"
+	"github.com/blockpane/cryptonym/engine"
+	errs "github.com/blockpane/cryptonym/errLog"
+	"strings"
+	"sync"
+)
+
+// ScenarioTab loads a scenario file and runs it against the connected node with the current account
+func ScenarioTab() *widget.Box {
+	fileEntry := widget.NewEntry()
+	fileEntry.SetPlaceHolder("scenario.yml")
+	output := widget.NewMultiLineEntry()
+	output.Wrapping = fyne.TextWrapOff
+	status := widget.NewLabel("")
+
+	var (
+		mux    sync.Mutex
+		cancel context.CancelFunc
+	)
+	appendOutput := func(s string) {
+		mux.Lock()
+		output.SetText(output.Text + s)
+		mux.Unlock()
+	}
+
+	stop := widget.NewButtonWithIcon("Stop", theme.CancelIcon(), func() {})
+	stop.Disable()
+	run := &widget.Button{}
+	run = widget.NewButtonWithIcon("Run", theme.MediaPlayIcon(), func() {
+		if !Session.Connected() {
+			errs.ErrChan <- "scenario: " + engine.ErrNotConnected.Error()
+			return
+		}
+		sc, err := engine.LoadScenario(strings.TrimSpace(fileEntry.Text))
+		if err != nil {
+			dialog.ShowError(err, Win)
+			return
+		}
+		if err = applySessionSigner(); err != nil {
+			errs.ErrChan <- "scenario: " + err.Error()
+			return
+		}
+		var ctx context.Context
+		ctx, cancel = context.WithCancel(context.Background())
+		run.Disable()
+		stop.Enable()
+		output.SetText("")
+		status.SetText("running " + sc.Name)
+		runner := &engine.Runner{
+			Session:     Session,
+			DefaultBody: DefaultJsonFor,
+			Progress: func(r engine.StepResult) {
+				result := "PASS"
+				if !r.Pass {
+					result = "FAIL"
+				}
+				appendOutput(fmt.Sprintf("%s  %d %s\n", result, r.Step, r.Name))
+				MarkActivity()
+			},
+		}
+		go func() {
+			defer func() {
+				cancel()
+				run.Enable()
+				stop.Disable()
+			}()
+			rep := runner.Run(ctx, sc)
+			output.SetText(rep.String())
+			status.SetText(fmt.Sprintf("%s: %d passed, %d failed", sc.Name, rep.Passed, rep.Failed))
+			errs.ErrChan <- fmt.Sprintf("scenario %s: %d passed, %d failed, %d skipped", sc.Name, rep.Passed, rep.Failed, rep.Skipped)
+			// show the response for failed steps, it's usually where the useful information is
+			for _, r := range rep.Steps {
+				if !r.Pass && !r.Skipped && r.Response != "" {
+					appendOutput(fmt.Sprintf("\n--- step %d response:\n%s\n", r.Step, r.Response))
+				}
+			}
+		}()
+	})
+	disableWatchOnly(run)
+	stop.OnTapped = func() {
+		if cancel != nil {
+			cancel()
+		}
+	}
+	browse := widget.NewButtonWithIcon("", theme.FolderOpenIcon(), func() {
+		dialog.ShowFileOpen(func(f fyne.URIReadCloser, err error) {
+			if err != nil {
+				errs.ErrChan <- err.Error()
+				return
+			}
+			if f == nil {
+				return
+			}
+			fileEntry.SetText(strings.TrimPrefix(f.URI().String(), "file://"))
+			_ = f.Close()
+		}, Win)
+	})
+
+	return widget.NewVBox(
+		widget.NewHBox(
+			widget.NewLabel("Scenario"),
+			fyne.NewContainerWithLayout(layout.NewFixedGridLayout(fyne.NewSize(400, fileEntry.MinSize().Height)), fileEntry),
+			browse,
+			run,
+			stop,
+			status,
+		),
+		fyne.NewContainerWithLayout(layout.NewFixedGridLayout(fyne.NewSize(RWidth(), PctHeight()-250)),
+			widget.NewScrollContainer(output),
+		),
+	)
+}
@@ -29,12 +29,6 @@ type prodInfo struct {
 }
 
 func InitServerInfo(info chan ServerInfo, reconnected chan bool) fyne.CanvasObject {
-	knownChains := map[string]string{
-		"b20901380af44ef59c5918439a1f9a41d83669020319a80574b804a5f95cbd7e": "FIO Testnet",
-		"21dcae42c0182200e93f954a074011f9048a7624c6fe81d3c9541a614a88bd1c": "FIO Mainnet",
-		"e143d39294a14616dbbee394f1c159a4eb71b656b9ca1094ebf924dc3714d7ae": "Dapix Development Chain",
-	}
-
 	prods := make(map[string]*prodInfo)
 
 	uriLabel := widget.NewLabel(Uri)
@@ -337,8 +331,8 @@ func InitServerInfo(info chan ServerInfo, reconnected chan bool) fyne.CanvasObje
 						headTimeLagIcon.Hide()
 						headTimeLagLabel.Hide()
 					}
-					if knownChains[si.Info.ChainID.String()] != "" {
-						chainIdKnownLabel.SetText(knownChains[si.Info.ChainID.String()])
+					if KnownChains[si.Info.ChainID.String()] != "" {
+						chainIdKnownLabel.SetText(KnownChains[si.Info.ChainID.String()])
 						if !chainIdIcon.Hidden {
 							chainIdIcon.Hide()
 						}
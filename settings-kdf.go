@@ -0,0 +1,212 @@
+package cryptonym
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/pbkdf2"
+	"golang.org/x/crypto/scrypt"
+)
+
+// Settings files start with a header that records how the key was derived, the whole header is authenticated
+// as additional data by the GCM cipher:
+//
+//	magic "CNYM" | version (1) | kdf (1) | time (4) | memory KiB (4) | threads (1) | salt (16) | nonce (12) | ciphertext
+//
+// files without the magic are the original format: a 12 byte salt followed by the ciphertext, with the key and
+// nonce both derived using pbkdf2.
+var settingsMagic = []byte("CNYM")
+
+const (
+	settingsVersion   uint8 = 1
+	settingsSaltLen         = 16
+	settingsHeaderLen       = 4 + 1 + 1 + 4 + 4 + 1 + settingsSaltLen + 12
+
+	legacySaltLen = 12
+)
+
+const (
+	KdfArgon2id = "argon2id"
+	KdfScrypt   = "scrypt"
+)
+
+var kdfIds = map[string]uint8{KdfArgon2id: 1, KdfScrypt: 2}
+
+// KdfMemoryOptions are the choices offered when changing the password, in KiB
+var KdfMemoryOptions = map[string]uint32{
+	"64 MiB":  64 * 1024,
+	"256 MiB": 256 * 1024,
+	"1 GiB":   1024 * 1024,
+}
+
+// KdfParams is the password hashing cost, Memory is in KiB and for scrypt is used as N (with r=8, N KiB are used.)
+// Time is only used by argon2id, Threads is p for scrypt.
+type KdfParams struct {
+	Kdf     string `json:"kdf"`
+	Time    uint32 `json:"time"`
+	Memory  uint32 `json:"memory"`
+	Threads uint8  `json:"threads"`
+}
+
+func DefaultKdf() KdfParams {
+	return KdfParams{Kdf: KdfArgon2id, Time: 3, Memory: 64 * 1024, Threads: 4}
+}
+
+// NewKdfParams uses the default cost for the algorithm, with the requested memory
+func NewKdfParams(kdf string, memory uint32) KdfParams {
+	p := DefaultKdf()
+	p.Kdf, p.Memory = kdf, memory
+	if kdf == KdfScrypt {
+		p.Time, p.Threads = 0, 1
+	}
+	return p
+}
+
+// Validate limits parameters read from a file, otherwise a corrupted header could ask for absurd amounts of memory
+func (p KdfParams) Validate() error {
+	if p.Memory < 8*1024 || p.Memory > 4*1024*1024 {
+		return fmt.Errorf("kdf memory must be between 8 MiB and 4 GiB, got %d KiB", p.Memory)
+	}
+	if p.Threads == 0 || p.Threads > 64 {
+		return fmt.Errorf("kdf threads must be between 1 and 64, got %d", p.Threads)
+	}
+	switch p.Kdf {
+	case KdfArgon2id:
+		if p.Time == 0 || p.Time > 64 {
+			return fmt.Errorf("argon2id time must be between 1 and 64, got %d", p.Time)
+		}
+	case KdfScrypt:
+		if p.Memory&(p.Memory-1) != 0 {
+			return fmt.Errorf("scrypt memory must be a power of 2, got %d KiB", p.Memory)
+		}
+	default:
+		return fmt.Errorf("unknown kdf %q", p.Kdf)
+	}
+	return nil
+}
+
+func (p KdfParams) String() string {
+	if p.Kdf == KdfScrypt {
+		return fmt.Sprintf("scrypt N=%d r=8 p=%d", p.Memory, p.Threads)
+	}
+	return fmt.Sprintf("%s t=%d m=%d MiB p=%d", p.Kdf, p.Time, p.Memory/1024, p.Threads)
+}
+
+func (p KdfParams) deriveKey(password string, salt []byte) ([]byte, error) {
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+	switch p.Kdf {
+	case KdfScrypt:
+		return scrypt.Key([]byte(password), salt, int(p.Memory), 8, int(p.Threads), 32)
+	default:
+		return argon2.IDKey([]byte(password), salt, p.Time, p.Memory, p.Threads, 32), nil
+	}
+}
+
+func (p KdfParams) header(salt []byte, nonce []byte) []byte {
+	h := bytes.NewBuffer(nil)
+	h.Write(settingsMagic)
+	h.WriteByte(settingsVersion)
+	h.WriteByte(kdfIds[p.Kdf])
+	_ = binary.Write(h, binary.BigEndian, p.Time)
+	_ = binary.Write(h, binary.BigEndian, p.Memory)
+	h.WriteByte(p.Threads)
+	h.Write(salt)
+	h.Write(nonce)
+	return h.Bytes()
+}
+
+// isLegacySettings is true for files written before the header was added
+func isLegacySettings(encrypted []byte) bool {
+	return len(encrypted) < settingsHeaderLen || !bytes.Equal(encrypted[:len(settingsMagic)], settingsMagic)
+}
+
+// parseSettingsHeader returns the kdf parameters, salt and nonce from a settings file
+func parseSettingsHeader(encrypted []byte) (params KdfParams, salt []byte, nonce []byte, err error) {
+	if isLegacySettings(encrypted) {
+		return params, nil, nil, errors.New("settings file does not have a header")
+	}
+	if v := encrypted[4]; v != settingsVersion {
+		return params, nil, nil, fmt.Errorf("settings file version %d is not supported, upgrade cryptonym", v)
+	}
+	for name, id := range kdfIds {
+		if encrypted[5] == id {
+			params.Kdf = name
+		}
+	}
+	params.Time = binary.BigEndian.Uint32(encrypted[6:10])
+	params.Memory = binary.BigEndian.Uint32(encrypted[10:14])
+	params.Threads = encrypted[14]
+	if err = params.Validate(); err != nil {
+		return params, nil, nil, err
+	}
+	salt = encrypted[15 : 15+settingsSaltLen]
+	nonce = encrypted[15+settingsSaltLen : settingsHeaderLen]
+	return
+}
+
+func sealSettings(plaintext []byte, params KdfParams, salt []byte, nonce []byte, password string) ([]byte, error) {
+	key, err := params.deriveKey(password, salt)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := newGcm(key)
+	if err != nil {
+		return nil, err
+	}
+	header := params.header(salt, nonce)
+	return gcm.Seal(header, nonce, plaintext, header), nil
+}
+
+func openSettings(encrypted []byte, password string) (plaintext []byte, params KdfParams, err error) {
+	params, salt, nonce, err := parseSettingsHeader(encrypted)
+	if err != nil {
+		return nil, params, err
+	}
+	key, err := params.deriveKey(password, salt)
+	if err != nil {
+		return nil, params, err
+	}
+	gcm, err := newGcm(key)
+	if err != nil {
+		return nil, params, err
+	}
+	plaintext, err = gcm.Open(nil, nonce, encrypted[settingsHeaderLen:], encrypted[:settingsHeaderLen])
+	return plaintext, params, err
+}
+
+// openLegacySettings decrypts the original format, pbkdf2 derives 48 bytes: the aes key then the nonce,
+// and the plaintext is pkcs7 padded.
+func openLegacySettings(encrypted []byte, password string) ([]byte, error) {
+	if len(encrypted) <= legacySaltLen {
+		return nil, errors.New("settings file is too short")
+	}
+	key := pbkdf2.Key([]byte(password), encrypted[:legacySaltLen], 12*1024, 48, sha256.New)
+	gcm, err := newGcm(key[:32])
+	if err != nil {
+		return nil, err
+	}
+	plain, err := gcm.Open(nil, key[len(key)-gcm.NonceSize():], encrypted[legacySaltLen:], nil)
+	if err != nil {
+		return nil, err
+	}
+	padLen := int(plain[len(plain)-1])
+	if len(plain) <= padLen {
+		return nil, errors.New("invalid padding, plaintext smaller than pkcs7 pad size")
+	}
+	return plain[:len(plain)-padLen], nil
+}
+
+func newGcm(key []byte) (cipher.AEAD, error) {
+	cb, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(cb)
+}
@@ -0,0 +1,199 @@
+package cryptonym
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+	"errors"
+	"fmt"
+	errs "github.com/blockpane/cryptonym/errLog"
+	"io/ioutil"
+)
+
+// SettingsSchemaVersion is written into the settings document, whenever a field is removed, renamed, or
+// changes type bump it and append a migration to settingsMigrations.
+const SettingsSchemaVersion = 3
+
+// settingsDocument is the plaintext inside the encrypted settings file
+type settingsDocument struct {
+	SchemaVersion int             `json:"schema_version"`
+	Settings      json.RawMessage `json:"settings"`
+}
+
+// settingsMigrations are applied in order, settingsMigrations[n] upgrades a version n+1 document to n+2
+var settingsMigrations = []func(settings map[string]interface{}) error{
+	migrateSchemaV1,
+	migrateSchemaV2,
+}
+
+// migrateSchemaV1: version 1 is the original layout with four fixed key slots and a single server, these
+// are moved into the keyring and network profiles.
+func migrateSchemaV1(settings map[string]interface{}) error {
+	return withSettingsStruct(settings, func(s *FioSettings) {
+		if s.migrateKeys() {
+			errs.ErrChan <- "DecryptSettings: moved legacy saved keys into the keyring"
+		}
+		if s.migrateProfiles() {
+			errs.ErrChan <- "DecryptSettings: created network profiles, active profile is " + s.ActiveProfile
+		}
+	})
+}
+
+// migrateSchemaV2: version 3 adds the idle lock, files without the field get the default timeout. A value
+// that's already there was set by the user, 0 turns the lock off and is kept.
+func migrateSchemaV2(settings map[string]interface{}) error {
+	if _, ok := settings["idle_lock_minutes"]; !ok {
+		settings["idle_lock_minutes"] = DefaultSettings().IdleLockMinutes
+	}
+	return nil
+}
+
+// withSettingsStruct is for migrations that are easier to do on the struct, the document is replaced with the result
+func withSettingsStruct(settings map[string]interface{}, f func(s *FioSettings)) error {
+	j, err := json.Marshal(settings)
+	if err != nil {
+		return err
+	}
+	s := &FioSettings{}
+	if err = json.Unmarshal(j, s); err != nil {
+		return err
+	}
+	f(s)
+	j, err = json.Marshal(s)
+	if err != nil {
+		return err
+	}
+	had := make(map[string]bool)
+	for k := range settings {
+		had[k] = true
+		delete(settings, k)
+	}
+	if err = json.Unmarshal(j, &settings); err != nil {
+		return err
+	}
+	dropAddedZeros(settings, had)
+	return nil
+}
+
+// dropAddedZeros removes empty fields that a struct round trip added, so later migrations can tell a field
+// that wasn't in the file from one that was set to its zero value
+func dropAddedZeros(settings map[string]interface{}, had map[string]bool) {
+	for k, v := range settings {
+		if had[k] {
+			continue
+		}
+		switch x := v.(type) {
+		case nil:
+			delete(settings, k)
+		case bool:
+			if !x {
+				delete(settings, k)
+			}
+		case float64:
+			if x == 0 {
+				delete(settings, k)
+			}
+		case string:
+			if x == "" {
+				delete(settings, k)
+			}
+		case []interface{}:
+			if len(x) == 0 {
+				delete(settings, k)
+			}
+		case map[string]interface{}:
+			if len(x) == 0 {
+				delete(settings, k)
+			}
+		}
+	}
+}
+
+func encodeSettings(set *FioSettings) ([]byte, error) {
+	j, err := json.Marshal(set)
+	if err != nil {
+		return nil, err
+	}
+	return json.MarshalIndent(&settingsDocument{SchemaVersion: SettingsSchemaVersion, Settings: j}, "", "  ")
+}
+
+// decodeSettings reads a JSON settings document, upgrading it to the current schema. Files written before
+// the document existed are gob encoded and treated as version 1.
+func decodeSettings(plain []byte) (*FioSettings, error) {
+	doc := &settingsDocument{}
+	fromGob := false
+	if len(plain) > 0 && plain[0] == '{' {
+		if err := json.Unmarshal(plain, doc); err != nil {
+			return nil, err
+		}
+	} else {
+		legacy := &FioSettings{}
+		if err := gob.NewDecoder(bytes.NewReader(plain)).Decode(legacy); err != nil {
+			return nil, err
+		}
+		j, err := json.Marshal(legacy)
+		if err != nil {
+			return nil, err
+		}
+		doc.SchemaVersion, doc.Settings, fromGob = 1, j, true
+	}
+	switch {
+	case doc.SchemaVersion > SettingsSchemaVersion:
+		return nil, fmt.Errorf("settings schema version %d is newer than this version of cryptonym supports (%d)", doc.SchemaVersion, SettingsSchemaVersion)
+	case doc.SchemaVersion < 1:
+		return nil, errors.New("settings document does not have a schema version")
+	}
+
+	settings := make(map[string]interface{})
+	if err := json.Unmarshal(doc.Settings, &settings); err != nil {
+		return nil, err
+	}
+	if fromGob {
+		// gob doesn't write zero values, so in the struct they're fields that weren't in the file
+		dropAddedZeros(settings, nil)
+	}
+	for v := doc.SchemaVersion; v < SettingsSchemaVersion; v++ {
+		if err := settingsMigrations[v-1](settings); err != nil {
+			return nil, fmt.Errorf("migrating settings from schema version %d: %s", v, err.Error())
+		}
+	}
+	j, err := json.Marshal(settings)
+	if err != nil {
+		return nil, err
+	}
+	s := &FioSettings{}
+	return s, json.Unmarshal(j, s)
+}
+
+// ExportSettings writes the saved settings to a portable backup file encrypted with exportPassword.
+func ExportSettings(fileName string, password string, exportPassword string) error {
+	ok, fileLen, saved, err := LoadEncryptedSettings(password)
+	switch {
+	case fileLen == 0 && err == nil:
+		return errors.New("there are no saved settings to export")
+	case err != nil:
+		return err
+	case !ok:
+		return errors.New("could not decrypt settings")
+	}
+	encrypted, err := EncryptSettings(saved, nil, exportPassword)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(fileName, encrypted, 0600)
+}
+
+// ImportSettings replaces the saved settings with a backup from ExportSettings, the settings are
+// re-encrypted using password.
+func ImportSettings(fileName string, backupPassword string, password string) error {
+	encrypted, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		return err
+	}
+	imported, err := DecryptSettings(encrypted, backupPassword)
+	if err != nil {
+		return err
+	}
+	_, err = SaveEncryptedSettings(password, imported)
+	return err
+}
@@ -13,7 +13,9 @@ import (
 	errs "github.com/blockpane/cryptonym/errLog"
 	"net/url"
 	"os"
+	"sort"
 	"strconv"
+	"strings"
 )
 
 const settingsTitle = "Cryptonym Settings"
@@ -53,6 +55,11 @@ func SettingsWindow() {
 	if PasswordVisible {
 		return
 	}
+	// the keyring was scrubbed, saving now would remove it from the file
+	if Locked() {
+		RequestUnlock()
+		return
+	}
 	w := App.NewWindow(settingsTitle)
 	w.Resize(fyne.NewSize(600, 800))
 	w.SetOnClosed(func() {
@@ -64,14 +71,13 @@ func SettingsWindow() {
 		}
 	})
 
-	if Settings == nil || Settings.Server == "" {
+	if Settings == nil {
 		Settings = DefaultSettings()
 	}
 
 	var filename string
 	updateFieldsFromSettings := func() {}
 	settingsFileLabel := widget.NewLabel("")
-	serverEntry := widget.NewEntry()
 	proxyEntry := widget.NewEntry()
 	widthEntry := widget.NewEntry()
 	heightEntry := widget.NewEntry()
@@ -104,6 +110,26 @@ func SettingsWindow() {
 		layout.NewSpacer(),
 	)
 
+	idleEntry := widget.NewEntry()
+	idleRow := widget.NewHBox(
+		layout.NewSpacer(),
+		widget.NewLabel("Lock after idle minutes: "),
+		idleEntry,
+		widget.NewLabelWithStyle(" (0 disables)", fyne.TextAlignCenter, fyne.TextStyle{Italic: true}),
+		layout.NewSpacer(),
+	)
+
+	controlCheck := widget.NewCheck("Local control API", func(bool) {})
+	controlEntry := widget.NewEntry()
+	controlEntry.SetPlaceHolder(DefaultControlAddress)
+	controlRow := widget.NewHBox(
+		layout.NewSpacer(),
+		controlCheck,
+		controlEntry,
+		widget.NewLabelWithStyle(" (token is saved next to the config file)", fyne.TextAlignCenter, fyne.TextStyle{Italic: true}),
+		layout.NewSpacer(),
+	)
+
 	themeSelect := widget.NewSelect([]string{"Dark", "Darker", "Grey", "Light"}, func(s string) {
 		switch s {
 		case "Dark":
@@ -123,29 +149,149 @@ func SettingsWindow() {
 		RefreshQr <- true
 	})
 
-	defKeyEntry := widget.NewPasswordEntry()
-	defKeyEntry.SetPlaceHolder("WIF Private Key")
-	defKeyDescEntry := widget.NewEntry()
-	defKeyDescEntry.SetPlaceHolder("Description")
-
-	favKey2Entry := widget.NewPasswordEntry()
-	favKey2Entry.SetPlaceHolder("WIF Private Key")
-	favKey2DescEntry := widget.NewEntry()
-	favKey2DescEntry.SetPlaceHolder("Description")
-
-	favKey3Entry := widget.NewPasswordEntry()
-	favKey3Entry.SetPlaceHolder("WIF Private Key")
-	favKey3DescEntry := widget.NewEntry()
-	favKey3DescEntry.SetPlaceHolder("Description")
-
-	favKey4Entry := widget.NewPasswordEntry()
-	favKey4Entry.SetPlaceHolder("WIF Private Key")
-	favKey4DescEntry := widget.NewEntry()
-	favKey4DescEntry.SetPlaceHolder("Description")
+	// the keyring is edited on a copy so that cancel doesn't change the loaded settings
+	keyRing := &FioSettings{}
+	keyRows := widget.NewVBox()
+	var redrawKeys func()
+	redrawKeys = func() {
+		keyRows.Children = make([]fyne.CanvasObject, 0)
+		for i, k := range keyRing.Keys {
+			key := k
+			idx := i
+			nameEntry := widget.NewEntry()
+			nameEntry.SetPlaceHolder("Name")
+			nameEntry.SetText(key.Name)
+			nameEntry.OnChanged = func(s string) {
+				key.Name = s
+			}
+			wifEntry := widget.NewPasswordEntry()
+			wifEntry.SetPlaceHolder("WIF Private Key")
+			wifEntry.SetText(key.Wif)
+			wifEntry.OnChanged = func(s string) {
+				key.Wif = s
+			}
+			tagEntry := widget.NewEntry()
+			tagEntry.SetPlaceHolder("tags, comma separated")
+			tagEntry.SetText(key.TagString())
+			tagEntry.OnChanged = func(s string) {
+				key.Tags = ParseTags(s)
+			}
+			upButton := widget.NewButtonWithIcon("", theme.MoveUpIcon(), func() {
+				if keyRing.MoveKey(idx, -1) {
+					redrawKeys()
+				}
+			})
+			if idx == 0 {
+				upButton.Disable()
+			}
+			downButton := widget.NewButtonWithIcon("", theme.MoveDownIcon(), func() {
+				if keyRing.MoveKey(idx, 1) {
+					redrawKeys()
+				}
+			})
+			if idx == len(keyRing.Keys)-1 {
+				downButton.Disable()
+			}
+			deleteButton := widget.NewButtonWithIcon("", theme.DeleteIcon(), func() {
+				if keyRing.DeleteKey(idx) {
+					redrawKeys()
+				}
+			})
+			marker := " "
+			if idx == 0 {
+				marker = "*"
+			}
+			keyRows.Append(widget.NewHBox(
+				widget.NewLabel(marker),
+				fyne.NewContainerWithLayout(layout.NewGridLayout(3), nameEntry, wifEntry, tagEntry),
+				upButton, downButton, deleteButton,
+			))
+		}
+		keyRows.Refresh()
+	}
+	addKeyButton := widget.NewButtonWithIcon("Add Key", theme.ContentAddIcon(), func() {
+		keyRing.AddKey("new key", "", []string{})
+		redrawKeys()
+	})
 
 	msigDefaultEntry := widget.NewEntry()
 	msigDefaultEntry.SetPlaceHolder("abcdefghi")
 
+	keosdAddressEntry := widget.NewEntry()
+	keosdAddressEntry.SetPlaceHolder("http://127.0.0.1:8900")
+	keosdWalletEntry := widget.NewEntry()
+	keosdWalletEntry.SetPlaceHolder("default")
+	keosdPasswordEntry := widget.NewPasswordEntry()
+	keosdPasswordEntry.SetPlaceHolder("wallet password")
+	externalSignerEntry := widget.NewEntry()
+	externalSignerEntry.SetPlaceHolder("/path/to/signer --args")
+	signerSelect := widget.NewSelect(SignerTypes, func(s string) {
+		keosdAddressEntry.Disable()
+		keosdWalletEntry.Disable()
+		keosdPasswordEntry.Disable()
+		externalSignerEntry.Disable()
+		switch s {
+		case SignerKeosd:
+			keosdAddressEntry.Enable()
+			keosdWalletEntry.Enable()
+			keosdPasswordEntry.Enable()
+		case SignerExternal:
+			externalSignerEntry.Enable()
+		}
+	})
+
+	// network profiles are also edited on a copy, fields are stored back into the profile when the selection changes
+	profiles := make([]*NetworkProfile, 0)
+	var editing *NetworkProfile
+	serversEntry := widget.NewMultiLineEntry()
+	serversEntry.SetPlaceHolder("http://127.0.0.1:8888\n(one server per line, first is preferred)")
+	chainIdEntry := widget.NewEntry()
+	chainIdEntry.SetPlaceHolder("expected chain ID, empty allows any")
+	chainNameLabel := widget.NewLabel("")
+	chainIdEntry.OnChanged = func(s string) {
+		if s == "" {
+			chainNameLabel.SetText("any chain")
+			return
+		}
+		chainNameLabel.SetText(ChainName(s))
+	}
+	profileKeySelect := widget.NewSelect(keyRing.KeyNames(""), func(string) {})
+	profileKeySelect.PlaceHolder = "(first saved key)"
+	storeProfile := func() {
+		if editing == nil {
+			return
+		}
+		editing.Servers = make([]string, 0)
+		for _, server := range strings.Split(serversEntry.Text, "\n") {
+			if server = strings.TrimSpace(server); server != "" {
+				editing.Servers = append(editing.Servers, server)
+			}
+		}
+		editing.ChainId = strings.TrimSpace(chainIdEntry.Text)
+		editing.DefaultKey = profileKeySelect.Selected
+		editing.Tpid = tpidEntry.Text
+		editing.MsigAccount = msigDefaultEntry.Text
+	}
+	profileSelect := widget.NewSelect([]string{}, func(name string) {
+		storeProfile()
+		editing = nil
+		for _, p := range profiles {
+			if p.Name == name {
+				editing = p
+			}
+		}
+		if editing == nil {
+			return
+		}
+		serversEntry.SetText(strings.Join(editing.Servers, "\n"))
+		chainIdEntry.SetText(editing.ChainId)
+		profileKeySelect.Options = keyRing.KeyNames("")
+		profileKeySelect.Selected = editing.DefaultKey
+		profileKeySelect.Refresh()
+		tpidEntry.SetText(editing.Tpid)
+		msigDefaultEntry.SetText(editing.MsigAccount)
+	})
+
 	defaultsButton := widget.NewButton("Load Defaults", func() {
 		Settings = DefaultSettings()
 		updateFieldsFromSettings()
@@ -169,22 +315,42 @@ func SettingsWindow() {
 			errs.ErrChan <- "Settings: got invalid height setting for window size"
 		}
 
-		Settings.Server = serverEntry.Text
+		idleMinutes, err := strconv.Atoi(idleEntry.Text)
+		if err != nil || idleMinutes < 0 {
+			dialog.ShowError(errors.New("idle lock minutes must be a number, use 0 to disable"), w)
+			return
+		}
+
+		storeProfile()
+		Settings.IdleLockMinutes = idleMinutes
+		Settings.ControlApi = controlCheck.Checked
+		Settings.ControlAddress = strings.TrimSpace(controlEntry.Text)
+		Settings.Profiles = profiles
+		Settings.ActiveProfile = profileSelect.Selected
 		Settings.Proxy = proxyEntry.Text
-		Settings.DefaultKey = defKeyEntry.Text
-		Settings.DefaultKeyDesc = defKeyDescEntry.Text
-		Settings.FavKey2 = favKey2Entry.Text
-		Settings.FavKey2Desc = favKey2DescEntry.Text
-		Settings.FavKey3 = favKey3Entry.Text
-		Settings.FavKey3Desc = favKey3DescEntry.Text
-		Settings.FavKey4 = favKey4Entry.Text
-		Settings.FavKey4Desc = favKey4DescEntry.Text
-		Settings.MsigAccount = msigDefaultEntry.Text
+		Settings.Keys = make([]*SavedKey, 0)
+		for _, k := range keyRing.Keys {
+			if k.Wif == "" {
+				continue
+			}
+			Settings.AddKey(k.Name, k.Wif, k.Tags)
+		}
 		Settings.AdvancedFeatures = advanced.Checked
 		if Settings.AdvancedFeatures {
 			_ = os.Setenv("ADVANCED", "true")
 		}
-		Settings.Tpid = tpidEntry.Text
+		Settings.SignerType = signerSelect.Selected
+		Settings.ExternalSigner = externalSignerEntry.Text
+		Settings.KeosdAddress = keosdAddressEntry.Text
+		Settings.KeosdWallet = keosdWalletEntry.Text
+		Settings.KeosdPassword = keosdPasswordEntry.Text
+		// don't let a save silently change the password, that is done with the change password button
+		if ok, fileLen, saved, _ := LoadEncryptedSettings(passEntry.Text); ok {
+			Settings.Kdf = saved.Kdf
+		} else if fileLen > 0 {
+			dialog.ShowError(errors.New("the password does not match the saved settings, use Change Password to set a new one"), w)
+			return
+		}
 		ok, err := SaveEncryptedSettings(passEntry.Text, Settings)
 		if ok {
 			if updateSize {
@@ -257,22 +423,44 @@ func SettingsWindow() {
 	cancelButton := widget.NewButton("Cancel", func() {
 		w.Close()
 	})
+	changePassButton := widget.NewButton("Change Password", func() {
+		changePasswordDialog(w)
+	})
 
 	updateFieldsFromSettings = func() {
 		heightEntry.SetText(fmt.Sprint(H))
 		widthEntry.SetText(fmt.Sprint(W))
-		serverEntry.SetText(Settings.Server)
 		proxyEntry.SetText(Settings.Proxy)
-		defKeyEntry.SetText(Settings.DefaultKey)
-		defKeyDescEntry.SetText(Settings.DefaultKeyDesc)
-		favKey2Entry.SetText(Settings.FavKey2)
-		favKey2DescEntry.SetText(Settings.FavKey2Desc)
-		favKey3Entry.SetText(Settings.FavKey3)
-		favKey3DescEntry.SetText(Settings.FavKey3Desc)
-		favKey4Entry.SetText(Settings.FavKey4)
-		favKey4DescEntry.SetText(Settings.FavKey4Desc)
-		msigDefaultEntry.SetText(Settings.MsigAccount)
-		tpidEntry.SetText(Settings.Tpid)
+		idleEntry.SetText(strconv.Itoa(Settings.IdleLockMinutes))
+		controlCheck.SetChecked(Settings.ControlApi)
+		controlEntry.SetText(Settings.ControlAddress)
+		keyRing.Keys = make([]*SavedKey, 0)
+		for _, k := range Settings.Keys {
+			if k != nil {
+				keyRing.AddKey(k.Name, k.Wif, append([]string{}, k.Tags...))
+			}
+		}
+		redrawKeys()
+		editing = nil
+		profiles = make([]*NetworkProfile, 0)
+		for _, p := range Settings.Profiles {
+			if p == nil {
+				continue
+			}
+			cp := *p
+			cp.Servers = append([]string{}, p.Servers...)
+			profiles = append(profiles, &cp)
+		}
+		profileSelect.Options = Settings.ProfileNames()
+		profileSelect.SetSelected(Settings.Profile().Name)
+		keosdAddressEntry.SetText(Settings.KeosdAddress)
+		keosdWalletEntry.SetText(Settings.KeosdWallet)
+		keosdPasswordEntry.SetText(Settings.KeosdPassword)
+		externalSignerEntry.SetText(Settings.ExternalSigner)
+		if Settings.SignerType == "" {
+			Settings.SignerType = SignerLocal
+		}
+		signerSelect.SetSelected(Settings.SignerType)
 		advanced.SetChecked(Settings.AdvancedFeatures)
 		if Settings.AdvancedFeatures {
 			_ = os.Setenv("ADVANCED", "true")
@@ -302,8 +490,20 @@ func SettingsWindow() {
 				fyne.NewContainerWithLayout(layout.NewFixedGridLayout(fyne.NewSize(50, 50)), layout.NewSpacer()),
 				widget.NewHBox(
 					layout.NewSpacer(),
-					widget.NewLabelWithStyle("Preferred Server", fyne.TextAlignTrailing, fyne.TextStyle{}),
-					serverEntry, layout.NewSpacer(),
+					widget.NewLabelWithStyle("Network Profile", fyne.TextAlignTrailing, fyne.TextStyle{Bold: true}),
+					profileSelect, layout.NewSpacer(),
+				),
+				fyne.NewContainerWithLayout(layout.NewGridLayout(2),
+					widget.NewLabelWithStyle("Servers", fyne.TextAlignTrailing, fyne.TextStyle{}),
+					fyne.NewContainerWithLayout(layout.NewFixedGridLayout(fyne.NewSize(280, 80)), serversEntry),
+					widget.NewLabelWithStyle("Expected Chain ID", fyne.TextAlignTrailing, fyne.TextStyle{}),
+					widget.NewVBox(chainIdEntry, chainNameLabel),
+					widget.NewLabelWithStyle("Default Key", fyne.TextAlignTrailing, fyne.TextStyle{}),
+					profileKeySelect,
+					widget.NewLabelWithStyle("MSIG Account for Proposals", fyne.TextAlignTrailing, fyne.TextStyle{}),
+					msigDefaultEntry,
+					widget.NewLabelWithStyle("TPID", fyne.TextAlignTrailing, fyne.TextStyle{}),
+					tpidEntry,
 				),
 				//widget.NewHBox(
 				//	layout.NewSpacer(),
@@ -317,49 +517,30 @@ func SettingsWindow() {
 				),
 				sizeRow,
 				advancedRow,
+				idleRow,
+				controlRow,
 				widget.NewHBox(
 					layout.NewSpacer(),
-					widget.NewLabelWithStyle("Preferred MSIG Account for Proposals", fyne.TextAlignTrailing, fyne.TextStyle{}),
-					msigDefaultEntry, layout.NewSpacer(),
+					widget.NewLabelWithStyle("Sign Transactions With", fyne.TextAlignTrailing, fyne.TextStyle{}),
+					signerSelect, layout.NewSpacer(),
 				),
-				widget.NewHBox(
-					layout.NewSpacer(),
-					widget.NewLabelWithStyle("Preferred TPID", fyne.TextAlignTrailing, fyne.TextStyle{}),
-					tpidEntry, layout.NewSpacer(),
+				fyne.NewContainerWithLayout(layout.NewGridLayout(3),
+					keosdAddressEntry, keosdWalletEntry, keosdPasswordEntry,
 				),
+				externalSignerEntry,
 				fyne.NewContainerWithLayout(layout.NewFixedGridLayout(fyne.NewSize(50, 50)), layout.NewSpacer()),
 				widget.NewHBox(
-					widget.NewLabelWithStyle("*Quick Load Key", fyne.TextAlignLeading, fyne.TextStyle{}),
-					widget.NewHBox(
-						defKeyEntry, layout.NewSpacer(), defKeyDescEntry,
-					),
+					widget.NewLabelWithStyle("Saved Keys", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
 					layout.NewSpacer(),
+					addKeyButton,
 				),
-				widget.NewHBox(
-					widget.NewLabelWithStyle(" Quick Load Key  ", fyne.TextAlignLeading, fyne.TextStyle{}),
-					widget.NewHBox(
-						favKey2Entry, layout.NewSpacer(), favKey2DescEntry,
-					),
-					layout.NewSpacer(),
-				),
-				widget.NewHBox(
-					widget.NewLabelWithStyle(" Quick Load Key  ", fyne.TextAlignLeading, fyne.TextStyle{}),
-					widget.NewHBox(
-						favKey3Entry, layout.NewSpacer(), favKey3DescEntry,
-					),
-					layout.NewSpacer(),
-				),
-				widget.NewHBox(
-					widget.NewLabelWithStyle(" Quick Load Key  ", fyne.TextAlignLeading, fyne.TextStyle{}),
-					widget.NewHBox(
-						favKey4Entry, layout.NewSpacer(), favKey4DescEntry,
-					),
-					layout.NewSpacer(),
+				fyne.NewContainerWithLayout(layout.NewFixedGridLayout(fyne.NewSize(580, 250)),
+					widget.NewScrollContainer(keyRows),
 				),
 				widget.NewLabelWithStyle("* - Default Key", fyne.TextAlignCenter, fyne.TextStyle{}),
 				fyne.NewContainerWithLayout(layout.NewFixedGridLayout(fyne.NewSize(50, 50)), layout.NewSpacer()),
 				layout.NewSpacer(),
-				widget.NewHBox(layout.NewSpacer(), saveButton, defaultsButton, cancelButton, layout.NewSpacer()),
+				widget.NewHBox(layout.NewSpacer(), saveButton, changePassButton, defaultsButton, cancelButton, layout.NewSpacer()),
 				widget.NewHBox(
 					layout.NewSpacer(),
 					widget.NewLabelWithStyle("Config File Location", fyne.TextAlignCenter, fyne.TextStyle{Bold: true}),
@@ -400,6 +581,65 @@ func (e *unlockEntry) KeyDown(key *fyne.KeyEvent) {
 	}
 }
 
+// changePasswordDialog re-encrypts the saved settings file, and allows changing the kdf and its cost
+func changePasswordDialog(w fyne.Window) {
+	currentEntry := widget.NewPasswordEntry()
+	currentEntry.SetPlaceHolder("current password")
+	newEntry := widget.NewPasswordEntry()
+	newEntry.SetPlaceHolder("new password")
+	confirmEntry := widget.NewPasswordEntry()
+	confirmEntry.SetPlaceHolder("confirm the new password")
+
+	memoryOptions := make([]string, 0)
+	for k := range KdfMemoryOptions {
+		memoryOptions = append(memoryOptions, k)
+	}
+	sort.Slice(memoryOptions, func(i, j int) bool {
+		return KdfMemoryOptions[memoryOptions[i]] < KdfMemoryOptions[memoryOptions[j]]
+	})
+	kdfSelect := widget.NewSelect([]string{KdfArgon2id, KdfScrypt}, func(string) {})
+	memorySelect := widget.NewSelect(memoryOptions, func(string) {})
+	kdfSelect.SetSelected(Settings.Kdf.Kdf)
+	if kdfSelect.Selected == "" {
+		kdfSelect.SetSelected(KdfArgon2id)
+	}
+	memorySelect.SetSelected(memoryOptions[0])
+	for k, v := range KdfMemoryOptions {
+		if v == Settings.Kdf.Memory {
+			memorySelect.SetSelected(k)
+		}
+	}
+
+	form := fyne.NewContainerWithLayout(layout.NewGridLayout(2),
+		widget.NewLabelWithStyle("Current Password", fyne.TextAlignTrailing, fyne.TextStyle{}), currentEntry,
+		widget.NewLabelWithStyle("New Password", fyne.TextAlignTrailing, fyne.TextStyle{}), newEntry,
+		widget.NewLabelWithStyle("Confirm", fyne.TextAlignTrailing, fyne.TextStyle{}), confirmEntry,
+		widget.NewLabelWithStyle("Key Derivation", fyne.TextAlignTrailing, fyne.TextStyle{}), kdfSelect,
+		widget.NewLabelWithStyle("Memory Cost", fyne.TextAlignTrailing, fyne.TextStyle{}), memorySelect,
+	)
+	dialog.ShowCustomConfirm("Change Settings Password", "Change", "Cancel", form, func(b bool) {
+		if !b {
+			return
+		}
+		switch {
+		case len(newEntry.Text) < 8:
+			dialog.ShowError(errors.New("the new password must be at least 8 characters"), w)
+			return
+		case newEntry.Text != confirmEntry.Text:
+			dialog.ShowError(errors.New("the new passwords do not match"), w)
+			return
+		}
+		params := NewKdfParams(kdfSelect.Selected, KdfMemoryOptions[memorySelect.Selected])
+		if err := ChangeSettingsPassword(currentEntry.Text, newEntry.Text, params); err != nil {
+			errs.ErrChan <- "Settings: could not change password: " + err.Error()
+			dialog.ShowError(err, w)
+			return
+		}
+		errs.ErrChan <- "Settings: password changed, settings are now encrypted using " + params.String()
+		dialog.ShowInformation("Password Changed", "Settings were re-encrypted using "+params.String(), w)
+	}, w)
+}
+
 func PromptForPassword() {
 	if PasswordVisible {
 		return
@@ -499,23 +739,29 @@ func PromptForPassword() {
 			resultLabel.SetText("Could not decrypt settings")
 			resultBox.Show()
 		case ok:
-			Settings.Server = newConfig.Server
-			if deferCheck.Checked {
-				Settings.Server = ""
-			} else if serverOverride != "" {
-				Settings.Server = serverOverride
+			Settings.Profiles = newConfig.Profiles
+			Settings.ActiveProfile = newConfig.ActiveProfile
+			Settings.deferConnect = deferCheck.Checked
+			Settings.serverOverride = ""
+			if !deferCheck.Checked && serverOverride != "" {
+				// the override list only has mainnet nodes, so the mainnet profile needs to be active
+				Settings.serverOverride = serverOverride
+				if Settings.FindProfile("mainnet") != nil {
+					Settings.ActiveProfile = "mainnet"
+				}
 			}
 			Settings.Proxy = newConfig.Proxy
-			Settings.DefaultKey = newConfig.DefaultKey
-			Settings.DefaultKeyDesc = newConfig.DefaultKeyDesc
-			Settings.FavKey2 = newConfig.FavKey2
-			Settings.FavKey2Desc = newConfig.FavKey2Desc
-			Settings.FavKey3 = newConfig.FavKey3
-			Settings.FavKey3Desc = newConfig.FavKey3Desc
-			Settings.FavKey4 = newConfig.FavKey4
-			Settings.FavKey4Desc = newConfig.FavKey4Desc
-			Settings.MsigAccount = newConfig.MsigAccount
-			Settings.Tpid = newConfig.Tpid
+			Settings.Keys = newConfig.Keys
+			Settings.SignerType = newConfig.SignerType
+			Settings.ExternalSigner = newConfig.ExternalSigner
+			Settings.KeosdAddress = newConfig.KeosdAddress
+			Settings.KeosdWallet = newConfig.KeosdWallet
+			Settings.KeosdPassword = newConfig.KeosdPassword
+			Settings.Kdf = newConfig.Kdf
+			Settings.IdleLockMinutes = newConfig.IdleLockMinutes
+			Settings.ControlApi = newConfig.ControlApi
+			Settings.ControlAddress = newConfig.ControlAddress
+			Unlock()
 
 			SettingsLoaded <- Settings
 			pop.Hide()
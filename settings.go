@@ -2,15 +2,10 @@ package cryptonym
 
 import (
 	"bytes"
-	"crypto/aes"
-	"crypto/cipher"
 	"crypto/rand"
-	"crypto/sha256"
-	"encoding/gob"
 	"errors"
 	"fmt"
 	errs "github.com/blockpane/cryptonym/errLog"
-	"golang.org/x/crypto/pbkdf2"
 	"os"
 	"time"
 )
@@ -21,42 +16,70 @@ const (
 )
 
 type FioSettings struct {
-	Server string `json:"server"`
-	Proxy  string `json:"proxy"`
+	Proxy string `json:"proxy"`
 
-	DefaultKey     string `json:"default_key"`
-	DefaultKeyDesc string `json:"default_key_desc"`
-	FavKey2        string `json:"fav_key_2"`
-	FavKey2Desc    string `json:"fav_key_2_desc"`
-	FavKey3        string `json:"fav_key_3"`
-	FavKey3Desc    string `json:"fav_key_3_desc"`
-	FavKey4        string `json:"fav_key_4"`
-	FavKey4Desc    string `json:"fav_key_4_desc"`
+	// Profiles are the per-network connection settings, ActiveProfile is the name of the one in use
+	Profiles      []*NetworkProfile `json:"profiles"`
+	ActiveProfile string            `json:"active_profile"`
 
-	MsigAccount string `json:"msig_account"`
-	Tpid        string `json:"tpid"`
+	// Keys is the keyring, the first entry is the default key
+	Keys []*SavedKey `json:"keys"`
+
+	// legacy quick-load slots, only read from older settings files and moved into Keys by the schema v1 migration
+	DefaultKey     string `json:"default_key,omitempty"`
+	DefaultKeyDesc string `json:"default_key_desc,omitempty"`
+	FavKey2        string `json:"fav_key_2,omitempty"`
+	FavKey2Desc    string `json:"fav_key_2_desc,omitempty"`
+	FavKey3        string `json:"fav_key_3,omitempty"`
+	FavKey3Desc    string `json:"fav_key_3_desc,omitempty"`
+	FavKey4        string `json:"fav_key_4,omitempty"`
+	FavKey4Desc    string `json:"fav_key_4_desc,omitempty"`
+
+	// legacy single-network settings, moved into a network profile by the schema v1 migration
+	Server      string `json:"server,omitempty"`
+	MsigAccount string `json:"msig_account,omitempty"`
+	Tpid        string `json:"tpid,omitempty"`
 
 	AdvancedFeatures bool `json:"advanced_features"`
 
-	// future:
-	KeosdAddress  string `json:"keosd_address"`
-	KeosdPassword string `json:"keosd_password"`
+	// SignerType is one of SignerTypes, keosd and external signers never need the private key loaded
+	SignerType     string `json:"signer_type"`
+	KeosdAddress   string `json:"keosd_address"`
+	KeosdWallet    string `json:"keosd_wallet"`
+	KeosdPassword  string `json:"keosd_password"`
+	ExternalSigner string `json:"external_signer"`
+
+	// IdleLockMinutes locks the app and removes keys from memory after no activity, 0 disables
+	IdleLockMinutes int `json:"idle_lock_minutes"`
+
+	// ControlApi serves the local control api on ControlAddress (DefaultControlAddress if empty)
+	ControlApi     bool   `json:"control_api"`
+	ControlAddress string `json:"control_address,omitempty"`
+
+	// Kdf is the password hashing cost used when saving, it is replaced with the file's values when loaded
+	Kdf KdfParams `json:"kdf"`
+
+	// set when unlocking, not saved
+	serverOverride string
+	deferConnect   bool
 }
 
 func DefaultSettings() *FioSettings {
 	return &FioSettings{
-		Server:         "http://127.0.0.1:8888",
-		Proxy:          "http://127.0.0.1:8080",
-		DefaultKey:     "5JBbUG5SDpLWxvBKihMeXLENinUzdNKNeozLas23Mj6ZNhz3hLS",
-		DefaultKeyDesc: "devnet - vote 1",
-		FavKey2:        "5KC6Edd4BcKTLnRuGj2c8TRT9oLuuXLd3ZuCGxM9iNngc3D8S93",
-		FavKey2Desc:    "devnet - vote 2",
-		FavKey3:        "5KQ6f9ZgUtagD3LZ4wcMKhhvK9qy4BuwL3L1pkm6E2v62HCne2R",
-		FavKey3Desc:    "devnet - bp1",
-		FavKey4:        "5HwvMtAEd7kwDPtKhZrwA41eRMdFH5AaBKPRim6KxkTXcg5M9L5",
-		FavKey4Desc:    "devnet - locked 1",
-		MsigAccount:    "eosio",
-		Tpid:           "tpid@blockpane",
+		Proxy:           "http://127.0.0.1:8080",
+		Profiles:        DefaultProfiles(),
+		ActiveProfile:   "devnet",
+		SignerType:      SignerLocal,
+		Kdf:             DefaultKdf(),
+		IdleLockMinutes: 15,
+		KeosdAddress:    "http://127.0.0.1:8900",
+		KeosdWallet:     "default",
+		Keys: []*SavedKey{
+			{Name: "devnet - vote 1", Wif: "5JBbUG5SDpLWxvBKihMeXLENinUzdNKNeozLas23Mj6ZNhz3hLS", Tags: []string{"devnet", "voter"}},
+			{Name: "devnet - vote 2", Wif: "5KC6Edd4BcKTLnRuGj2c8TRT9oLuuXLd3ZuCGxM9iNngc3D8S93", Tags: []string{"devnet", "voter"}},
+			{Name: "devnet - bp1", Wif: "5KQ6f9ZgUtagD3LZ4wcMKhhvK9qy4BuwL3L1pkm6E2v62HCne2R", Tags: []string{"devnet", "bp"}},
+			{Name: "devnet - locked 1", Wif: "5HwvMtAEd7kwDPtKhZrwA41eRMdFH5AaBKPRim6KxkTXcg5M9L5", Tags: []string{"devnet", "locked"}},
+		},
 	}
 }
 
@@ -66,101 +89,65 @@ func EncryptSettings(set *FioSettings, salt []byte, password string) (encrypted
 	}
 
 	// if a salt isn't supplied, create one, note: using crypto/rand NOT math/rand, has better entropy
-	if salt == nil || len(salt) != 12 || bytes.Equal(salt, bytes.Repeat([]byte{0}, 12)) {
-		salt = make([]byte, 12)
+	if salt == nil || len(salt) != settingsSaltLen || bytes.Equal(salt, bytes.Repeat([]byte{0}, settingsSaltLen)) {
+		salt = make([]byte, settingsSaltLen)
 		if _, e := rand.Read(salt); e != nil {
 			errs.ErrChan <- "EncryptSettings: " + e.Error()
-			return nil, err
+			return nil, e
 		}
 	}
-
-	// prepend the salt to our buffer:
-	crypted := bytes.NewBuffer(nil)
-	crypted.Write(salt)
-
-	// convert our settings to a binary struct
-	data := bytes.NewBuffer(nil)
-	g := gob.NewEncoder(data)
-	err = g.Encode(set)
-	if err != nil {
-		errs.ErrChan <- "EncryptSettings: " + err.Error()
-		return nil, err
+	// the nonce is random and stored in the header, the key is unique per salt so it is never re-used
+	nonce := make([]byte, 12)
+	if _, e := rand.Read(nonce); e != nil {
+		errs.ErrChan <- "EncryptSettings: " + e.Error()
+		return nil, e
 	}
-
-	// password-based key derivation, 48 bytes, 1st 32 is aes key, last 12 mac key
-	key := pbkdf2.Key([]byte(password), salt, 12*1024, 48, sha256.New)
-
-	// aes 256
-	cb, err := aes.NewCipher(key[:32])
-	if err != nil {
-		errs.ErrChan <- "EncryptSettings: " + err.Error()
-		return nil, err
+	if set.Kdf.Kdf == "" {
+		set.Kdf = DefaultKdf()
 	}
 
-	//pkcs7 pad the plaintext
-	plaintext := append(data.Bytes(), func() []byte {
-		padLen := cb.BlockSize() - (len(data.Bytes()) % cb.BlockSize())
-		pad := make([]byte, padLen)
-		for i := range pad {
-			pad[i] = uint8(padLen)
-		}
-		return pad
-	}()...)
-
-	// use an authenticated (Galois) cipher
-	gcm, err := cipher.NewGCM(cb)
+	// the plaintext is a versioned json document, see settings-schema.go
+	data, err := encodeSettings(set)
 	if err != nil {
 		errs.ErrChan <- "EncryptSettings: " + err.Error()
 		return nil, err
 	}
-	// since the nonce should be secret and is derived via pbkdf, don't save it, write ciphertext directly to the buffer
-	l, err := crypted.Write(gcm.Seal(nil, key[len(key)-gcm.NonceSize():], plaintext, nil))
+
+	encrypted, err = sealSettings(data, set.Kdf, salt, nonce, password)
 	if err != nil {
 		errs.ErrChan <- "EncryptSettings: " + err.Error()
 		return nil, err
-	} else if l < len(plaintext)+gcm.Overhead() {
-		err = errors.New("unable to encrypt data, did not get correct size")
-		errs.ErrChan <- "EncryptSettings: " + err.Error()
-		return nil, err
 	}
-
-	// final paranoid sanity check that we got the correct amount of data back
-	if len(crypted.Bytes()) != len(salt)+len(plaintext)+gcm.Overhead() {
-		return nil, errors.New("unable to encrypt data, resulting ciphertext was wrong size")
-	}
-
-	return crypted.Bytes(), nil
+	return encrypted, nil
 }
 
 func DecryptSettings(encrypted []byte, password string) (settings *FioSettings, err error) {
-	key := pbkdf2.Key([]byte(password), encrypted[:12], 12*1024, 48, sha256.New)
-	cb, err := aes.NewCipher(key[:32])
-	if err != nil {
-		errs.ErrChan <- "DecryptSettings: " + err.Error()
-		return nil, err
+	if password == "" {
+		return nil, errors.New("invalid password supplied")
 	}
-	gcm, err := cipher.NewGCM(cb)
-	if err != nil {
-		errs.ErrChan <- "DecryptSettings: " + err.Error()
-		return nil, err
+	var plain []byte
+	params := DefaultKdf()
+	legacy := isLegacySettings(encrypted)
+	if legacy {
+		plain, err = openLegacySettings(encrypted, password)
+	} else {
+		plain, params, err = openSettings(encrypted, password)
 	}
-	plain, err := gcm.Open(nil, key[len(key)-gcm.NonceSize():], encrypted[12:], nil)
 	if err != nil {
 		errs.ErrChan <- "DecryptSettings: " + err.Error()
 		return nil, err
 	}
-	padLen := int(plain[len(plain)-1])
-	if len(plain) <= padLen {
-		err = errors.New("invalid padding, plaintext smaller than pkcs7 pad size")
-		errs.ErrChan <- "DecryptSettings: " + err.Error()
-		return nil, err
-	}
-	g := gob.NewDecoder(bytes.NewReader(plain[:len(plain)-padLen]))
-	err = g.Decode(&settings)
+	// older files are gob encoded, or use an older schema, and will be upgraded next time settings are saved
+	settings, err = decodeSettings(plain)
 	if err != nil {
 		errs.ErrChan <- "DecryptSettings: " + err.Error()
 		return nil, err
 	}
+	// keep the same cost when saving again, legacy files get the default and are upgraded on the next save
+	settings.Kdf = params
+	if legacy {
+		errs.ErrChan <- "DecryptSettings: settings file uses the old pbkdf2 format, it will be re-encrypted using " + params.String() + " when saved"
+	}
 	if settings.AdvancedFeatures {
 		_ = os.Setenv("ADVANCED", "true")
 	}
@@ -197,6 +184,33 @@ func SaveEncryptedSettings(password string, settings *FioSettings) (ok bool, err
 	return
 }
 
+// ChangeSettingsPassword re-encrypts the saved settings file with a new password and kdf cost, the current
+// password must decrypt the existing file. Unsaved changes are not written.
+func ChangeSettingsPassword(current string, password string, params KdfParams) error {
+	if err := params.Validate(); err != nil {
+		return err
+	}
+	ok, fileLen, saved, err := LoadEncryptedSettings(current)
+	switch {
+	case fileLen == 0 && err == nil:
+		return errors.New("there are no saved settings, use save instead")
+	case err != nil && err.Error() == "cipher: message authentication failed":
+		return errors.New("incorrect password")
+	case err != nil:
+		return err
+	case !ok:
+		return errors.New("could not decrypt settings")
+	}
+	saved.Kdf = params
+	if _, err = SaveEncryptedSettings(password, saved); err != nil {
+		return err
+	}
+	if Settings != nil {
+		Settings.Kdf = params
+	}
+	return nil
+}
+
 func MkDir() (ok bool, err error) {
 	configDir, err := os.UserConfigDir()
 	if err != nil {
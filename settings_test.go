@@ -1,7 +1,18 @@
 package cryptonym
 
 import (
+	"bytes"
+	"crypto/aes"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/gob"
+	"encoding/json"
 	"fmt"
+	"golang.org/x/crypto/pbkdf2"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"testing"
 )
 
@@ -20,3 +31,281 @@ func TestEncryptSettings(t *testing.T) {
 	fmt.Printf("%#v\n", decrypted)
 
 }
+
+func TestMigrateKeys(t *testing.T) {
+	legacy := &FioSettings{
+		Server:         "http://127.0.0.1:8888",
+		DefaultKey:     "5JBbUG5SDpLWxvBKihMeXLENinUzdNKNeozLas23Mj6ZNhz3hLS",
+		DefaultKeyDesc: "devnet - vote 1",
+		FavKey2:        "5KC6Edd4BcKTLnRuGj2c8TRT9oLuuXLd3ZuCGxM9iNngc3D8S93",
+		FavKey3:        "5KQ6f9ZgUtagD3LZ4wcMKhhvK9qy4BuwL3L1pkm6E2v62HCne2R",
+		FavKey3Desc:    "devnet - vote 1",
+	}
+	// old files are gob encoded, which is schema version 1
+	decrypted, err := DecryptSettings(legacyEncrypt(t, legacy, "password"), "password")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if len(decrypted.Keys) != 3 {
+		t.Fatalf("expected 3 keys in keyring, got %d", len(decrypted.Keys))
+	}
+	if decrypted.DefaultKey != "" || decrypted.FavKey2 != "" || decrypted.FavKey3 != "" {
+		t.Error("legacy slots were not cleared")
+	}
+	if k := decrypted.DefaultSavedKey(); k == nil || k.Wif != legacy.DefaultKey {
+		t.Error("default key was not first in the keyring")
+	}
+	for i, name := range []string{"devnet - vote 1", "key 2", "devnet - vote 1 (2)"} {
+		if decrypted.Keys[i].Name != name {
+			t.Errorf("expected key %d to be named %q, got %q", i, name, decrypted.Keys[i].Name)
+		}
+	}
+}
+
+func TestSearchKeys(t *testing.T) {
+	s := DefaultSettings()
+	if n := len(s.SearchKeys("")); n != len(s.Keys) {
+		t.Errorf("empty search should match all keys, got %d", n)
+	}
+	if n := len(s.SearchKeys("VOTER")); n != 2 {
+		t.Errorf("expected 2 keys tagged voter, got %d", n)
+	}
+	if k := s.SearchKeys("bp1"); len(k) != 1 || k[0].Name != "devnet - bp1" {
+		t.Error("name search did not find bp1")
+	}
+	if names := s.KeyNames("voter"); len(names) != 2 || len(names) >= len(s.KeyNames("")) {
+		t.Errorf("expected the filter to narrow the key names to 2, got %v", names)
+	}
+	if !s.MoveKey(2, -2) || s.DefaultSavedKey().Name != "devnet - bp1" {
+		t.Error("move did not change the default key")
+	}
+	if !s.DeleteKey(0) || s.FindKey("devnet - bp1") != nil {
+		t.Error("key was not deleted")
+	}
+}
+
+func TestMigrateProfiles(t *testing.T) {
+	legacy := &FioSettings{
+		Server:      "https://fio.greymass.com",
+		Tpid:        "me@mine",
+		MsigAccount: "abcdefghijkl",
+		DefaultKey:  "5JBbUG5SDpLWxvBKihMeXLENinUzdNKNeozLas23Mj6ZNhz3hLS",
+	}
+	// old files are gob encoded, which is schema version 1
+	decrypted, err := DecryptSettings(legacyEncrypt(t, legacy, "password"), "password")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	p := decrypted.Profile()
+	if p.Name != "mainnet" {
+		t.Fatalf("expected mainnet profile to be active, got %s", p.Name)
+	}
+	if p.Server() != legacy.Server || p.Tpid != legacy.Tpid || p.MsigAccount != legacy.MsigAccount {
+		t.Errorf("legacy settings not moved into profile: %#v", p)
+	}
+	if len(p.Servers) != len(MainnetApi) {
+		t.Errorf("expected %d mainnet servers, got %d", len(MainnetApi), len(p.Servers))
+	}
+	if decrypted.Server != "" || decrypted.Tpid != "" || decrypted.MsigAccount != "" {
+		t.Error("legacy network settings were not cleared")
+	}
+	if decrypted.ProfileKey() == nil || decrypted.ProfileKey().Wif != legacy.DefaultKey {
+		t.Error("profile default key not set")
+	}
+
+	custom := &FioSettings{Server: "http://10.0.0.1:8888"}
+	custom.migrateProfiles()
+	if custom.Profile().Name != "custom" || custom.Profile().Server() != "http://10.0.0.1:8888" {
+		t.Errorf("unknown server should use the custom profile, got %#v", custom.Profile())
+	}
+}
+
+func TestCheckChainId(t *testing.T) {
+	s := DefaultSettings()
+	devnet := s.FindProfile("devnet")
+	if err := devnet.CheckChainId(devnet.ChainId); err != nil {
+		t.Error(err)
+	}
+	err := devnet.CheckChainId(s.FindProfile("mainnet").ChainId)
+	if err == nil {
+		t.Fatal("devnet profile should refuse mainnet")
+	}
+	fmt.Println(err)
+	if err = s.FindProfile("custom").CheckChainId(devnet.ChainId); err != nil {
+		t.Error("custom profile should allow any chain")
+	}
+}
+
+// legacyEncrypt writes settings the way cryptonym did before the file had a header
+func legacyEncrypt(t *testing.T, set *FioSettings, password string) []byte {
+	salt := make([]byte, legacySaltLen)
+	_, _ = rand.Read(salt)
+	data := bytes.NewBuffer(nil)
+	if err := gob.NewEncoder(data).Encode(set); err != nil {
+		t.Fatal(err)
+	}
+	key := pbkdf2.Key([]byte(password), salt, 12*1024, 48, sha256.New)
+	gcm, err := newGcm(key[:32])
+	if err != nil {
+		t.Fatal(err)
+	}
+	padLen := aes.BlockSize - (data.Len() % aes.BlockSize)
+	plaintext := append(data.Bytes(), bytes.Repeat([]byte{uint8(padLen)}, padLen)...)
+	return append(salt, gcm.Seal(nil, key[len(key)-gcm.NonceSize():], plaintext, nil)...)
+}
+
+func TestLegacySettings(t *testing.T) {
+	encrypted := legacyEncrypt(t, DefaultSettings(), "password")
+	if !isLegacySettings(encrypted) {
+		t.Fatal("legacy file was not detected")
+	}
+	if _, err := DecryptSettings(encrypted, "wrong"); err == nil {
+		t.Error("expected wrong password to fail")
+	}
+	decrypted, err := DecryptSettings(encrypted, "password")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if decrypted.Kdf != DefaultKdf() {
+		t.Errorf("legacy file should be upgraded to the default kdf, got %s", decrypted.Kdf)
+	}
+	// saving again uses the new format
+	upgraded, err := EncryptSettings(decrypted, nil, "password")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if isLegacySettings(upgraded) {
+		t.Fatal("re-encrypted file does not have a header")
+	}
+	if _, err = DecryptSettings(upgraded, "password"); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestKdfHeader(t *testing.T) {
+	s := DefaultSettings()
+	s.Kdf = NewKdfParams(KdfScrypt, 16*1024)
+	encrypted, err := EncryptSettings(s, nil, "password")
+	if err != nil {
+		t.Fatal(err)
+	}
+	params, _, _, err := parseSettingsHeader(encrypted)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if params != s.Kdf {
+		t.Errorf("header has %s, expected %s", params, s.Kdf)
+	}
+	decrypted, err := DecryptSettings(encrypted, "password")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if decrypted.Kdf != s.Kdf {
+		t.Errorf("kdf should be kept from the file, got %s", decrypted.Kdf)
+	}
+
+	// the header is authenticated, lowering the cost has to break decryption
+	tampered := append([]byte{}, encrypted...)
+	tampered[14] = 2
+	if _, err = DecryptSettings(tampered, "password"); err == nil {
+		t.Error("modified header should not decrypt")
+	}
+	tampered = append([]byte{}, encrypted...)
+	binary.BigEndian.PutUint32(tampered[10:14], 0xffffffff)
+	if _, err = DecryptSettings(tampered, "password"); err == nil {
+		t.Error("expected absurd memory cost to be refused")
+	}
+
+	s.Kdf = NewKdfParams(KdfScrypt, 10*1024)
+	if _, err = EncryptSettings(s, nil, "password"); err == nil {
+		t.Error("scrypt memory that isn't a power of 2 should be refused")
+	}
+}
+
+func TestSettingsSchema(t *testing.T) {
+	s := DefaultSettings()
+	plain, err := encodeSettings(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	doc := &settingsDocument{}
+	if err = json.Unmarshal(plain, doc); err != nil {
+		t.Fatal("settings document is not json: ", err)
+	}
+	if doc.SchemaVersion != SettingsSchemaVersion {
+		t.Errorf("expected schema version %d, got %d", SettingsSchemaVersion, doc.SchemaVersion)
+	}
+	decoded, err := decodeSettings(plain)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(decoded.Keys) != len(s.Keys) || decoded.Profile().Name != s.Profile().Name || decoded.Kdf != s.Kdf {
+		t.Errorf("settings changed after round trip: %#v", decoded)
+	}
+
+	// a version 1 json document is migrated the same as gob
+	v1 := []byte(`{"schema_version":1,"settings":{"server":"http://127.0.0.1:8888","default_key":"5JBbUG5SDpLWxvBKihMeXLENinUzdNKNeozLas23Mj6ZNhz3hLS","default_key_desc":"vote 1"}}`)
+	decoded, err = decodeSettings(v1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if decoded.FindKey("vote 1") == nil || decoded.Profile().Name != "devnet" || decoded.Profile().DefaultKey != "vote 1" {
+		t.Errorf("version 1 document was not migrated: %#v", decoded)
+	}
+	if decoded.IdleLockMinutes != DefaultSettings().IdleLockMinutes {
+		t.Errorf("expected the default idle lock after migrating, got %d", decoded.IdleLockMinutes)
+	}
+
+	// a version 2 file as it was written before the idle lock, with its empty fields
+	v2 := []byte(`{"schema_version":2,"settings":{"proxy":"","profiles":[{"name":"devnet","servers":["http://127.0.0.1:8888"],"chain_id":"","default_key":"","tpid":"","msig_account":""}],` +
+		`"active_profile":"devnet","keys":[],"advanced_features":false,"signer_type":"local","keosd_address":"",` +
+		`"keosd_wallet":"","keosd_password":"","external_signer":""}}`)
+	if decoded, err = decodeSettings(v2); err != nil || decoded.IdleLockMinutes != DefaultSettings().IdleLockMinutes || decoded.SignerType != SignerLocal {
+		t.Errorf("expected the default idle lock for a version 2 file, got %d %v", decoded.IdleLockMinutes, err)
+	}
+
+	if _, err = decodeSettings([]byte(`{"schema_version":99,"settings":{}}`)); err == nil {
+		t.Error("a newer schema version should be refused")
+	}
+}
+
+func TestExportSettings(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cryptonym")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	for _, env := range []string{"XDG_CONFIG_HOME", "HOME"} {
+		defer os.Setenv(env, os.Getenv(env))
+		_ = os.Setenv(env, dir)
+	}
+
+	s := DefaultSettings()
+	s.Kdf = NewKdfParams(KdfArgon2id, 8*1024)
+	s.AddKey("exported", "5KQ6f9ZgUtagD3LZ4wcMKhhvK9qy4BuwL3L1pkm6E2v62HCne2R", []string{"bp"})
+	if ok, err := SaveEncryptedSettings("local password", s); !ok {
+		t.Fatal("could not save settings: ", err)
+	}
+	backup := filepath.Join(dir, "backup.dat")
+	if err = ExportSettings(backup, "wrong", "backup password"); err == nil {
+		t.Error("export should require the settings password")
+	}
+	if err = ExportSettings(backup, "local password", "backup password"); err != nil {
+		t.Fatal(err)
+	}
+	if err = ImportSettings(backup, "local password", "new password"); err == nil {
+		t.Error("import should require the backup password")
+	}
+	if err = ImportSettings(backup, "backup password", "new password"); err != nil {
+		t.Fatal(err)
+	}
+	ok, _, imported, err := LoadEncryptedSettings("new password")
+	if !ok {
+		t.Fatal("could not load imported settings: ", err)
+	}
+	if imported.FindKey("exported") == nil {
+		t.Error("imported settings are missing the exported key")
+	}
+}
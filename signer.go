@@ -0,0 +1,406 @@
+package cryptonym
+
+import (
+	"bufio"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/fioprotocol/fio-go"
+	"github.com/fioprotocol/fio-go/eos"
+	"github.com/fioprotocol/fio-go/eos/ecc"
+	"io"
+	"os/exec"
+	"strings"
+	"sync"
+)
+
+const (
+	SignerLocal    = "local"
+	SignerKeosd    = "keosd"
+	SignerExternal = "external"
+)
+
+var SignerTypes = []string{SignerLocal, SignerKeosd, SignerExternal}
+
+// Signer is used by everything that sends a transaction, the implementation is chosen in the settings.
+type Signer interface {
+	eos.Signer
+
+	// RequiredKeys are the keys that should sign for the account, signing with extra keys will cause
+	// nodeos to reject the transaction.
+	RequiredKeys(account *fio.Account) ([]ecc.PublicKey, error)
+	Description() string
+}
+
+// LocalSigner signs with a WIF loaded into the account's key bag
+type LocalSigner struct {
+	*eos.KeyBag
+}
+
+func (l *LocalSigner) RequiredKeys(account *fio.Account) ([]ecc.PublicKey, error) {
+	if l.KeyBag == nil || len(l.KeyBag.Keys) == 0 {
+		return nil, errors.New("no private key is loaded, select a different signer in settings or import a WIF")
+	}
+	return l.KeyBag.AvailableKeys()
+}
+
+func (l *LocalSigner) Description() string {
+	return "local private key"
+}
+
+// ExternalSigner runs a separate program that signs digests, it is started once and kept running.
+// The protocol is one JSON object per line on stdin, with one JSON object per line of reply on stdout:
+//
+//	{"method":"public_keys"}                                      -> {"public_keys":["FIO..."]}
+//	{"method":"sign_digest","digest":"<hex>","public_key":"FIO..."} -> {"signature":"SIG_K1_..."}
+//
+// any reply can instead be {"error":"message"}.
+type ExternalSigner struct {
+	Command string
+
+	mux    sync.Mutex
+	cmd    *exec.Cmd
+	stdin  io.WriteCloser
+	stdout *bufio.Reader
+}
+
+type externalRequest struct {
+	Method    string `json:"method"`
+	Digest    string `json:"digest,omitempty"`
+	PublicKey string `json:"public_key,omitempty"`
+}
+
+type externalResponse struct {
+	PublicKeys []string `json:"public_keys,omitempty"`
+	Signature  string   `json:"signature,omitempty"`
+	Error      string   `json:"error,omitempty"`
+}
+
+func NewExternalSigner(command string) *ExternalSigner {
+	return &ExternalSigner{Command: command}
+}
+
+func (x *ExternalSigner) start() error {
+	if x.cmd != nil {
+		return nil
+	}
+	args := strings.Fields(x.Command)
+	if len(args) == 0 {
+		return errors.New("no command set for external signer")
+	}
+	cmd := exec.Command(args[0], args[1:]...)
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	if err = cmd.Start(); err != nil {
+		return err
+	}
+	x.cmd, x.stdin, x.stdout = cmd, stdin, bufio.NewReader(stdout)
+	return nil
+}
+
+// Close stops the signing process, it will be restarted on the next request
+func (x *ExternalSigner) Close() {
+	x.mux.Lock()
+	defer x.mux.Unlock()
+	x.stop()
+}
+
+func (x *ExternalSigner) stop() {
+	if x.cmd == nil {
+		return
+	}
+	_ = x.stdin.Close()
+	if x.cmd.Process != nil {
+		_ = x.cmd.Process.Kill()
+	}
+	_ = x.cmd.Wait()
+	x.cmd = nil
+}
+
+func (x *ExternalSigner) call(req externalRequest) (*externalResponse, error) {
+	x.mux.Lock()
+	defer x.mux.Unlock()
+	if err := x.start(); err != nil {
+		return nil, err
+	}
+	j, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+	if _, err = x.stdin.Write(append(j, '\n')); err != nil {
+		x.stop()
+		return nil, err
+	}
+	line, err := x.stdout.ReadBytes('\n')
+	if err != nil {
+		// the process probably exited, start over next time
+		x.stop()
+		return nil, fmt.Errorf("external signer: %s", err.Error())
+	}
+	resp := &externalResponse{}
+	if err = json.Unmarshal(line, resp); err != nil {
+		return nil, fmt.Errorf("external signer sent an invalid response: %s", err.Error())
+	}
+	if resp.Error != "" {
+		return nil, errors.New("external signer: " + resp.Error)
+	}
+	return resp, nil
+}
+
+func (x *ExternalSigner) AvailableKeys() (out []ecc.PublicKey, err error) {
+	resp, err := x.call(externalRequest{Method: "public_keys"})
+	if err != nil {
+		return nil, err
+	}
+	for _, k := range resp.PublicKeys {
+		pub, err := ecc.NewPublicKey(k)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, pub)
+	}
+	return
+}
+
+// SignDigest asks the external process for a single signature
+func (x *ExternalSigner) SignDigest(digest []byte, key ecc.PublicKey) (ecc.Signature, error) {
+	resp, err := x.call(externalRequest{
+		Method:    "sign_digest",
+		Digest:    hex.EncodeToString(digest),
+		PublicKey: fioPubKey(key),
+	})
+	if err != nil {
+		return ecc.Signature{}, err
+	}
+	sig, err := ecc.NewSignature(resp.Signature)
+	if err != nil {
+		return ecc.Signature{}, err
+	}
+	// don't trust the signer, make sure the signature is for the digest and key that were requested
+	recovered, err := sig.PublicKey(digest)
+	if err != nil {
+		return ecc.Signature{}, err
+	}
+	if recovered.String() != key.String() {
+		return ecc.Signature{}, fmt.Errorf("external signer returned a signature from the wrong key: %s", fioPubKey(recovered))
+	}
+	return sig, nil
+}
+
+func (x *ExternalSigner) Sign(tx *eos.SignedTransaction, chainID []byte, requiredKeys ...ecc.PublicKey) (*eos.SignedTransaction, error) {
+	txdata, cfd, err := tx.PackedTransactionAndCFD()
+	if err != nil {
+		return nil, err
+	}
+	digest := eos.SigDigest(chainID, txdata, cfd)
+	for _, key := range requiredKeys {
+		sig, err := x.SignDigest(digest, key)
+		if err != nil {
+			return nil, err
+		}
+		tx.Signatures = append(tx.Signatures, sig)
+	}
+	return tx, nil
+}
+
+func (x *ExternalSigner) ImportPrivateKey(wifPrivKey string) error {
+	return errors.New("the external signer manages its own keys")
+}
+
+func (x *ExternalSigner) RequiredKeys(account *fio.Account) ([]ecc.PublicKey, error) {
+	return requireAccountKey(x, account, "external signer")
+}
+
+func (x *ExternalSigner) Description() string {
+	return "external signer: " + x.Command
+}
+
+// requireAccountKey is used by remote signers that hold many keys, only the account's key is returned
+func requireAccountKey(signer eos.Signer, account *fio.Account, name string) ([]ecc.PublicKey, error) {
+	pub, err := ecc.NewPublicKey(account.PubKey)
+	if err != nil {
+		return nil, err
+	}
+	available, err := signer.AvailableKeys()
+	if err != nil {
+		return nil, err
+	}
+	for _, a := range available {
+		if a.String() == pub.String() {
+			return []ecc.PublicKey{pub}, nil
+		}
+	}
+	return nil, fmt.Errorf("%s does not have the key %s", name, account.PubKey)
+}
+
+var (
+	keosdSigner    *KeosdSigner
+	externalSigner *ExternalSigner
+)
+
+// keosdFromSettings re-uses the signer unless the keosd settings have changed
+func keosdFromSettings() *KeosdSigner {
+	if keosdSigner == nil || keosdSigner.Wallet != Settings.KeosdWallet || keosdSigner.password != Settings.KeosdPassword ||
+		keosdSigner.api.BaseURL != strings.TrimRight(Settings.KeosdAddress, "/") {
+		keosdSigner = NewKeosdSigner(Settings.KeosdAddress, Settings.KeosdWallet, Settings.KeosdPassword)
+	}
+	return keosdSigner
+}
+
+// SignerFor returns the signer selected in the settings for an account
+func SignerFor(account *fio.Account) (Signer, error) {
+	if account == nil {
+		return nil, errors.New("no account loaded")
+	}
+	signerType := SignerLocal
+	if Settings != nil && Settings.SignerType != "" {
+		signerType = Settings.SignerType
+	}
+	switch signerType {
+	case SignerLocal:
+		return &LocalSigner{KeyBag: account.KeyBag}, nil
+	case SignerKeosd:
+		return keosdFromSettings(), nil
+	case SignerExternal:
+		if externalSigner == nil || externalSigner.Command != Settings.ExternalSigner {
+			if externalSigner != nil {
+				externalSigner.Close()
+			}
+			externalSigner = NewExternalSigner(Settings.ExternalSigner)
+		}
+		return externalSigner, nil
+	}
+	return nil, fmt.Errorf("unknown signer type %q", signerType)
+}
+
+// applySigner sets the signer from the settings on an API connection, it must be called before signing
+// anything so that changes in the settings are picked up.
+func applySigner(api *fio.API, account *fio.Account) error {
+	signer, err := unlockedSigner(account)
+	if err != nil {
+		return err
+	}
+	api.SetSigner(signer)
+	api.SetCustomGetRequiredKeys(func(tx *eos.Transaction) ([]ecc.PublicKey, error) {
+		return signer.RequiredKeys(account)
+	})
+	return nil
+}
+
+// applySessionSigner does the same for the shared Session, which is used by scenarios
+func applySessionSigner() error {
+	account := Session.Account()
+	signer, err := unlockedSigner(account)
+	if err != nil {
+		return err
+	}
+	Session.SetSigner(accountKeys{Signer: signer, account: account})
+	return nil
+}
+
+// unlockedSigner is the signer from the settings, if signing is allowed right now
+func unlockedSigner(account *fio.Account) (Signer, error) {
+	if Locked() {
+		RequestUnlock()
+		return nil, errLocked
+	}
+	if WatchOnly {
+		return nil, errWatchOnly
+	}
+	MarkActivity()
+	signer, err := SignerFor(account)
+	if err != nil {
+		return nil, err
+	}
+	if _, err = signer.RequiredKeys(account); err != nil {
+		return nil, err
+	}
+	return signer, nil
+}
+
+// accountKeys limits a signer to the account's keys. engine.Session signs with every available key, and keosd
+// will have others.
+type accountKeys struct {
+	Signer
+	account *fio.Account
+}
+
+func (a accountKeys) AvailableKeys() ([]ecc.PublicKey, error) {
+	return a.RequiredKeys(a.account)
+}
+
+// pubKeySigner only lists the account's public key, it's enough for get_required_keys in a dry run when the
+// account is watch-only
+type pubKeySigner struct {
+	pub ecc.PublicKey
+}
+
+func newPubKeySigner(account *fio.Account) (*pubKeySigner, error) {
+	if account == nil {
+		return nil, errors.New("no account loaded")
+	}
+	pub, err := ecc.NewPublicKey(account.PubKey)
+	if err != nil {
+		return nil, err
+	}
+	return &pubKeySigner{pub: pub}, nil
+}
+
+func (p *pubKeySigner) AvailableKeys() ([]ecc.PublicKey, error) {
+	return []ecc.PublicKey{p.pub}, nil
+}
+
+func (p *pubKeySigner) Sign(tx *eos.SignedTransaction, chainID []byte, requiredKeys ...ecc.PublicKey) (*eos.SignedTransaction, error) {
+	return nil, errWatchOnly
+}
+
+func (p *pubKeySigner) ImportPrivateKey(wifPrivKey string) error {
+	return errWatchOnly
+}
+
+// SignerPublicKeys lists the keys a remote signer (keosd or external) can sign with, used for picking the key to load
+func SignerPublicKeys() ([]string, error) {
+	if Settings == nil || Settings.SignerType == "" || Settings.SignerType == SignerLocal {
+		return nil, errors.New("the local signer uses an imported WIF")
+	}
+	signer, err := SignerFor(&fio.Account{})
+	if err != nil {
+		return nil, err
+	}
+	keys, err := signer.AvailableKeys()
+	if err != nil {
+		return nil, err
+	}
+	pubs := make([]string, 0)
+	for _, key := range keys {
+		pubs = append(pubs, fioPubKey(key))
+	}
+	return pubs, nil
+}
+
+// NewPubKeyAccount creates an account that only knows its public key, signing is done by a remote signer.
+func NewPubKeyAccount(pubKey string) (*fio.Account, error) {
+	actor, err := fio.ActorFromPub(pubKey)
+	if err != nil {
+		return nil, err
+	}
+	return &fio.Account{
+		KeyBag:    eos.NewKeyBag(),
+		PubKey:    pubKey,
+		Actor:     actor,
+		Addresses: make([]fio.FioName, 0),
+		Domains:   make([]fio.FioName, 0),
+	}, nil
+}
+
+// HasPrivateKey is false when using a remote signer, some actions (encrypting request content) need the key locally
+func HasPrivateKey(account *fio.Account) bool {
+	return account != nil && account.KeyBag != nil && len(account.KeyBag.Keys) > 0
+}
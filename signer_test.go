@@ -0,0 +1,147 @@
+package cryptonym
+
+import (
+	"bufio"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"github.com/fioprotocol/fio-go"
+	"github.com/fioprotocol/fio-go/eos"
+	"github.com/fioprotocol/fio-go/eos/ecc"
+	"os"
+	"testing"
+)
+
+const testSignerWif = "5KC6Edd4BcKTLnRuGj2c8TRT9oLuuXLd3ZuCGxM9iNngc3D8S93"
+
+// TestExternalSignerProcess isn't a real test, it is the external signer started by TestExternalSigner
+func TestExternalSignerProcess(t *testing.T) {
+	if os.Getenv("CRYPTONYM_TEST_SIGNER") != "1" {
+		return
+	}
+	defer os.Exit(0)
+	acc, _ := fio.NewAccountFromWif(testSignerWif)
+	in := bufio.NewScanner(os.Stdin)
+	reply := func(v interface{}) {
+		j, _ := json.Marshal(v)
+		fmt.Println(string(j))
+	}
+	for in.Scan() {
+		req := externalRequest{}
+		if err := json.Unmarshal(in.Bytes(), &req); err != nil {
+			reply(externalResponse{Error: err.Error()})
+			continue
+		}
+		switch req.Method {
+		case "public_keys":
+			reply(externalResponse{PublicKeys: []string{acc.PubKey}})
+		case "sign_digest":
+			if req.PublicKey != acc.PubKey {
+				reply(externalResponse{Error: "unknown key"})
+				continue
+			}
+			digest, _ := hex.DecodeString(req.Digest)
+			sig, err := acc.KeyBag.Keys[0].Sign(digest)
+			if err != nil {
+				reply(externalResponse{Error: err.Error()})
+				continue
+			}
+			reply(externalResponse{Signature: sig.String()})
+		default:
+			reply(externalResponse{Error: "unknown method"})
+		}
+	}
+}
+
+func TestExternalSigner(t *testing.T) {
+	acc, err := fio.NewAccountFromWif(testSignerWif)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = os.Setenv("CRYPTONYM_TEST_SIGNER", "1")
+	defer os.Unsetenv("CRYPTONYM_TEST_SIGNER")
+
+	Settings = DefaultSettings()
+	Settings.SignerType = SignerExternal
+	Settings.ExternalSigner = os.Args[0] + " -test.run=TestExternalSignerProcess"
+	defer func() {
+		if externalSigner != nil {
+			externalSigner.Close()
+		}
+		Settings = DefaultSettings()
+	}()
+
+	pubs, err := SignerPublicKeys()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pubs) != 1 || pubs[0] != acc.PubKey {
+		t.Fatalf("expected signer to list %s, got %v", acc.PubKey, pubs)
+	}
+
+	pubAcc, err := NewPubKeyAccount(acc.PubKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer, err := SignerFor(pubAcc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := signer.(*ExternalSigner); !ok {
+		t.Fatalf("expected an external signer, got %s", signer.Description())
+	}
+
+	api := &fio.API{API: eos.New("http://127.0.0.1:1")}
+	if err = applySigner(api, pubAcc); err != nil {
+		t.Fatal(err)
+	}
+	opts := &fio.TxOptions{}
+	opts.ChainID, _ = hex.DecodeString(fio.ChainIdMainnet)
+	opts.HeadBlockID = make([]byte, 32)
+	tx := fio.NewTransaction(
+		[]*fio.Action{fio.NewTransferTokensPubKey(pubAcc.Actor, "FIO6G9pXXM92Gy5eMwNquGULoCj3ZStwPLPdEb9mVXZz6nMBGd2VY", fio.Tokens(1.0))},
+		opts,
+	)
+	signed, _, err := api.SignTransaction(tx, opts.ChainID, fio.CompressionNone)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(signed.Signatures) != 1 {
+		t.Fatalf("expected one signature, got %d", len(signed.Signatures))
+	}
+	txdata, cfd, _ := signed.PackedTransactionAndCFD()
+	recovered, err := signed.Signatures[0].PublicKey(eos.SigDigest(opts.ChainID, txdata, cfd))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fioPubKey(recovered) != acc.PubKey {
+		t.Errorf("signature is from %s, expected %s", fioPubKey(recovered), acc.PubKey)
+	}
+
+	// keys the signer doesn't hold are refused before signing
+	other, _ := fio.NewRandomAccount()
+	if err = applySigner(api, other); err == nil {
+		t.Error("expected an error for a key the signer does not have")
+	}
+	otherPub, _ := ecc.NewPublicKey(other.PubKey)
+	if _, err = externalSigner.SignDigest(make([]byte, 32), otherPub); err == nil {
+		t.Error("expected an error signing with an unknown key")
+	}
+}
+
+func TestLocalSigner(t *testing.T) {
+	Settings = DefaultSettings()
+	acc, _ := fio.NewAccountFromWif(testSignerWif)
+	signer, err := SignerFor(acc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys, err := signer.RequiredKeys(acc)
+	if err != nil || len(keys) != 1 || fioPubKey(keys[0]) != acc.PubKey {
+		t.Errorf("expected the local key to be required, got %v %v", keys, err)
+	}
+	pubAcc, _ := NewPubKeyAccount(acc.PubKey)
+	if err = applySigner(&fio.API{API: eos.New("http://127.0.0.1:1")}, pubAcc); err == nil {
+		t.Error("local signer should refuse an account without a private key")
+	}
+}
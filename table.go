@@ -1,17 +1,14 @@
 package cryptonym
 
 import (
-	"crypto/sha256"
-	"encoding/hex"
 	"encoding/json"
-	"fmt"
 	"fyne.io/fyne"
 	"fyne.io/fyne/layout"
 	"fyne.io/fyne/theme"
 	"fyne.io/fyne/widget"
+	"github.com/blockpane/cryptonym/engine"
 	errs "github.com/blockpane/cryptonym/errLog"
 	"github.com/fioprotocol/fio-go"
-	"github.com/fioprotocol/fio-go/eos"
 	"math"
 	"sort"
 	"strconv"
@@ -24,105 +21,21 @@ type FioActions struct {
 	Actions map[string][]string
 }
 
-type contracts struct {
-	Owner string `json:"owner"`
-}
-
 func GetAccountSummary(api *fio.API) (*FioActions, error) {
-
-	table, err := api.GetTableRows(eos.GetTableRowsRequest{
-		Code:  "eosio",
-		Scope: "eosio",
-		Table: "abihash",
-		Limit: uint32(math.MaxUint32),
-		JSON:  true,
-	})
-	if err != nil {
-		return nil, err
-	}
-	result := make([]contracts, 0)
-	err = json.Unmarshal(table.Rows, &result)
+	c, err := engine.GetContracts(api)
 	if err != nil {
 		return nil, err
 	}
-	// FIXME: reading the abihash table isn't returning everything because of how the chain is boostrapped.
-	// for now, appending a list of known contracts if not found :(
-	defaults := []contracts{
-		{Owner: "eosio"},
-		//{Owner: "eosio.bios"},
-		{Owner: "eosio.msig"},
-		{Owner: "eosio.wrap"},
-		{Owner: "fio.address"},
-		//{Owner: "fio.common"},
-		{Owner: "fio.fee"},
-		{Owner: "fio.foundatn"},
-		{Owner: "fio.reqobt"},
-		//{Owner: "fio.system"},
-		{Owner: "fio.token"},
-		{Owner: "fio.tpid"},
-		{Owner: "fio.treasury"},
-		//{Owner: "fio.whitelst"},
+	for _, err := range c.Errors {
+		errs.ErrChan <- "problem while loading abi: " + err.Error()
 	}
-	for _, def := range defaults {
-		func() {
-			for _, found := range result {
-				if def.Owner == found.Owner {
-					return
-				}
-			}
-			result = append(result, def)
-		}()
-	}
-
-	actions := FioActions{
-		Actions: make(map[string][]string),
+	for contract, tables := range c.Tables {
+		TableIndex.Add(contract, tables)
 	}
-
-	// sort by account name
-	func() {
-		sorted := make([]string, 0)
-		resultTemp := make([]contracts, 0)
-		sortMap := make(map[string]int)
-		for i, c := range result {
-			sorted = append(sorted, c.Owner)
-			sortMap[c.Owner] = i
-		}
-		sort.Strings(sorted)
-		for _, ascending := range sorted {
-			resultTemp = append(resultTemp, result[sortMap[ascending]])
-		}
-		result = resultTemp
-	}()
-
-	for _, name := range result {
-		bi, err := api.GetABI(eos.AccountName(name.Owner))
-		if err != nil {
-			errs.ErrChan <- "problem while loading abi: " + err.Error()
-			continue
-		}
-		actionList := make(map[string]bool, 0)
-		for _, name := range bi.ABI.Actions {
-			actionList[string(name.Name)] = true
-		}
-		if actions.Actions[name.Owner] == nil {
-			actions.Actions[name.Owner] = make([]string, 0)
-			actions.Index = append(actions.Index, name.Owner)
-		}
-		for a := range actionList {
-			actions.Actions[name.Owner] = append(actions.Actions[name.Owner], a)
-		}
-		func() {
-			tableList := make([]string, 0)
-			for _, table := range bi.ABI.Tables {
-				tableList = append(tableList, string(table.Name))
-			}
-			if len(tableList) == 0 {
-				return
-			}
-			TableIndex.Add(name.Owner, tableList)
-		}()
-	}
-	return &actions, nil
+	return &FioActions{
+		Index:   c.Index,
+		Actions: c.Actions,
+	}, nil
 }
 
 type TableBrowserIndex struct {
@@ -456,92 +369,48 @@ Result
 }
 
 func QueryTable(offset uint32, max uint32, contract string, table string, api *fio.API) (out *string, query string, more bool) {
-	gtr := eos.GetTableRowsRequest{
-		Code:       contract,
-		Scope:      contract,
-		Table:      table,
-		LowerBound: strconv.Itoa(int(offset)),
-		Limit:      max,
-		JSON:       true,
-	}
-	qs, _ := json.MarshalIndent(gtr, "", "  ")
-	query = string(qs)
-	resp, err := api.GetTableRows(gtr)
-	var o string
-	if err != nil {
-		o = err.Error()
-		return &o, query, more
-	}
-	more = resp.More
-	j, err := json.MarshalIndent(resp.Rows, "", "  ")
-	if err != nil {
-		o = err.Error()
-		return &o, query, more
-	}
-	o = string(j)
-	return &o, query, more
+	return queryTable(api, &engine.TableQuery{
+		Contract: contract,
+		Table:    table,
+		Lower:    strconv.Itoa(int(offset)),
+		Limit:    max,
+	})
 }
 
 func QueryTableAdvanced(max uint32, scope string, contract string, table string, index string, keyType string, lower string, upper string, transform string, reverse bool, api *fio.API) (out *string, query string, more bool) {
 	if keyType == "(key type)" {
 		keyType = "name"
 	}
-	switch transform {
-	case "name -> i64":
-		u, err := eos.StringToName(upper)
-		if err != nil {
-			e := err.Error()
-			out = &e
-			return
-		}
-		l, err := eos.StringToName(lower)
-		if err != nil {
-			e := err.Error()
-			out = &e
-			return
-		}
-		upper = fmt.Sprintf("%d", u)
-		lower = fmt.Sprintf("%d", l)
-	case "checksum256":
-		h := sha256.New()
-		_, err := h.Write([]byte(upper))
-		if err != nil {
-			e := err.Error()
-			out = &e
-			return
-		}
-		ub := h.Sum(nil)
-		upper = hex.EncodeToString(ub)
-		h.Reset()
-		h.Write([]byte(lower))
-		lb := h.Sum(nil)
-		lower = hex.EncodeToString(lb)
-	case "hash":
-		upper = FioDomainNameHash(upper)
-		lower = FioDomainNameHash(lower)
-	}
-	gtr := fio.GetTableRowsOrderRequest{
-		Code:       contract,
-		Scope:      scope,
-		Table:      table,
-		LowerBound: lower,
-		UpperBound: upper,
-		Limit:      max,
-		KeyType:    keyType,
-		Index:      index,
-		JSON:       true,
-		Reverse:    reverse,
+	return queryTable(api, &engine.TableQuery{
+		Contract:  contract,
+		Scope:     scope,
+		Table:     table,
+		Index:     index,
+		KeyType:   keyType,
+		Lower:     lower,
+		Upper:     upper,
+		Transform: transform,
+		Reverse:   reverse,
+		Limit:     max,
+	})
+}
+
+// queryTable returns the indented rows, or the error, for the table browser
+func queryTable(api *fio.API, q *engine.TableQuery) (out *string, query string, more bool) {
+	var o string
+	gtr, err := q.Request()
+	if err != nil {
+		o = err.Error()
+		return &o, query, more
 	}
 	qs, _ := json.MarshalIndent(gtr, "", "  ")
 	query = string(qs)
-	resp, err := api.GetTableRowsOrder(gtr)
-	var o string
+	rows, more, err := engine.QueryTable(api, q)
 	if err != nil {
 		o = err.Error()
 		return &o, query, more
 	}
-	more = resp.More
-	j, err := json.MarshalIndent(resp.Rows, "", "  ")
+	j, err := json.MarshalIndent(rows, "", "  ")
 	if err != nil {
 		o = err.Error()
 		return &o, query, more
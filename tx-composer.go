@@ -0,0 +1,124 @@
+package cryptonym
+
+import (
+	"fyne.io/fyne"
+	"fyne.io/fyne/layout"
+	"fyne.io/fyne/theme"
+	"fyne.io/fyne/widget"
+	"github.com/blockpane/cryptonym/engine"
+	errs "github.com/blockpane/cryptonym/errLog"
+	"github.com/blockpane/cryptonym/fuzzer"
+)
+
+var (
+	// Composer holds the actions that will be sent together in one transaction
+	Composer       = engine.NewComposition()
+	composerWindow fyne.Window
+	// sendComposition is set by the action editor, it opens the results window using the editor's settings
+	sendComposition = func() {}
+	// composerRefresh redraws the composer window if it's open
+	composerRefresh = func() {}
+)
+
+// ComposerWindow shows the actions in the composition, they can be reordered, removed, previewed and sent
+func ComposerWindow() {
+	if composerWindow != nil {
+		composerRefresh()
+		composerWindow.RequestFocus()
+		return
+	}
+	composerWindow = App.NewWindow("Transaction Composer")
+	summary := widget.NewLabel("")
+	list := widget.NewVBox()
+	preview := widget.NewMultiLineEntry()
+
+	showPreview := func() {
+		var payloads []*engine.Payload
+		err := fuzzer.WithSeed(fuzzSeed, 0, func() (e error) {
+			payloads, e = Composer.Generate(Account, Uri)
+			return
+		})
+		if err != nil {
+			preview.SetText(err.Error())
+			return
+		}
+		j, err := engine.Preview(Account, payloads)
+		if err != nil {
+			preview.SetText(err.Error())
+			return
+		}
+		preview.SetText(string(j))
+	}
+
+	composerRefresh = func() {
+		actions := Composer.Actions()
+		summary.SetText(p.Sprintf("%d actions in one transaction, in this order:", len(actions)))
+		list.Children = make([]fyne.CanvasObject, 0, len(actions))
+		for i := range actions {
+			i := i
+			list.Append(widget.NewHBox(
+				widget.NewButtonWithIcon("", theme.MoveUpIcon(), func() {
+					Composer.Move(i, i-1)
+					composerRefresh()
+				}),
+				widget.NewButtonWithIcon("", theme.MoveDownIcon(), func() {
+					Composer.Move(i, i+1)
+					composerRefresh()
+				}),
+				widget.NewButtonWithIcon("", theme.DeleteIcon(), func() {
+					Composer.Remove(i)
+					composerRefresh()
+				}),
+				widget.NewLabelWithStyle(p.Sprintf("%d. %s", i+1, actions[i].String()), fyne.TextAlignLeading, fyne.TextStyle{Monospace: true}),
+			))
+		}
+		list.Refresh()
+		if len(actions) > 0 {
+			// generators can query the chain, don't hold up the window
+			go showPreview()
+		} else {
+			preview.SetText("")
+		}
+	}
+
+	send := widget.NewButtonWithIcon("Send", theme.MailSendIcon(), func() {
+		if Composer.Len() == 0 {
+			errs.ErrChan <- "add actions from the action editor first"
+			return
+		}
+		sendComposition()
+	})
+	disableWatchOnly(send)
+	top := widget.NewHBox(
+		send,
+		widget.NewButtonWithIcon("preview", theme.VisibilityIcon(), showPreview),
+		widget.NewButtonWithIcon("clear", theme.ContentClearIcon(), func() {
+			Composer.Clear()
+			composerRefresh()
+		}),
+		widget.NewButtonWithIcon("dry run", theme.SearchIcon(), func() {
+			go dryRun(Composer, false)
+		}),
+		widget.NewButtonWithIcon("export unsigned", theme.DocumentSaveIcon(), func() {
+			go exportComposition()
+		}),
+		widget.NewButtonWithIcon("import", theme.FolderOpenIcon(), func() {
+			importOffline(composerWindow)
+		}),
+		summary,
+	)
+	composerWindow.SetContent(fyne.NewContainerWithLayout(layout.NewBorderLayout(top, nil, nil, nil),
+		top,
+		fyne.NewContainerWithLayout(layout.NewGridLayout(2),
+			widget.NewScrollContainer(list),
+			widget.NewScrollContainer(preview),
+		),
+	))
+	composerWindow.SetOnClosed(func() {
+		composerWindow = nil
+		composerRefresh = func() {}
+	})
+	composerRefresh()
+	composerWindow.Resize(fyne.NewSize(W, H))
+	composerWindow.Show()
+}
@@ -0,0 +1,64 @@
+package cryptonym
+
+import (
+	"context"
+	"fyne.io/fyne"
+	"fyne.io/fyne/widget"
+	"github.com/blockpane/cryptonym/engine"
+	errs "github.com/blockpane/cryptonym/errLog"
+	"github.com/blockpane/cryptonym/fuzzer"
+	"gopkg.in/yaml.v3"
+)
+
+// dryRun shows the sizes, fees and required keys for the editor's action, or the composer's actions if compose
+// is set. Nothing is signed or sent.
+func dryRun(compose *engine.Composition, msig bool) {
+	var payloads []*engine.Payload
+	err := fuzzer.WithSeed(fuzzSeed, 0, func() (e error) {
+		if compose != nil {
+			payloads, e = compose.Generate(Account, Uri)
+			return
+		}
+		if e = FormState.GeneratePayloads(Account); e != nil {
+			return
+		}
+		FormState.mux.RLock()
+		payloads = []*engine.Payload{FormState.payload()}
+		FormState.mux.RUnlock()
+		return
+	})
+	if err != nil {
+		errs.ErrChan <- "dry run: " + err.Error()
+		return
+	}
+	// the dry run gets its own client so that the signer it sets isn't left on the shared Api
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	api, err := Session.Api(ctx)
+	if err != nil {
+		errs.ErrChan <- "dry run: " + err.Error()
+		return
+	}
+	opts := *Opts
+	d, err := dryRunPayloads(api, &opts, Account, payloads, msig)
+	if err != nil {
+		errs.ErrChan <- "dry run: " + err.Error()
+		return
+	}
+	y, err := yaml.Marshal(d)
+	if err != nil {
+		errs.ErrChan <- err.Error()
+		return
+	}
+	title := "Dry Run: ok"
+	if !d.Ok() {
+		title = "Dry Run: would fail"
+	}
+	errs.ErrChan <- title + ", fee " + d.TotalFee
+	text := widget.NewMultiLineEntry()
+	text.SetText(string(y))
+	w := App.NewWindow(title)
+	w.SetContent(widget.NewScrollContainer(text))
+	w.Resize(fyne.NewSize(txW, txH))
+	w.Show()
+}
@@ -0,0 +1,212 @@
+package cryptonym
+
+import (
+	"fmt"
+	"fyne.io/fyne"
+	"fyne.io/fyne/canvas"
+	"fyne.io/fyne/dialog"
+	"fyne.io/fyne/layout"
+	"fyne.io/fyne/theme"
+	"fyne.io/fyne/widget"
+	"github.com/blockpane/cryptonym/engine"
+	errs "github.com/blockpane/cryptonym/errLog"
+	"github.com/blockpane/cryptonym/fuzzer"
+	"github.com/skip2/go-qrcode"
+	"gopkg.in/yaml.v3"
+	"image/color"
+	"io/ioutil"
+	"strings"
+)
+
+// offlineQrSize is the characters in each qr frame, small enough to scan off a laptop screen
+const offlineQrSize = 1000
+
+// exportComposition builds an unsigned transaction from the composer's actions, using the editor's zlib and delay
+// settings, and shows it for saving or scanning.
+func exportComposition() {
+	if Composer.Len() == 0 {
+		errs.ErrChan <- "add actions from the action editor first"
+		return
+	}
+	var payloads []*engine.Payload
+	err := fuzzer.WithSeed(fuzzSeed, 0, func() (e error) {
+		payloads, e = Composer.Generate(Account, Uri)
+		return
+	})
+	if err != nil {
+		errs.ErrChan <- err.Error()
+		return
+	}
+	po := engine.PackOptions{Compress: useZlib}
+	if deferTx {
+		po.DelaySecs = uint32(delayTxSec)
+	}
+	opts := *Opts
+	tx, err := engine.ExportUnsigned(Api, &opts, Account, payloads, po, engine.MaxOfflineExpiration)
+	if err != nil {
+		errs.ErrChan <- "could not export: " + err.Error()
+		return
+	}
+	OfflineTxWindow(tx)
+}
+
+// importOffline opens a transaction file, json or the qr frame text
+func importOffline(parent fyne.Window) {
+	dialog.ShowFileOpen(func(f fyne.URIReadCloser, err error) {
+		if err != nil {
+			errs.ErrChan <- err.Error()
+			return
+		}
+		if f == nil {
+			return
+		}
+		defer f.Close()
+		b, err := ioutil.ReadAll(f)
+		if err != nil {
+			errs.ErrChan <- err.Error()
+			return
+		}
+		tx, err := engine.ParseOfflineTx(b)
+		if err != nil {
+			errs.ErrChan <- "could not read transaction: " + err.Error()
+			return
+		}
+		OfflineTxWindow(tx)
+	}, parent)
+}
+
+// OfflineTxWindow shows what's in an offline transaction, and can save, sign or push it. The review is decoded
+// from the transaction itself using the abis in the file.
+func OfflineTxWindow(tx *engine.OfflineTx) {
+	w := App.NewWindow("Offline Transaction")
+	review := widget.NewMultiLineEntry()
+	qr := canvas.NewImageFromImage(disabledImage(imageSize, imageSize))
+	qr.FillMode = canvas.ImageFillOriginal
+	frameLabel := widget.NewLabel("")
+	var frames []string
+	current := 0
+	push := widget.NewButtonWithIcon("Push", theme.MailSendIcon(), nil)
+
+	showFrame := func() {
+		if len(frames) == 0 {
+			return
+		}
+		q, err := qrcode.New(frames[current], qrcode.Low)
+		if err != nil {
+			errs.ErrChan <- err.Error()
+			return
+		}
+		if strings.Contains(WinSettings.T, "Dark") {
+			q.ForegroundColor, q.BackgroundColor = darkestGrey, lightestGrey
+		} else {
+			q.ForegroundColor, q.BackgroundColor = color.Black, color.White
+		}
+		qr.Image = q.Image(imageSize)
+		qr.Refresh()
+		frameLabel.SetText(fmt.Sprintf("qr %d of %d", current+1, len(frames)))
+	}
+	refresh := func() {
+		r, err := tx.Review()
+		if err != nil {
+			review.SetText(err.Error())
+		} else {
+			review.SetText(string(r))
+		}
+		if frames, err = tx.Frames(offlineQrSize); err != nil {
+			errs.ErrChan <- err.Error()
+		}
+		current = 0
+		showFrame()
+		if tx.Signed() {
+			push.Enable()
+		} else {
+			push.Disable()
+		}
+	}
+
+	push.OnTapped = func() {
+		summary, result, err := engine.Push(Api, "/v1/chain/push_transaction", tx.Packed)
+		if err != nil {
+			if len(result) > 0 {
+				review.SetText(string(result))
+			}
+			errs.ErrChan <- "push failed: " + err.Error()
+			return
+		}
+		y, _ := yaml.Marshal(summary)
+		review.SetText(string(y))
+		errs.ErrChan <- "pushed offline transaction " + summary.TransactionId
+	}
+	sign := widget.NewButtonWithIcon("Sign", theme.ConfirmIcon(), func() {
+		// the signer from the settings, so keosd and external signers work offline too
+		signer, err := unlockedSigner(Account)
+		if err != nil {
+			errs.ErrChan <- "could not sign: " + err.Error()
+			return
+		}
+		keys, err := signer.RequiredKeys(Account)
+		if err != nil {
+			errs.ErrChan <- "could not sign: " + err.Error()
+			return
+		}
+		if len(keys) == 0 {
+			errs.ErrChan <- "could not sign: the signer has no key for " + string(Account.Actor)
+			return
+		}
+		if err = tx.Sign(signer, keys[0]); err != nil {
+			errs.ErrChan <- "could not sign: " + err.Error()
+			return
+		}
+		refresh()
+	})
+	disableWatchOnly(sign)
+	save := widget.NewButtonWithIcon("Save", theme.DocumentSaveIcon(), func() {
+		j, err := tx.Json()
+		if err != nil {
+			errs.ErrChan <- err.Error()
+			return
+		}
+		dialog.ShowFileSave(func(f fyne.URIWriteCloser, err error) {
+			if err != nil {
+				errs.ErrChan <- err.Error()
+				return
+			}
+			if f == nil {
+				return
+			}
+			defer f.Close()
+			if _, err = f.Write(append(j, '\n')); err != nil {
+				errs.ErrChan <- err.Error()
+				return
+			}
+			errs.ErrChan <- "saved transaction to " + f.URI().String()
+		}, w)
+	})
+	prev := widget.NewButtonWithIcon("", theme.NavigateBackIcon(), func() {
+		if current > 0 {
+			current -= 1
+			showFrame()
+		}
+	})
+	next := widget.NewButtonWithIcon("", theme.NavigateNextIcon(), func() {
+		if current < len(frames)-1 {
+			current += 1
+			showFrame()
+		}
+	})
+
+	refresh()
+	top := widget.NewHBox(save, sign, push)
+	w.SetContent(fyne.NewContainerWithLayout(layout.NewBorderLayout(top, nil, nil, nil),
+		top,
+		fyne.NewContainerWithLayout(layout.NewGridLayout(2),
+			widget.NewScrollContainer(review),
+			widget.NewVBox(
+				widget.NewHBox(layout.NewSpacer(), prev, frameLabel, next, layout.NewSpacer()),
+				widget.NewHBox(layout.NewSpacer(), qr, layout.NewSpacer()),
+			),
+		),
+	))
+	w.Resize(fyne.NewSize(W, H))
+	w.Show()
+}
@@ -4,13 +4,17 @@ import (
 	"bufio"
 	"bytes"
 	"compress/zlib"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"fyne.io/fyne"
 	"fyne.io/fyne/layout"
 	"fyne.io/fyne/theme"
 	"fyne.io/fyne/widget"
+	"github.com/blockpane/cryptonym/engine"
 	errs "github.com/blockpane/cryptonym/errLog"
+	"github.com/blockpane/cryptonym/fuzzer"
 	"github.com/fioprotocol/fio-go"
 	"github.com/fioprotocol/fio-go/eos"
 	"gopkg.in/yaml.v3"
@@ -23,6 +27,7 @@ import (
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -31,6 +36,8 @@ var (
 	requestText   = widget.NewMultiLineEntry()
 	responseText  = widget.NewMultiLineEntry()
 	stopRequested = make(chan bool)
+	// SuccessCorpus is the action data from successful transactions, it's what gets mutated
+	SuccessCorpus = engine.NewCorpus(64)
 )
 
 type TxResult struct {
@@ -41,43 +48,15 @@ type TxResult struct {
 	Success  bool
 	Index    int
 	Summary  string
+	// Seed and Iteration regenerate the payload, see fuzzer.WithSeed
+	Seed      int64
+	Iteration int
+	// Base is the action data that was mutated, and Mutations what was changed. Empty if it was generated.
+	Base      json.RawMessage
+	Mutations []string
 }
 
-type TxSummary struct {
-	TransactionId string `json:"transaction_id" yaml:"Transaction Id"`
-	Processed     struct {
-		BlockNum  uint32 `json:"block_num" yaml:"Block Number"`
-		BlockTime string `json:"block_time" yaml:"Block Time"`
-		Receipt   struct {
-			Status string `json:"status" yaml:"Status"`
-		} `json:"receipt" yaml:"Receipt,omitempty"`
-	} `json:"processed" yaml:"Processed,omitempty"`
-	ErrorCode  interface{} `json:"error_code" yaml:"Error,omitempty"`                             // is this a string, int, varies on context?
-	TotalBytes int         `json:"total_bytes,omitempty" yaml:"TX Size of All Actions,omitempty"` // this is field we calculate later
-}
-
-// to get the *real* size of what was transacted, we need to dig into the action traces and look at the length
-// of the hex_data field, which is buried in the response.
-type txTraces struct {
-	Processed struct {
-		ActionTraces []struct {
-			Act struct {
-				HexData string `json:"hex_data"`
-			} `json:"act"`
-		} `json:"action_traces"`
-	} `json:"processed"`
-}
-
-func (tt txTraces) size() int {
-	if len(tt.Processed.ActionTraces) == 0 {
-		return 0
-	}
-	var sz int
-	for _, t := range tt.Processed.ActionTraces {
-		sz = sz + (len(t.Act.HexData) / 2)
-	}
-	return sz
-}
+type TxSummary = engine.TxSummary
 
 type txResultOpts struct {
 	repeat      int
@@ -93,6 +72,11 @@ type txResultOpts struct {
 	msigName    func() string
 	wrap        bool
 	wrapActor   string
+	seed        int64
+	// compose sends the composer's actions in one transaction instead of the editor's action
+	compose *engine.Composition
+	// load paces loop mode, and stops it after a duration or once the budget is spent
+	load engine.LoadProfile
 }
 
 func TxResultsWindow(win *txResultOpts, api *fio.API, opts *fio.TxOptions, account *fio.Account) {
@@ -168,8 +152,56 @@ func TxResultsWindow(win *txResultOpts, api *fio.API, opts *fio.TxOptions, accou
 		}
 	}(successChan, failedChan)
 
-	run := func() {}
+	// run sends the next iterations for the seed, or only the one in replay. If mutate is set the payloads
+	// are mutated instead of generated by the editor.
+	run := func(replay *TxResult, mutate *engine.Mutation, worker fuzzer.Worker) {}
+	// startWorkers starts every worker on the next iterations of the seed
+	startWorkers := func() {}
+	var mutation *engine.Mutation
+	var nextIteration int64
+	// a new load run starts with each batch of workers, it's nil without a profile
+	var (
+		loadRun  *engine.LoadRun
+		loadDone *sync.Once
+	)
+	startLoad := func() bool {
+		loadRun = nil
+		if !win.loop || !win.load.Active() {
+			return true
+		}
+		l, err := win.load.Start(loadBalance(api, account), loadFee(api, account, win.compose, win.msig))
+		if err != nil {
+			errs.ErrChan <- "could not start the load test: " + err.Error()
+			return false
+		}
+		loadRun, loadDone = l, &sync.Once{}
+		errs.ErrChan <- "load test: " + win.load.String()
+		return true
+	}
 	mux := sync.Mutex{}
+	// failures are grouped by signature, this isn't cleared when the list of results is trimmed
+	triage := engine.NewTriage()
+	triaged := func(output TxResult, data json.RawMessage, resp []byte) {
+		if !json.Valid(data) {
+			data = nil
+		}
+		contract, action := FormState.Contract, FormState.Action
+		if win.compose != nil {
+			// composed data is every action, it can't be sent as the editor's action
+			contract, action = "composition", win.compose.String()
+		}
+		sig, isNew := triage.Add(engine.Reproducer{
+			Contract:  contract,
+			Action:    action,
+			Endpoint:  actionEndPointActive,
+			Seed:      output.Seed,
+			Iteration: output.Iteration,
+			Data:      data,
+		}, resp)
+		if isNew {
+			errs.ErrChan <- "new failure: " + sig.String()
+		}
+	}
 	Results = make([]TxResult, 0)
 
 	summaryGroup := widget.NewGroupWithScroller("Transaction Result")
@@ -274,7 +306,68 @@ func TxResultsWindow(win *txResultOpts, api *fio.API, opts *fio.TxOptions, accou
 			return
 		}
 		exit = false
-		go run()
+		if startLoad() {
+			go run(nil, nil, fuzzer.Worker{Count: 1})
+		}
+	})
+	replayButton := widget.NewButtonWithIcon("replay", theme.MediaReplayIcon(), func() {
+		if running || len(Results) <= fullResponseIndex {
+			return
+		}
+		replay := Results[fullResponseIndex]
+		errs.ErrChan <- fmt.Sprintf("replaying seed %d iteration %d", replay.Seed, replay.Iteration)
+		var m *engine.Mutation
+		if len(replay.Base) > 0 {
+			m = &engine.Mutation{Contract: FormState.Contract, Action: FormState.Action, Fields: FormState.Fields()}
+			if mutation != nil {
+				*m = *mutation
+			}
+			m.Base = replay.Base
+		}
+		exit = false
+		go run(&replay, m, fuzzer.Worker{Count: 1})
+	})
+	// replaying a run starts the selected result's seed over from the first iteration with the same workers
+	replayRunButton := widget.NewButtonWithIcon("replay run", theme.MediaReplayIcon(), func() {
+		if running || len(Results) <= fullResponseIndex {
+			return
+		}
+		replay := Results[fullResponseIndex]
+		if len(replay.Base) > 0 {
+			errs.ErrChan <- "mutated results can only be replayed one iteration at a time"
+			return
+		}
+		errs.ErrChan <- fmt.Sprintf("replaying the run for seed %d from iteration 0", replay.Seed)
+		win.seed = replay.Seed
+		atomic.StoreInt64(&nextIteration, 0)
+		exit = false
+		startWorkers()
+	})
+	mutateButton := widget.NewButtonWithIcon("mutate", theme.ContentRedoIcon(), func() {
+		if running || len(Results) <= fullResponseIndex {
+			return
+		}
+		base := Results[fullResponseIndex]
+		data, err := inflate(base.FullReq)
+		if !base.Success || win.msig || win.compose != nil || err != nil || !json.Valid(data) {
+			errs.ErrChan <- "select a successful transaction (that isn't a proposal or composed) to mutate"
+			return
+		}
+		go func() {
+			mutation = &engine.Mutation{
+				Contract: FormState.Contract,
+				Action:   FormState.Action,
+				Fields:   FormState.Fields(),
+				Base:     data,
+				Corpus:   SuccessCorpus.Get(FormState.Contract, FormState.Action),
+				Names:    fuzzer.ExistingFioAddresses(Uri),
+			}
+			errs.ErrChan <- fmt.Sprintf("mutating iteration %d with %d other successful transactions", base.Iteration, len(mutation.Corpus))
+			exit = false
+			if startLoad() {
+				run(nil, mutation, fuzzer.Worker{Count: 1})
+			}
+		}()
 	})
 	stopButton = widget.NewButtonWithIcon("stop", theme.CancelIcon(), func() {
 		if running {
@@ -284,10 +377,18 @@ func TxResultsWindow(win *txResultOpts, api *fio.API, opts *fio.TxOptions, accou
 
 	clearButton := widget.NewButtonWithIcon("clear results", theme.ContentRemoveIcon(), func() {
 		clear()
+		triage.Reset()
+	})
+	triageButton := widget.NewButtonWithIcon("triage", theme.WarningIcon(), func() {
+		TriageWindow(triage, win.window)
 	})
 	closeRow = widget.NewGroup(" Control ",
 		stopButton,
 		resendButton,
+		replayButton,
+		replayRunButton,
+		mutateButton,
+		triageButton,
 		clearButton,
 		closeButton,
 		layout.NewSpacer(),
@@ -385,7 +486,11 @@ func TxResultsWindow(win *txResultOpts, api *fio.API, opts *fio.TxOptions, accou
 			if i >= len(Results) {
 				return
 			}
-			reqChan <- string(Results[i].Req)
+			header := fmt.Sprintf("seed: %d iteration: %d\n", Results[i].Seed, Results[i].Iteration)
+			if len(Results[i].Mutations) > 0 {
+				header = header + "mutated: " + strings.Join(Results[i].Mutations, ", ") + "\n"
+			}
+			reqChan <- header + "\n" + string(Results[i].Req)
 			respChan <- string(Results[i].Resp)
 			fullRespChan <- i
 		})
@@ -393,24 +498,33 @@ func TxResultsWindow(win *txResultOpts, api *fio.API, opts *fio.TxOptions, accou
 		repaint()
 	}
 
-	run = func() {
+	run = func(replay *TxResult, mutate *engine.Mutation, worker fuzzer.Worker) {
 		defer func() {
 			if running {
 				stopRequested <- true
 			}
 		}()
-		// give each thread it's own http client pool:
-		workerApi, workerOpts, err := fio.NewConnection(account.KeyBag, api.BaseURL)
+		// give each thread it's own client, requests in flight are cancelled when stopped:
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		workerApi, err := Session.Api(ctx)
 		if err != nil {
 			errs.ErrChan <- err.Error()
 			errs.ErrChan <- "ERROR: could not get new client connection"
 			return
 		}
-		workerApi.Header.Set("User-Agent", "fio-cryptonym-wallet")
+		workerOpts := Session.Opts()
+		if err = applySigner(workerApi, account); err != nil {
+			errs.ErrChan <- "ERROR: " + err.Error()
+			return
+		}
 		running = true
 		stopButton.Enable()
 		bombsAway.Disable()
 		resendButton.Disable()
+		replayButton.Disable()
+		replayRunButton.Disable()
+		mutateButton.Disable()
 		closeButton.Disable()
 
 		defer func() {
@@ -418,6 +532,9 @@ func TxResultsWindow(win *txResultOpts, api *fio.API, opts *fio.TxOptions, accou
 			stopButton.Disable()
 			bombsAway.Enable()
 			resendButton.Enable()
+			replayButton.Enable()
+			replayRunButton.Enable()
+			mutateButton.Enable()
 			closeButton.Enable()
 		}()
 
@@ -430,6 +547,16 @@ func TxResultsWindow(win *txResultOpts, api *fio.API, opts *fio.TxOptions, accou
 		default:
 			end = 1
 		}
+		load, done := loadRun, loadDone
+		if replay != nil {
+			end = 1
+			load = nil
+		}
+		// the tables are read the first time an on chain value is generated, do it now instead of while holding
+		// the fuzzer's seed
+		if replay == nil && mutate == nil && needsChainState(win.compose) {
+			fuzzer.ChainState(Uri)
+		}
 		finished := make(chan bool)
 		wg := sync.WaitGroup{}
 		wg.Add(1)
@@ -443,15 +570,75 @@ func TxResultsWindow(win *txResultOpts, api *fio.API, opts *fio.TxOptions, accou
 				if exit {
 					return
 				}
+				if load != nil {
+					if e := load.Wait(ctx); e != nil {
+						if ctx.Err() == nil {
+							done.Do(func() {
+								errs.ErrChan <- e.Error() + ", " + load.Status()
+							})
+						}
+						return
+					}
+				}
+				seed, iteration := win.seed, int(atomic.AddInt64(&nextIteration, 1)-1)
+				if replay != nil {
+					seed, iteration = replay.Seed, replay.Iteration
+				}
 				output := TxResult{
-					Summary: fmt.Sprintf("%s", time.Now().Format("15:04:05.000")),
-					Index:   i,
+					Summary:   fmt.Sprintf("%s #%d", time.Now().Format("15:04:05.000"), iteration),
+					Index:     i,
+					Seed:      seed,
+					Iteration: iteration,
+				}
+				// only generating holds the fuzzer's seed, packing and signing happen after so workers don't wait on
+				// each other's network calls. The form is shared, so its payload is copied before the next worker
+				// generates.
+				var (
+					raw json.RawMessage
+					tx  *eos.PackedTransaction
+					err error
+				)
+				var e error
+				if mutate != nil {
+					var payload *engine.Payload
+					output.Base = mutate.Base
+					payload, output.Mutations, e = mutate.Payload(seed, iteration)
+					if e == nil {
+						raw, tx, err = packPayload(workerApi, workerOpts, account, payload, win.msig)
+					}
+				} else if win.compose != nil {
+					var payloads []*engine.Payload
+					worker.Sent = i
+					e = fuzzer.WithWorker(seed, iteration, worker, func() (e error) {
+						payloads, e = win.compose.Generate(account, Uri)
+						return
+					})
+					if e == nil {
+						raw, tx, err = packComposition(workerApi, workerOpts, account, payloads, win.msig)
+					}
+				} else {
+					var payload *engine.Payload
+					worker.Sent = i
+					e = fuzzer.WithWorker(seed, iteration, worker, func() error {
+						if e := FormState.GeneratePayloads(account); e != nil {
+							return e
+						}
+						payload = FormState.generated()
+						return nil
+					})
+					if e == nil && payload != nil {
+						raw, tx, err = packPayload(workerApi, workerOpts, account, payload, win.msig)
+					}
+				}
+				if errors.Is(e, fuzzer.ErrExhausted) {
+					errs.ErrChan <- e.Error()
+					return
 				}
-				e := FormState.GeneratePayloads(account)
 				if e != nil {
 					errs.ErrChan <- e.Error()
 					errs.ErrChan <- "there was a problem generating dynamic payloads"
 					output.Resp = []byte(e.Error())
+					triaged(output, raw, output.Resp)
 					Results = append(Results, output)
 					newButton(output.Summary, len(Results)-1, true)
 					continue
@@ -459,8 +646,7 @@ func TxResultsWindow(win *txResultOpts, api *fio.API, opts *fio.TxOptions, accou
 				if exit {
 					return
 				}
-				raw, tx, err := FormState.PackAndSign(workerApi, workerOpts, account, win.msig)
-				if tx == nil || tx.PackedTransaction == nil {
+				if (tx == nil || tx.PackedTransaction == nil) && win.compose == nil {
 					errs.ErrChan <- "sending a signed transaction with null action data"
 					empty := fio.NewAction(eos.AccountName(FormState.Contract), eos.ActionName(FormState.Action), account.Actor, nil)
 					_, tx, err = workerApi.SignTransaction(fio.NewTransaction([]*fio.Action{empty}, workerOpts), workerOpts.ChainID, fio.CompressionNone)
@@ -473,6 +659,7 @@ func TxResultsWindow(win *txResultOpts, api *fio.API, opts *fio.TxOptions, accou
 					if tx == nil || tx.PackedTransaction == nil {
 						errs.ErrChan <- "did not build a valid transaction, refusing to continue."
 						output.Resp = []byte("could not build the transaction. Don't worry it's me, not you.")
+						triaged(output, raw, output.Resp)
 						Results = append(Results, output)
 						newButton(output.Summary, len(Results)-1, true)
 						continue
@@ -481,6 +668,7 @@ func TxResultsWindow(win *txResultOpts, api *fio.API, opts *fio.TxOptions, accou
 					if err != nil {
 						errs.ErrChan <- "Problem repacking transaction to embed in msig propose " + err.Error()
 						output.Resp = []byte(err.Error())
+						triaged(output, raw, output.Resp)
 						Results = append(Results, output)
 						newButton(output.Summary, len(Results)-1, true)
 						continue
@@ -497,12 +685,13 @@ func TxResultsWindow(win *txResultOpts, api *fio.API, opts *fio.TxOptions, accou
 						untx.Expiration = eos.JSONTime{Time: time.Unix(0, 0)}
 						untx.RefBlockNum = 0
 						untx.RefBlockPrefix = 0
-						for i := range untx.Actions {
+						// composed actions keep their own authorization
+						for i := 0; i < len(untx.Actions) && win.compose == nil; i++ {
 							untx.Actions[i].Authorization = []eos.PermissionLevel{
 								{Actor: eos.AccountName(win.wrapActor), Permission: "active"},
 							}
 						}
-					} else {
+					} else if win.compose == nil {
 						for i := range ntx.Actions {
 							ntx.Actions[i].Authorization = []eos.PermissionLevel{{
 								Actor:      eos.AccountName(win.msigAccount),
@@ -529,7 +718,7 @@ func TxResultsWindow(win *txResultOpts, api *fio.API, opts *fio.TxOptions, accou
 							Proposer:     account.Actor,
 							ProposalName: eos.Name(win.msigName()),
 							Requested:    requested,
-							MaxFee:       fio.Tokens(fio.GetMaxFee(fio.FeeMsigPropose))*uint64(len(packed.PackedTransaction)/1000) + fio.Tokens(1.0),
+							MaxFee:       fio.Tokens(liveFee(workerApi, fio.FeeMsigPropose))*uint64(len(packed.PackedTransaction)/1000) + fio.Tokens(1.0),
 							Trx:          ntx,
 						}
 					} else if win.wrap {
@@ -547,7 +736,7 @@ func TxResultsWindow(win *txResultOpts, api *fio.API, opts *fio.TxOptions, accou
 							Proposer:     account.Actor,
 							ProposalName: eos.Name(win.msigName()),
 							Requested:    requested,
-							MaxFee:       fio.Tokens(fio.GetMaxFee(fio.FeeMsigPropose))*uint64(len(packed.PackedTransaction)/1000) + fio.Tokens(1.0),
+							MaxFee:       fio.Tokens(liveFee(workerApi, fio.FeeMsigPropose))*uint64(len(packed.PackedTransaction)/1000) + fio.Tokens(1.0),
 							Trx:          wTx,
 						}
 					}
@@ -567,6 +756,7 @@ func TxResultsWindow(win *txResultOpts, api *fio.API, opts *fio.TxOptions, accou
 					if err != nil {
 						errs.ErrChan <- "Problem signing msig propose " + err.Error()
 						output.Resp = []byte(err.Error())
+						triaged(output, raw, output.Resp)
 						Results = append(Results, output)
 						newButton(output.Summary, len(Results)-1, true)
 						continue
@@ -580,6 +770,7 @@ func TxResultsWindow(win *txResultOpts, api *fio.API, opts *fio.TxOptions, accou
 					errs.ErrChan <- err.Error()
 					errs.ErrChan <- "could not marshall into a TX"
 					output.Resp = []byte(err.Error())
+					triaged(output, raw, output.Resp)
 					Results = append(Results, output)
 					newButton(output.Summary, len(Results)-1, true)
 					continue
@@ -587,6 +778,7 @@ func TxResultsWindow(win *txResultOpts, api *fio.API, opts *fio.TxOptions, accou
 				if tx == nil || tx.PackedTransaction == nil {
 					errs.ErrChan <- "did not build a valid transaction, refusing to continue."
 					output.Resp = []byte("could not build the transaction. Don't worry it's me, not you.")
+					triaged(output, raw, output.Resp)
 					Results = append(Results, output)
 					newButton(output.Summary, len(Results)-1, true)
 					continue
@@ -603,12 +795,17 @@ func TxResultsWindow(win *txResultOpts, api *fio.API, opts *fio.TxOptions, accou
 				result, err := workerApi.PushEndpointRaw(actionEndPointActive, tx)
 				if err != nil {
 					errs.ErrChan <- err.Error()
+					if len(result) > 0 {
+						triaged(output, raw, result)
+					} else {
+						triaged(output, raw, []byte(err.Error()))
+					}
 					if win.hideFail {
 						failedChan <- true
 						continue
 					}
 					output.Resp = []byte(err.Error())
-					output.Summary = fmt.Sprintf("%s", time.Now().Format("15:04:05.000"))
+					output.Summary = fmt.Sprintf("%s #%d", time.Now().Format("15:04:05.000"), iteration)
 					buf := bytes.Buffer{}
 					zWriter, _ := zlib.NewWriterLevel(&buf, zlib.BestCompression)
 					if len(result) > 0 {
@@ -627,12 +824,12 @@ func TxResultsWindow(win *txResultOpts, api *fio.API, opts *fio.TxOptions, accou
 				}
 				// store two responses, the summary -- displayed by default, and zlib compressed full response.
 				// the full response is huge, and will seriously screw up the display and consume a lot of memory!
-				summary := &TxSummary{}
-				err = json.Unmarshal(result, summary)
+				summary, err := engine.ParseTxSummary(result)
 				if err != nil {
 					errs.ErrChan <- err.Error()
 					output.Resp = []byte(err.Error())
-					output.Summary = fmt.Sprintf("%s", time.Now().Format("15:04:05.000"))
+					output.Summary = fmt.Sprintf("%s #%d", time.Now().Format("15:04:05.000"), iteration)
+					triaged(output, raw, output.Resp)
 					if win.hideFail {
 						failedChan <- true
 						continue
@@ -641,11 +838,13 @@ func TxResultsWindow(win *txResultOpts, api *fio.API, opts *fio.TxOptions, accou
 					newButton(output.Summary, len(Results)-1, true)
 					continue
 				}
-				// get the real tx size, since we got this far assume we have a valid tx result
-				sz := &txTraces{}
-				_ = json.Unmarshal(result, sz)
-				summary.TotalBytes = sz.size()
 
+				output.Success = true
+				if !win.msig && win.compose != nil {
+					SuccessCorpus.AddComposed(raw)
+				} else if !win.msig {
+					SuccessCorpus.Add(FormState.Contract, FormState.Action, raw)
+				}
 				if win.hideSucc {
 					successChan <- true
 					continue
@@ -665,6 +864,7 @@ func TxResultsWindow(win *txResultOpts, api *fio.API, opts *fio.TxOptions, accou
 			select {
 			case _ = <-stopRequested:
 				exit = true
+				cancel()
 			case _ = <-finished:
 				wg.Wait()
 				return
@@ -672,9 +872,14 @@ func TxResultsWindow(win *txResultOpts, api *fio.API, opts *fio.TxOptions, accou
 		}
 	}
 
-	for w := 0; w < workers; w++ {
-		go run()
+	startWorkers = func() {
+		if startLoad() {
+			for w := 0; w < workers; w++ {
+				go run(nil, nil, fuzzer.Worker{Id: w, Count: workers})
+			}
+		}
 	}
+	startWorkers()
 	time.Sleep(250 * time.Millisecond)
 	setGrid()
 
@@ -701,6 +906,16 @@ func TxResultsWindow(win *txResultOpts, api *fio.API, opts *fio.TxOptions, accou
 	}
 }
 
+// inflate reads the compressed FullReq and FullResp
+func inflate(b []byte) ([]byte, error) {
+	zlReader, err := zlib.NewReader(bytes.NewReader(b))
+	if err != nil {
+		return nil, err
+	}
+	defer zlReader.Close()
+	return ioutil.ReadAll(zlReader)
+}
+
 func ShowFullResponse(b []byte, win fyne.Window) {
 	FullResponseText := widget.NewMultiLineEntry()
 	FullActionRespWin := App.NewWindow("Full Response")
@@ -0,0 +1,106 @@
+package cryptonym
+
+import (
+	"encoding/json"
+	"fmt"
+	"fyne.io/fyne"
+	"fyne.io/fyne/dialog"
+	"fyne.io/fyne/layout"
+	"fyne.io/fyne/theme"
+	"fyne.io/fyne/widget"
+	"github.com/blockpane/cryptonym/engine"
+	errs "github.com/blockpane/cryptonym/errLog"
+)
+
+// TriageWindow lists the failures from a fuzzing run grouped by error signature
+func TriageWindow(triage *engine.Triage, parent fyne.Window) {
+	w := App.NewWindow("Failure Triage")
+	summary := widget.NewLabel("")
+	list := widget.NewVBox()
+
+	refresh := func() {
+		buckets := triage.Buckets()
+		summary.SetText(p.Sprintf("%d failures, %d distinct", triage.Failures(), len(buckets)))
+		list.Children = make([]fyne.CanvasObject, 0, len(buckets))
+		for i := range buckets {
+			b := buckets[i]
+			sig := b.Signature.String()
+			if len(sig) > 120 {
+				sig = sig[:120] + "..."
+			}
+			list.Append(widget.NewHBox(
+				fyne.NewContainerWithLayout(layout.NewFixedGridLayout(fyne.NewSize(80, 30)),
+					widget.NewLabelWithStyle(p.Sprintf("%d", b.Count), fyne.TextAlignTrailing, fyne.TextStyle{Bold: true}),
+				),
+				widget.NewButtonWithIcon("first", theme.VisibilityIcon(), func() {
+					showReproducer(b.First)
+				}),
+				widget.NewButtonWithIcon("last", theme.VisibilityIcon(), func() {
+					showReproducer(b.Last)
+				}),
+				widget.NewButtonWithIcon("export", theme.DocumentSaveIcon(), func() {
+					exportReproducer(minimalReproducer(b), w)
+				}),
+				widget.NewLabelWithStyle(sig, fyne.TextAlignLeading, fyne.TextStyle{Monospace: true}),
+			))
+		}
+		list.Refresh()
+	}
+
+	top := widget.NewHBox(widget.NewButtonWithIcon("refresh", theme.ViewRefreshIcon(), refresh), summary)
+	w.SetContent(fyne.NewContainerWithLayout(layout.NewBorderLayout(top, nil, nil, nil),
+		top,
+		widget.NewScrollContainer(list),
+	))
+	refresh()
+	w.Resize(fyne.NewSize(W, H))
+	w.SetOnClosed(func() {
+		parent.RequestFocus()
+	})
+	w.Show()
+}
+
+// minimalReproducer is the example with the smallest request
+func minimalReproducer(b engine.Bucket) engine.Reproducer {
+	if len(b.Last.Data) > 0 && len(b.Last.Data) < len(b.First.Data) {
+		return b.Last
+	}
+	return b.First
+}
+
+func showReproducer(r engine.Reproducer) {
+	j, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		errs.ErrChan <- err.Error()
+		return
+	}
+	text := widget.NewMultiLineEntry()
+	text.SetText(string(j))
+	w := App.NewWindow(fmt.Sprintf("Seed %d Iteration %d", r.Seed, r.Iteration))
+	w.SetContent(widget.NewScrollContainer(text))
+	w.Resize(fyne.NewSize(W, H))
+	w.Show()
+}
+
+func exportReproducer(r engine.Reproducer, parent fyne.Window) {
+	j, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		errs.ErrChan <- err.Error()
+		return
+	}
+	dialog.ShowFileSave(func(f fyne.URIWriteCloser, err error) {
+		if err != nil {
+			errs.ErrChan <- err.Error()
+			return
+		}
+		if f == nil {
+			return
+		}
+		defer f.Close()
+		if _, err = f.Write(append(j, '\n')); err != nil {
+			errs.ErrChan <- err.Error()
+			return
+		}
+		errs.ErrChan <- "saved reproducer to " + f.URI().String()
+	}, parent)
+}
@@ -258,7 +258,7 @@ func VoteContent(content chan fyne.CanvasObject, refresh chan bool) {
 			return names
 		}()
 		addrsSelect := widget.NewSelect(myAddrs, func(s string) {
-			fee := fio.GetMaxFee(`vote_producer`)
+			fee := liveFeeFor(Api, fio.FeeVoteProducer, s)
 			if err == nil {
 				voteButton.SetText(pp.Sprintf("Vote! %s %g", fio.FioSymbol, fee))
 			}
@@ -271,15 +271,19 @@ func VoteContent(content chan fyne.CanvasObject, refresh chan bool) {
 						prods = append(prods, k)
 					}
 				}
-				vp := fio.NewVoteProducer(prods, Account.Actor, addrsSelect.Selected)
+				vp := withLiveFee(Api, fio.NewVoteProducer(prods, Account.Actor, addrsSelect.Selected), fio.FeeVoteProducer)
 				var result string
-				resp, err := Api.SignPushTransaction(fio.NewTransaction(
-					[]*fio.Action{vp},
-					Opts,
-				),
-					Opts.ChainID,
-					fio.CompressionNone,
-				)
+				var resp *eos.PushTransactionFullResp
+				err := applySigner(Api, Account)
+				if err == nil {
+					resp, err = Api.SignPushTransaction(fio.NewTransaction(
+						[]*fio.Action{vp},
+						Opts,
+					),
+						Opts.ChainID,
+						fio.CompressionNone,
+					)
+				}
 				if err != nil {
 					result = err.Error()
 					errs.ErrChan <- err.Error()
@@ -316,6 +320,7 @@ func VoteContent(content chan fyne.CanvasObject, refresh chan bool) {
 			addrsSelect,
 			voteButton,
 			refreshButton,
+			watchOnlyNotice(),
 			layout.NewSpacer(),
 		)
 
@@ -343,7 +348,7 @@ func VoteContent(content chan fyne.CanvasObject, refresh chan bool) {
 						}
 					}
 					countLabel.SetText(fmt.Sprintf("%d of 30 votes selected", votes))
-					if changed != 0 && votes <= 30 && addrsSelect.Selected != "" && addrsSelect.Selected != "(Select one)" {
+					if changed != 0 && votes <= 30 && addrsSelect.Selected != "" && addrsSelect.Selected != "(Select one)" && !WatchOnly {
 						voteButton.Enable()
 						continue
 					}
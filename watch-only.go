@@ -0,0 +1,90 @@
+package cryptonym
+
+import (
+	"errors"
+	"fmt"
+	"fyne.io/fyne"
+	"fyne.io/fyne/widget"
+	"github.com/fioprotocol/fio-go"
+	"github.com/fioprotocol/fio-go/eos"
+	"strings"
+)
+
+// WatchOnly is set when the loaded account was entered as a public key, actor, or FIO address. Tabs show
+// the account's data, but everything that would sign is disabled.
+var WatchOnly bool
+
+const watchOnlyReason = "Watch-only account: load a private key, or select a remote signer, to send transactions."
+
+var errWatchOnly = errors.New("watch-only account, signing is disabled")
+
+// disableWatchOnly is used on every button that signs a transaction
+func disableWatchOnly(buttons ...*widget.Button) {
+	if !WatchOnly {
+		return
+	}
+	for _, b := range buttons {
+		if b != nil {
+			b.Disable()
+		}
+	}
+}
+
+// watchOnlyNotice explains why the signing buttons are disabled, it is hidden for normal accounts
+func watchOnlyNotice() fyne.CanvasObject {
+	l := widget.NewLabelWithStyle(watchOnlyReason, fyne.TextAlignCenter, fyne.TextStyle{Italic: true})
+	if !WatchOnly {
+		l.Hide()
+	}
+	return l
+}
+
+// NewWatchAccount looks up an account from a public key, actor, or FIO address. The account has no private
+// key. An actor that doesn't have a single active key will have an empty PubKey.
+func NewWatchAccount(api *fio.API, query string) (*fio.Account, error) {
+	query = strings.TrimSpace(query)
+	switch {
+	case strings.HasPrefix(query, "FIO") && len(query) == 53:
+		return NewPubKeyAccount(query)
+
+	case strings.Contains(query, "@"):
+		if api == nil || api.BaseURL == "" {
+			return nil, errors.New("not connected, can't look up " + query)
+		}
+		pa, found, err := api.PubAddressLookup(fio.Address(query), "FIO", "FIO")
+		if err != nil {
+			return nil, err
+		}
+		if !found {
+			return nil, fmt.Errorf("%s was not found", query)
+		}
+		acc, err := NewPubKeyAccount(pa.PublicAddress)
+		if err != nil {
+			return nil, err
+		}
+		acc.Addresses = append(acc.Addresses, fio.FioName{FioAddress: query})
+		return acc, nil
+
+	case len(query) == 12:
+		if api == nil || api.BaseURL == "" {
+			return nil, errors.New("not connected, can't look up " + query)
+		}
+		resp, err := api.GetAccount(eos.AccountName(query))
+		if err != nil {
+			return nil, err
+		}
+		acc := &fio.Account{
+			KeyBag:    eos.NewKeyBag(),
+			Actor:     eos.AccountName(query),
+			Addresses: make([]fio.FioName, 0),
+			Domains:   make([]fio.FioName, 0),
+		}
+		for _, perm := range resp.Permissions {
+			if perm.PermName == "active" && len(perm.RequiredAuth.Keys) == 1 {
+				acc.PubKey = fioPubKey(perm.RequiredAuth.Keys[0].PublicKey)
+			}
+		}
+		return acc, nil
+	}
+	return nil, fmt.Errorf("%q is not a public key, actor, or FIO address", query)
+}
@@ -0,0 +1,93 @@
+package cryptonym
+
+import (
+	"encoding/json"
+	"fyne.io/fyne/widget"
+	"github.com/fioprotocol/fio-go"
+	"github.com/fioprotocol/fio-go/eos"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestNewWatchAccount(t *testing.T) {
+	watched, _ := fio.NewAccountFromWif("5KC6Edd4BcKTLnRuGj2c8TRT9oLuuXLd3ZuCGxM9iNngc3D8S93")
+	pub := watched.PubKey
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/chain/get_pub_address", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]string{"public_address": pub})
+	})
+	mux.HandleFunc("/v1/chain/get_account", func(w http.ResponseWriter, r *http.Request) {
+		key, _ := json.Marshal(pub)
+		_, _ = w.Write([]byte(`{"account_name":"abcdefghijkl","permissions":[{"perm_name":"active","parent":"owner","required_auth":{"threshold":1,"keys":[{"key":` + string(key) + `,"weight":1}]}}]}`))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+	api := &fio.API{API: eos.New(server.URL)}
+
+	actor, _ := fio.ActorFromPub(pub)
+	for _, query := range []string{pub, "watched@fiotestnet", "abcdefghijkl"} {
+		acc, err := NewWatchAccount(api, query)
+		if err != nil {
+			t.Error(query, err)
+			continue
+		}
+		if acc.PubKey != pub {
+			t.Errorf("%s: expected %s, got %s", query, pub, acc.PubKey)
+		}
+		if HasPrivateKey(acc) {
+			t.Errorf("%s: watch account has a private key", query)
+		}
+		if query != "abcdefghijkl" && acc.Actor != actor {
+			t.Errorf("%s: expected actor %s, got %s", query, actor, acc.Actor)
+		}
+	}
+	if _, err := NewWatchAccount(api, "not a thing"); err == nil {
+		t.Error("expected an error for an invalid query")
+	}
+}
+
+func TestWatchOnlySigning(t *testing.T) {
+	defer func() { WatchOnly = false }()
+	Settings = DefaultSettings()
+	acc, _ := fio.NewAccountFromWif("5JBbUG5SDpLWxvBKihMeXLENinUzdNKNeozLas23Mj6ZNhz3hLS")
+	api := &fio.API{API: eos.New("http://127.0.0.1:1")}
+
+	WatchOnly = true
+	if err := applySigner(api, acc); err != errWatchOnly {
+		t.Errorf("expected signing to be refused for watch-only, got %v", err)
+	}
+	// a dry run only lists the public key
+	listing, err := newPubKeySigner(acc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if keys, _ := listing.AvailableKeys(); len(keys) != 1 || fioPubKey(keys[0]) != acc.PubKey {
+		t.Error("expected the account's public key, got", keys)
+	}
+	if _, err = listing.Sign(&eos.SignedTransaction{}, nil); err != errWatchOnly {
+		t.Error("the key listing signer should not sign")
+	}
+	b := widget.NewButton("sign", func() {})
+	disableWatchOnly(b)
+	if !b.Disabled() {
+		t.Error("button was not disabled")
+	}
+
+	WatchOnly = false
+	if err := applySigner(api, acc); err != nil {
+		t.Error(err)
+	}
+
+	// scenarios sign with the shared session
+	Session.SetAccount(acc)
+	defer Session.SetAccount(nil)
+	WatchOnly = true
+	if err := applySessionSigner(); err != errWatchOnly {
+		t.Errorf("expected scenarios to be refused for watch-only, got %v", err)
+	}
+	WatchOnly = false
+	if err := applySessionSigner(); err != nil {
+		t.Error(err)
+	}
+}